@@ -0,0 +1,103 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/config"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// streamableHTTPEndpoint is the path the streamable-http transport serves MCP on
+const streamableHTTPEndpoint = "/mcp"
+
+// newAuthenticator creates the authenticator for the configured bearer tokens and JWT keys,
+// or returns nil when authentication is not configured
+func newAuthenticator(cfg config.Auth) (auth.Authenticator, error) {
+	var authenticators auth.Authenticators
+	if cfg.TokensFile != "" {
+		tokens, err := auth.LoadTokens(cfg.TokensFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, tokens)
+	}
+	if cfg.JWT.JWKSFile != "" {
+		jwt, err := auth.NewJWT(cfg.JWT.JWKSFile, auth.WithIssuer(cfg.JWT.Issuer), auth.WithAudience(cfg.JWT.Audience))
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, jwt)
+	}
+
+	switch len(authenticators) {
+	case 0:
+		return nil, nil
+	case 1:
+		return authenticators[0], nil
+	}
+	return authenticators, nil
+}
+
+// authenticate wraps handler with bearer authentication when an authenticator is configured
+func authenticate(authenticator auth.Authenticator, handler http.Handler) http.Handler {
+	if authenticator == nil {
+		log.Println("Authentication is disabled; any client that can reach the server may use it")
+		return handler
+	}
+	log.Println("Authentication is enabled; requests without a valid bearer token are rejected")
+	return auth.Middleware(authenticator, handler)
+}
+
+// newAuthorizer creates the authorizer for the configured policies, or returns nil when no
+// policies are configured
+func newAuthorizer(cfg *config.Config) (*authz.Authorizer, error) {
+	if len(cfg.Authorization) == 0 {
+		return nil, nil
+	}
+	authorizer, err := authz.New(cfg.Authorization)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Authorization is enabled with %d policies; authenticated principals no policy applies to may do nothing",
+		len(cfg.Authorization))
+	if strings.EqualFold(cfg.Transport, config.TransportStdio) {
+		log.Println("Authorization policies do not apply to the stdio transport; its client is not restricted")
+	}
+	return authorizer, nil
+}
+
+// newAccess creates the access rules for the configured table allow and deny lists, or returns
+// nil when they hide nothing
+func newAccess(tables config.Tables) (*database.Access, error) {
+	access, err := tables.Access()
+	if err != nil || access == nil {
+		return nil, err
+	}
+	if len(tables.Allow) > 0 {
+		log.Printf("Clients are limited to tables %s", strings.Join(tables.Allow, ", "))
+	}
+	if hidden := append(slices.Clone(tables.Deny), tables.DenyColumns...); len(hidden) > 0 {
+		log.Printf("Hiding %s from clients", strings.Join(hidden, ", "))
+	}
+	return access, nil
+}
+
+// newMasks creates the masks for the configured masking rules, or returns nil when there are none
+func newMasks(rules []database.MaskRule) (*database.Masks, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	masks, err := database.NewMasks(rules)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		log.Printf("Masking column %s with %s", rule.Column, rule.Method)
+	}
+	return masks, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runConfigCommand runs "config validate" or "config print" and returns the exit code.
+// Both accept the server's flags, so they report the configuration the server would run with.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || (args[0] != "validate" && args[0] != "print") {
+		fmt.Fprintf(os.Stderr, "Usage: %s config validate|print [options]\n", os.Args[0])
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	flags := defineFlags(fs)
+	_ = fs.Parse(args[1:]) // ExitOnError exits on failure
+
+	cfg, err := loadConfig(fs, flags)
+	if cfg != nil && args[0] == "print" {
+		out, printErr := cfg.Print()
+		if printErr != nil {
+			fmt.Fprintln(os.Stderr, printErr)
+			return 1
+		}
+		fmt.Print(out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		return 1
+	}
+
+	if args[0] == "validate" {
+		fmt.Println("Configuration is valid")
+	}
+	return 0
+}
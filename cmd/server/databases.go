@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/config"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// databaseExtensions are the file extensions -db-dir treats as SQLite databases
+var databaseExtensions = []string{".db", ".sqlite", ".sqlite3"}
+
+// dbSpecs collects repeated -db flags
+type dbSpecs []config.Database
+
+// String implements flag.Value
+func (s *dbSpecs) String() string {
+	parts := make([]string, len(*s))
+	for i, spec := range *s {
+		parts[i] = config.DatabaseName(spec) + "=" + spec.Path
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set implements flag.Value. It accepts path, name=path, and either of them followed by
+// ",ro" or ",rw" to override -read-write for that database.
+func (s *dbSpecs) Set(value string) error {
+	spec := config.Database{Path: value}
+
+	readOnly, readWrite := false, true
+	if path, ok := strings.CutSuffix(spec.Path, ",ro"); ok {
+		spec.Path, spec.ReadWrite = path, &readOnly
+	} else if path, ok := strings.CutSuffix(spec.Path, ",rw"); ok {
+		spec.Path, spec.ReadWrite = path, &readWrite
+	}
+
+	// A path or URI can hold = too, but not before a directory separator or scheme
+	if name, path, ok := strings.Cut(spec.Path, "="); ok && !strings.ContainsAny(name, `/\:`) {
+		if err := database.ValidateDatabaseName(name); err != nil {
+			return err
+		}
+		spec.Name, spec.Path = name, path
+	}
+
+	if spec.Path == "" {
+		return fmt.Errorf("database path is required in %q", value)
+	}
+
+	*s = append(*s, spec)
+	return nil
+}
+
+// attachSpecs collects repeated -attach flags
+type attachSpecs []database.Attachment
+
+// String implements flag.Value
+func (s *attachSpecs) String() string {
+	parts := make([]string, len(*s))
+	for i, a := range *s {
+		parts[i] = a.Alias + "=" + a.Path
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set implements flag.Value. It accepts alias=path.
+func (s *attachSpecs) Set(value string) error {
+	alias, path, ok := strings.Cut(value, "=")
+	if !ok || alias == "" || path == "" {
+		return fmt.Errorf("expected alias=path, got %q", value)
+	}
+
+	*s = append(*s, database.Attachment{Alias: alias, Path: path})
+	return nil
+}
+
+// scanDatabaseDir returns every SQLite database file directly inside dir
+func scanDatabaseDir(dir string) ([]config.Database, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database directory: %w", err)
+	}
+
+	var specs []config.Database
+	for _, entry := range entries {
+		if entry.IsDir() || !isDatabaseFile(entry.Name()) {
+			continue
+		}
+
+		specs = append(specs, config.Database{Path: filepath.Join(dir, entry.Name())})
+	}
+
+	sort.Slice(specs, func(i, j int) bool { return config.DatabaseName(specs[i]) < config.DatabaseName(specs[j]) })
+	return specs, nil
+}
+
+// samePath reports whether two paths name the same file
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// isDatabaseFile reports whether a file name has one of the database extensions
+func isDatabaseFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, dbExt := range databaseExtensions {
+		if ext == dbExt {
+			return true
+		}
+	}
+	return false
+}
+
+// initializeDatabases opens every configured database with opts. Databases listed in the
+// configuration come first, in order, followed by those found in its database directory; the
+// first one is the default.
+func initializeDatabases(cfg *config.Config, opts []database.Option) *database.Databases {
+	if cfg.DatabaseDir != "" && cfg.Create {
+		if err := os.MkdirAll(cfg.DatabaseDir, 0o750); err != nil {
+			log.Fatalf("Failed to create %s: %v", cfg.DatabaseDir, err)
+		}
+	}
+
+	specs := append([]config.Database(nil), cfg.Databases...)
+	if cfg.DatabaseDir != "" {
+		found, err := scanDatabaseDir(cfg.DatabaseDir)
+		if err != nil {
+			log.Fatalf("Failed to scan %s: %v", cfg.DatabaseDir, err)
+		}
+		// A file also given with -db is served once, under the name given there
+		for _, spec := range found {
+			if !slices.ContainsFunc(cfg.Databases, func(db config.Database) bool { return samePath(db.Path, spec.Path) }) {
+				specs = append(specs, spec)
+			}
+		}
+	}
+	if len(specs) == 0 && cfg.DatabaseDir == "" {
+		specs = []config.Database{{Path: config.DefaultDB}}
+	}
+	// An empty directory is fine when create_database can fill it
+	if len(specs) == 0 && !cfg.CanCreateDatabases() {
+		log.Fatalf("No databases found in %s", cfg.DatabaseDir)
+	}
+	if len(cfg.InitSQL) > 0 && !slices.ContainsFunc(specs, isMemory) {
+		log.Printf("Warning: init scripts only run on in-memory databases and none are served")
+	}
+
+	dbs := database.NewDatabases()
+	names := config.DatabaseNames(specs)
+	for i, spec := range specs {
+		name := names[i]
+		db, err := initializeDatabase(cfg, spec, opts)
+		if err != nil {
+			closeDatabases(dbs)
+			log.Fatalf("Failed to open database %s: %v", name, err)
+		}
+		if err := dbs.Add(name, db); err != nil {
+			_ = db.Close()
+			closeDatabases(dbs)
+			log.Fatalf("Failed to register database %s: %v", name, err)
+		}
+	}
+
+	return dbs
+}
+
+// databaseOptions returns the options every database is opened with
+func databaseOptions(cfg *config.Config) []database.Option {
+	pragmas, err := cfg.Durability.Pragmas()
+	if err != nil {
+		log.Fatalf("Invalid durability settings: %v", err)
+	}
+	log.Printf("Opening databases with the %s durability profile (journal_mode=%s, synchronous=%s)",
+		cfg.Durability.Profile, pragmas.JournalMode, pragmas.Synchronous)
+
+	return []database.Option{
+		database.WithAttachments(cfg.Attach...),
+		database.WithPragmas(cfg.Durability.Profile, pragmas),
+		database.WithReaders(cfg.Pool.MaxReaders, cfg.Pool.IdleReaders),
+		database.WithMaxQueuedWrites(cfg.Pool.MaxQueuedWrites),
+	}
+}
+
+// createOptions returns the options database files are created with, which run the schema
+func createOptions(cfg *config.Config, opts []database.Option) []database.Option {
+	if cfg.Schema == "" {
+		return opts
+	}
+	return append(slices.Clone(opts), database.WithInitScripts(cfg.Schema))
+}
+
+// isMemory reports whether a database is held in memory
+func isMemory(spec config.Database) bool {
+	return spec.Path == database.InMemoryDB
+}
+
+// initializeDatabase opens a configured database. In-memory databases run the init scripts,
+// and missing files are created when the configuration allows it.
+func initializeDatabase(cfg *config.Config, spec config.Database, opts []database.Option) (*database.DB, error) {
+	readOnly := !cfg.DatabaseReadWrite(spec)
+	if isMemory(spec) {
+		opts = append(slices.Clone(opts), database.WithInitScripts(cfg.InitSQL...))
+	} else if _, err := os.Stat(spec.Path); os.IsNotExist(err) {
+		if !cfg.Create {
+			return nil, fmt.Errorf("database file does not exist: %s; use -create to create it", spec.Path)
+		}
+		return database.Create(spec.Path, readOnly, createOptions(cfg, opts)...)
+	}
+
+	db, err := database.New(spec.Path, readOnly, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
+
+// closeDatabases safely closes every database connection
+func closeDatabases(dbs *database.Databases) {
+	if err := dbs.Close(); err != nil {
+		log.Printf("Error closing databases: %v", err)
+	}
+}
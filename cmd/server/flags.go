@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/config"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/tools"
+)
+
+// flagValues holds the parsed command line flags. Only the flags given on the command line
+// override the configuration file and environment.
+type flagValues struct {
+	configPath    string
+	databases     dbSpecs
+	dbDir         string
+	create        bool
+	schema        string
+	attachments   attachSpecs
+	initSQL       fileList
+	durability    string
+	journalMode   string
+	synchronous   string
+	busyTimeout   time.Duration
+	cacheSize     int64
+	mmapSize      int64
+	maxReaders    int
+	idleReaders   int
+	maxQueued     int
+	addr          string
+	readWrite     bool
+	transport     string
+	queryTimeout  time.Duration
+	maxRows       int
+	pageSize      int
+	bigIntStrings bool
+	txIdle        time.Duration
+	requireWhere  bool
+	allowDDL      bool
+	allowVacuum   bool
+	maxAffected   int64
+	allowTables   patternList
+	denyTables    patternList
+	denyColumns   patternList
+	masks         maskSpecs
+	promptsDir    string
+	tokensFile    string
+	jwksFile      string
+	jwtIssuer     string
+	jwtAudience   string
+	help          bool
+}
+
+// defineFlags defines the server's flags on fs
+func defineFlags(fs *flag.FlagSet) *flagValues {
+	f := &flagValues{}
+	fs.StringVar(&f.configPath, "config", "", "YAML configuration file. Environment variables and flags override its settings")
+	fs.Var(&f.databases, "db", "SQLite database to serve, as path or name=path, optionally followed by ,ro or ,rw "+
+		"to override -read-write. Repeat to serve several databases; the first is the default (default "+config.DefaultDB+")")
+	fs.Var(&f.attachments, "attach", "Attach a database file read-only to every served database as alias=path, "+
+		"so its tables can be queried as alias.table. May be repeated")
+	fs.Var(&f.initSQL, "init-sql", "Run a SQL script, or every .sql script in a directory in name order, on each "+
+		"in-memory database before it is served. May be repeated")
+	fs.StringVar(&f.dbDir, "db-dir", "", "Serve every .db, .sqlite and .sqlite3 file in this directory, named after the file")
+	fs.BoolVar(&f.create, "create", false, "Create missing database files, their parent directories and the -db-dir "+
+		"directory at startup, and let the create_database tool add databases to -db-dir")
+	fs.StringVar(&f.schema, "schema", "",
+		"With -create, run this SQL script, or every .sql script in this directory in name order, on each database file "+
+			"as it is created")
+	fs.StringVar(&f.durability, "durability", database.DefaultDurability,
+		"Connection settings profile: 'container-safe' (rollback journal, full sync), 'wal' (WAL, normal sync) "+
+			"or 'full' (WAL, full sync)")
+	fs.StringVar(&f.journalMode, "journal-mode", "",
+		"Override the profile's journal mode: delete, truncate, persist, memory, wal or off")
+	fs.StringVar(&f.synchronous, "synchronous", "", "Override the profile's synchronous setting: off, normal, full or extra")
+	fs.DurationVar(&f.busyTimeout, "busy-timeout", 0,
+		"Override how long a statement waits for a lock held by another connection (profile default 5s)")
+	fs.Int64Var(&f.cacheSize, "cache-size", 0,
+		"Override the page cache size, in pages, or in KiB when negative (profile default -64000)")
+	fs.Int64Var(&f.mmapSize, "mmap-size", 0, "Override the number of bytes of the database file read through memory mapping")
+	fs.IntVar(&f.maxReaders, "max-readers", database.DefaultMaxReaders,
+		"Maximum number of read-only connections each database keeps open for queries")
+	fs.IntVar(&f.idleReaders, "idle-readers", database.DefaultIdleReaders,
+		"Number of unused read-only connections each database keeps open")
+	fs.IntVar(&f.maxQueued, "max-queued-writes", 0,
+		"Reject writes when this many are already waiting for a database's writer connection (0 disables the limit)")
+	fs.StringVar(&f.addr, "addr", config.DefaultAddr, "Address to listen on")
+	fs.BoolVar(&f.readWrite, "read-write", false,
+		"Whether to allow write operations on the databases. When false, they are opened read-only")
+	fs.StringVar(&f.transport, "transport", config.TransportStreamableHTTP,
+		"Transport protocol: 'sse', 'streamable-http' or 'stdio'. Also via MCP_TRANSPORT env var")
+	fs.DurationVar(&f.queryTimeout, "query-timeout", config.DefaultQueryTimeout,
+		"Maximum time a single query or statement may run before it is interrupted (0 disables the limit)")
+	fs.IntVar(&f.maxRows, "max-rows", tools.DefaultMaxRows,
+		"Maximum number of rows a single query may return across all pages (0 disables the limit)")
+	fs.IntVar(&f.pageSize, "page-size", tools.DefaultPageSize, "Default number of rows returned per page of a query result")
+	fs.BoolVar(&f.bigIntStrings, "big-int-strings", false,
+		"Return integers outside the range JSON clients can represent exactly (±2^53) as strings")
+	fs.DurationVar(&f.txIdle, "tx-idle-timeout", tools.DefaultTransactionIdleTimeout,
+		"Roll back a session's transaction after it has been unused this long (0 disables the limit)")
+	fs.BoolVar(&f.requireWhere, "require-where", true,
+		"Reject UPDATE and DELETE statements that have no WHERE clause")
+	fs.BoolVar(&f.allowDDL, "allow-ddl", false, "Allow DROP and ALTER statements")
+	fs.BoolVar(&f.allowVacuum, "allow-vacuum-into", false,
+		"Allow VACUUM INTO statements, which write a copy of the database to any path the server can write to")
+	fs.Int64Var(&f.maxAffected, "max-affected-rows", 0,
+		"Roll back any statement that changes more rows than this (0 disables the limit)")
+	fs.Var(&f.allowTables, "allow-tables",
+		"Comma-separated table patterns clients are limited to, such as 'orders,order_*'. May be repeated")
+	fs.Var(&f.denyTables, "deny-tables", "Comma-separated table patterns hidden from clients. May be repeated")
+	fs.Var(&f.denyColumns, "deny-columns",
+		"Comma-separated table.column patterns hidden from clients, such as 'users.password_hash'. May be repeated")
+	fs.Var(&f.masks, "mask", "Mask a result column as column=method, where column is a table.column or column name "+
+		"pattern and method is redact, hash, last4 or email_domain. May be repeated")
+	fs.StringVar(&f.promptsDir, "prompts-dir", "",
+		"Serve every .md file in this directory as a prompt template besides the built-in prompts")
+	fs.StringVar(&f.tokensFile, "auth-tokens-file", "",
+		"Require HTTP clients to send one of the bearer tokens listed in this file, one 'principal token [scope...]' per line")
+	fs.StringVar(&f.jwksFile, "auth-jwks-file", "",
+		"Require HTTP clients to send a JWT signed by one of the keys in this JWKS file")
+	fs.StringVar(&f.jwtIssuer, "auth-jwt-issuer", "", "Issuer required in the iss claim of JWTs")
+	fs.StringVar(&f.jwtAudience, "auth-jwt-audience", "", "Audience required in the aud claim of JWTs")
+	fs.BoolVar(&f.help, "help", false, "Show help message")
+	return f
+}
+
+// loadConfig builds the effective configuration from the defaults, the -config file, the
+// environment and the flags set on fs, in increasing order of precedence, and validates it
+func loadConfig(fs *flag.FlagSet, f *flagValues) (*config.Config, error) {
+	cfg := config.Default()
+	if f.configPath != "" {
+		var err error
+		if cfg, err = config.Load(f.configPath); err != nil {
+			return nil, err
+		}
+	}
+	cfg.ApplyEnv()
+	f.apply(fs, cfg)
+
+	return cfg, cfg.Validate()
+}
+
+// apply overrides cfg with the flags set on fs
+func (f *flagValues) apply(fs *flag.FlagSet, cfg *config.Config) {
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "db":
+			cfg.Databases = f.databases
+		case "db-dir":
+			cfg.DatabaseDir = f.dbDir
+		case "create":
+			cfg.Create = f.create
+		case "schema":
+			cfg.Schema = f.schema
+		case "attach":
+			cfg.Attach = f.attachments
+		case "init-sql":
+			cfg.InitSQL = f.initSQL
+		case "durability":
+			cfg.Durability.Profile = strings.ToLower(f.durability)
+		case "journal-mode":
+			cfg.Durability.JournalMode = f.journalMode
+		case "synchronous":
+			cfg.Durability.Synchronous = f.synchronous
+		case "busy-timeout":
+			busyTimeout := config.Duration(f.busyTimeout)
+			cfg.Durability.BusyTimeout = &busyTimeout
+		case "cache-size":
+			cfg.Durability.CacheSize = &f.cacheSize
+		case "mmap-size":
+			cfg.Durability.MmapSize = &f.mmapSize
+		case "max-readers":
+			cfg.Pool.MaxReaders = f.maxReaders
+		case "idle-readers":
+			cfg.Pool.IdleReaders = f.idleReaders
+		case "max-queued-writes":
+			cfg.Pool.MaxQueuedWrites = f.maxQueued
+		case "addr":
+			cfg.Addr = f.addr
+		case "read-write":
+			cfg.ReadWrite = f.readWrite
+		case "transport":
+			cfg.Transport = strings.ToLower(f.transport)
+		case "query-timeout":
+			cfg.Limits.QueryTimeout = config.Duration(f.queryTimeout)
+		case "max-rows":
+			cfg.Limits.MaxRows = f.maxRows
+		case "page-size":
+			cfg.Limits.PageSize = f.pageSize
+		case "big-int-strings":
+			cfg.Output.BigIntStrings = f.bigIntStrings
+		case "tx-idle-timeout":
+			cfg.Limits.TxIdleTimeout = config.Duration(f.txIdle)
+		case "require-where":
+			cfg.Policy.RequireWhere = f.requireWhere
+		case "allow-ddl":
+			cfg.Policy.AllowDDL = f.allowDDL
+		case "allow-vacuum-into":
+			cfg.Policy.AllowVacuumInto = f.allowVacuum
+		case "max-affected-rows":
+			cfg.Policy.MaxAffectedRows = f.maxAffected
+		case "allow-tables":
+			cfg.Tables.Allow = f.allowTables
+		case "deny-tables":
+			cfg.Tables.Deny = f.denyTables
+		case "deny-columns":
+			cfg.Tables.DenyColumns = f.denyColumns
+		case "mask":
+			cfg.Masking = f.masks
+		case "prompts-dir":
+			cfg.PromptsDir = f.promptsDir
+		case "auth-tokens-file":
+			cfg.Auth.TokensFile = f.tokensFile
+		case "auth-jwks-file":
+			cfg.Auth.JWT.JWKSFile = f.jwksFile
+		case "auth-jwt-issuer":
+			cfg.Auth.JWT.Issuer = f.jwtIssuer
+		case "auth-jwt-audience":
+			cfg.Auth.JWT.Audience = f.jwtAudience
+		}
+	})
+}
+
+// patternList collects comma-separated table patterns from repeated flags
+type patternList []string
+
+// String implements flag.Value
+func (l *patternList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value
+func (l *patternList) Set(value string) error {
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			*l = append(*l, pattern)
+		}
+	}
+	return nil
+}
+
+// fileList collects the paths of repeated flags
+type fileList []string
+
+// String implements flag.Value
+func (l *fileList) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Set implements flag.Value
+func (l *fileList) Set(value string) error {
+	if value == "" {
+		return errors.New("path is required")
+	}
+	*l = append(*l, value)
+	return nil
+}
+
+// maskSpecs collects repeated -mask flags
+type maskSpecs []database.MaskRule
+
+// String implements flag.Value
+func (s *maskSpecs) String() string {
+	parts := make([]string, len(*s))
+	for i, rule := range *s {
+		parts[i] = rule.Column + "=" + rule.Method
+	}
+	return strings.Join(parts, " ")
+}
+
+// Set implements flag.Value. It accepts column=method; regex rules need the configuration file.
+func (s *maskSpecs) Set(value string) error {
+	column, method, ok := strings.Cut(value, "=")
+	if !ok || column == "" || method == "" {
+		return fmt.Errorf("invalid mask %q: use column=method", value)
+	}
+	rule := database.MaskRule{Column: column, Method: strings.ToLower(method)}
+	if rule.Method == database.MaskRegex {
+		return errors.New("regex masks need a pattern; configure them in the masking section of the configuration file")
+	}
+	if _, err := database.NewMasks([]database.MaskRule{rule}); err != nil {
+		return err
+	}
+	*s = append(*s, rule)
+	return nil
+}
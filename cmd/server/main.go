@@ -3,90 +3,106 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/server"
 
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/config"
 	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/prompts"
 	"github.com/StacklokLabs/sqlite-mcp/internal/resources"
 	"github.com/StacklokLabs/sqlite-mcp/internal/tools"
 )
 
-const (
-	defaultDB = "./database.db"
-
-	// Transport types
-	transportSSE            = "sse"
-	transportStreamableHTTP = "streamable-http"
-)
-
 func main() {
-	config := parseFlags()
-	if config.help {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
+	flags := defineFlags(flag.CommandLine)
+	flag.Parse()
+	if flags.help {
 		showHelp()
 		return
 	}
 
-	ctx := setupContext()
-	db := initializeDatabase(config.dbPath, config.readWrite)
-	defer closeDatabase(db)
-
-	mcpServer := createMCPServer()
-	registerToolsAndResources(mcpServer, db, config.readWrite)
+	cfg, err := loadConfig(flag.CommandLine, flags)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
 
-	runServer(ctx, mcpServer, config.addr, config.dbPath, config.readWrite, config.transport)
-}
+	authorizer, err := newAuthorizer(cfg)
+	if err != nil {
+		log.Fatalf("Invalid authorization policies: %v", err)
+	}
+	access, err := newAccess(cfg.Tables)
+	if err != nil {
+		log.Fatalf("Invalid table lists: %v", err)
+	}
+	masks, err := newMasks(cfg.Masking)
+	if err != nil {
+		log.Fatalf("Invalid masking rules: %v", err)
+	}
+	templates, err := cfg.Prompts()
+	if err != nil {
+		log.Fatalf("Invalid prompt templates: %v", err)
+	}
 
-// Config holds the parsed command line configuration
-type Config struct {
-	dbPath    string
-	addr      string
-	readWrite bool
-	transport string
-	help      bool
-}
+	ctx := setupContext()
+	dbOpts := databaseOptions(cfg)
+	dbs := initializeDatabases(cfg, dbOpts)
+	defer closeDatabases(dbs)
 
-// parseFlags parses command line flags and returns configuration
-func parseFlags() Config {
-	dbPath := flag.String("db", defaultDB, "Path to SQLite database file")
-	addr := flag.String("addr", getDefaultAddress(), "Address to listen on")
-	readWrite := flag.Bool("read-write", false,
-		"Whether to allow write operations on the database. When false, the server operates in read-only mode")
-	transport := flag.String("transport", getDefaultTransport(),
-		"Transport protocol: 'sse' or 'streamable-http'. Also via MCP_TRANSPORT env var")
-	help := flag.Bool("help", false, "Show help message")
+	queryTools := newQueryTools(dbs, cfg, dbOpts, authorizer, access, masks)
+	schemaResources := resources.New(dbs, resources.WithAuthorizer(authorizer), resources.WithAccess(access))
+	serverPrompts := prompts.New(dbs,
+		prompts.WithTemplates(templates...), prompts.WithAuthorizer(authorizer), prompts.WithAccess(access))
 
-	flag.Parse()
+	hooks := &server.Hooks{}
+	mcpServer := createMCPServer(hooks, queryTools.FilterTools)
+	registerToolsAndResources(mcpServer, hooks, queryTools, schemaResources, dbs, cfg)
+	registerPrompts(mcpServer, serverPrompts)
 
-	return Config{
-		dbPath:    *dbPath,
-		addr:      *addr,
-		readWrite: *readWrite,
-		transport: *transport,
-		help:      *help,
-	}
+	runServer(ctx, mcpServer, cfg, dbs)
 }
 
 // showHelp displays the help message
 func showHelp() {
 	fmt.Printf("SQLite MCP Server - A Model Context Protocol server for SQLite databases\n\n")
-	fmt.Printf("Usage: %s [options]\n\n", os.Args[0])
+	fmt.Printf("Usage: %s [options]\n", os.Args[0])
+	fmt.Printf("       %s config validate|print [options]\n\n", os.Args[0])
 	fmt.Printf("Options:\n")
 	flag.PrintDefaults()
+	fmt.Printf("\nSettings are layered: the -config file overrides the defaults, environment variables\n")
+	fmt.Printf("override the file, and flags given on the command line override both.\n")
+	fmt.Printf("\nCommands:\n")
+	fmt.Printf("  config validate  Check the configuration and the files it refers to\n")
+	fmt.Printf("  config print     Print the effective configuration as YAML\n")
 	fmt.Printf("\nEnvironment Variables:\n")
-	fmt.Printf("  MCP_PORT       Port to listen on (overrides -addr flag port)\n")
-	fmt.Printf("  MCP_TRANSPORT  Transport protocol: 'sse' or 'streamable-http' (default: streamable-http)\n")
+	fmt.Printf("  MCP_PORT       Port to listen on (overrides the config file addr; -addr overrides it)\n")
+	fmt.Printf("  MCP_TRANSPORT  Transport protocol: 'sse', 'streamable-http' or 'stdio' (default: streamable-http)\n")
 	fmt.Printf("\nExample:\n")
 	fmt.Printf("  %s -db ./mydata.db -addr :8080\n", os.Args[0])
 	fmt.Printf("  MCP_PORT=9000 %s -db ./mydata.db\n", os.Args[0])
 	fmt.Printf("  MCP_TRANSPORT=sse %s -db ./mydata.db\n", os.Args[0])
+	fmt.Printf("  %s -db ./mydata.db -transport stdio\n", os.Args[0])
+	fmt.Printf("  %s -read-write -db sales=./sales.db -db audit=./audit.db,ro\n", os.Args[0])
+	fmt.Printf("  %s -db-dir ./databases\n", os.Args[0])
+	fmt.Printf("  %s -db ./orders.db -attach ref=./reference.db\n", os.Args[0])
+	fmt.Printf("  %s -read-write -create -schema ./schema.sql -db-dir ./databases\n", os.Args[0])
+	fmt.Printf("  %s -db ./mydata.db -prompts-dir ./prompts\n", os.Args[0])
+	fmt.Printf("  %s -config ./sqlite-mcp.yaml\n", os.Args[0])
+	fmt.Printf("  %s config print -config ./sqlite-mcp.yaml -transport stdio\n", os.Args[0])
 }
 
 // setupContext creates a cancellable context with signal handling
@@ -104,58 +120,93 @@ func setupContext() context.Context {
 	return ctx
 }
 
-// initializeDatabase validates and initializes the database connection
-func initializeDatabase(dbPath string, readWrite bool) *database.DB {
-	// Validate database file exists (skip check for in-memory databases)
-	if dbPath != ":memory:" {
-		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-			log.Fatalf("Database file does not exist: %s", dbPath)
-		}
-	}
-
-	// Initialize database connection with read-only mode detection
-	db, err := database.New(dbPath, !readWrite)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-	}
-
-	return db
-}
-
-// closeDatabase safely closes the database connection
-func closeDatabase(db *database.DB) {
-	if err := db.Close(); err != nil {
-		log.Printf("Error closing database: %v", err)
-	}
-}
-
 // createMCPServer creates and configures the MCP server
-func createMCPServer() *server.MCPServer {
+func createMCPServer(hooks *server.Hooks, toolFilter server.ToolFilterFunc) *server.MCPServer {
 	return server.NewMCPServer(
 		"sqlite-mcp",
 		"1.0.0",
-		server.WithToolCapabilities(false), // No tool list change notifications
+		server.WithHooks(hooks),
+		server.WithToolCapabilities(false),            // No tool list change notifications
+		server.WithToolFilter(toolFilter),             // Only list the tools the caller may use
 		server.WithResourceCapabilities(false, false), // No resource subscriptions or change notifications
+		server.WithPromptCapabilities(false),          // No prompt list change notifications
 		server.WithLogging(),                          // Enable logging
 		server.WithRecovery(),                         // Enable panic recovery
 	)
 }
 
-// registerToolsAndResources registers tools and resources with the MCP server
-func registerToolsAndResources(mcpServer *server.MCPServer, db *database.DB, readWrite bool) {
-	// Initialize tools and resources
-	queryTools := tools.New(db)
-	schemaResources := resources.New(db)
+// isWriteTool reports whether a tool is only available when a database is read-write
+func isWriteTool(name string) bool {
+	switch name {
+	case "execute_statement", "begin_transaction", "commit_transaction", "rollback_transaction", "create_database":
+		return true
+	}
+	return false
+}
 
-	// Register tools based on read-write mode
+// newQueryTools creates the tools with the configured limits and policies. Databases added by
+// create_database are opened with dbOpts.
+func newQueryTools(
+	dbs *database.Databases, cfg *config.Config, dbOpts []database.Option, authorizer *authz.Authorizer,
+	access *database.Access, masks *database.Masks,
+) *tools.QueryTools {
+	opts := []tools.Option{
+		tools.WithQueryTimeout(time.Duration(cfg.Limits.QueryTimeout)),
+		tools.WithMaxRows(cfg.Limits.MaxRows),
+		tools.WithPageSize(cfg.Limits.PageSize),
+		tools.WithEncoding(database.Encoding{BigIntsAsStrings: cfg.Output.BigIntStrings}),
+		tools.WithTransactionIdleTimeout(time.Duration(cfg.Limits.TxIdleTimeout)),
+		tools.WithWritePolicy(tools.WritePolicy{
+			RequireWhere:    cfg.Policy.RequireWhere,
+			AllowDDL:        cfg.Policy.AllowDDL,
+			AllowVacuumInto: cfg.Policy.AllowVacuumInto,
+			MaxAffectedRows: cfg.Policy.MaxAffectedRows,
+		}),
+		tools.WithAuthorizer(authorizer),
+		tools.WithAccess(access),
+		tools.WithMasks(masks),
+	}
+	if cfg.CanCreateDatabases() {
+		opts = append(opts, tools.WithDatabaseCreation(cfg.DatabaseDir, cfg.ReadWrite, createOptions(cfg, dbOpts)...))
+	}
+	return tools.New(dbs, opts...)
+}
+
+// registerToolsAndResources registers tools and resources with the MCP server
+func registerToolsAndResources(
+	mcpServer *server.MCPServer, hooks *server.Hooks, queryTools *tools.QueryTools,
+	schemaResources *resources.SchemaResources, dbs *database.Databases, cfg *config.Config,
+) {
+	// Databases created later follow -read-write
+	readWrite := dbs.Writable() || (cfg.CanCreateDatabases() && cfg.ReadWrite)
+
+	// Let clients interrupt running statements with notifications/cancelled
+	hooks.AddBeforeCallTool(queryTools.TrackRequest)
+	mcpServer.AddNotificationHandler(tools.MethodNotificationCancelled, queryTools.HandleCancelled)
+
+	// Roll back transactions left open by sessions that go away
+	hooks.AddOnUnregisterSession(queryTools.EndSession)
+
+	// Register the configured tools, based on read-write mode
+	var registered []string
 	for _, tool := range queryTools.GetTools() {
-		// In read-only mode, skip write operations
-		if !readWrite && (tool.Name == "execute_statement") {
+		if !cfg.ToolEnabled(tool.Name) {
+			log.Printf("Skipping tool '%s' disabled by configuration", tool.Name)
+			continue
+		}
+		if tool.Name == "create_database" && !cfg.CanCreateDatabases() {
+			log.Printf("Skipping tool '%s': it needs -create and -db-dir", tool.Name)
+			continue
+		}
+		// When every database is read-only, skip write operations
+		if !readWrite && isWriteTool(tool.Name) {
 			log.Printf("Skipping write tool '%s' in read-only mode", tool.Name)
 			continue
 		}
 		mcpServer.AddTool(tool, queryTools.HandleTool)
+		registered = append(registered, tool.Name)
 	}
+	log.Printf("Available tools: %s", strings.Join(registered, ", "))
 
 	// Register resources
 	for _, resource := range schemaResources.GetResources() {
@@ -168,29 +219,63 @@ func registerToolsAndResources(mcpServer *server.MCPServer, db *database.DB, rea
 	}
 }
 
+// registerPrompts registers the built-in and configured prompts with the MCP server
+func registerPrompts(mcpServer *server.MCPServer, serverPrompts *prompts.Prompts) {
+	var registered []string
+	for _, prompt := range serverPrompts.GetPrompts() {
+		mcpServer.AddPrompt(prompt, serverPrompts.HandlePrompt)
+		registered = append(registered, prompt.Name)
+	}
+	log.Printf("Available prompts: %s", strings.Join(registered, ", "))
+}
+
 // runServer starts the server and handles shutdown
-func runServer(ctx context.Context, mcpServer *server.MCPServer, addr, dbPath string, readWrite bool, transport string) {
-	// Create the appropriate transport server
+func runServer(ctx context.Context, mcpServer *server.MCPServer, cfg *config.Config, dbs *database.Databases) {
+	addr, transport := cfg.Addr, cfg.Transport
+
+	// stdio has no listener, so it is served directly on stdin/stdout
+	if strings.ToLower(transport) == config.TransportStdio {
+		if cfg.Auth.Enabled() {
+			log.Println("Authentication only applies to the HTTP transports; the stdio transport is not authenticated")
+		}
+		runStdioServer(ctx, mcpServer, dbs)
+		return
+	}
+
+	authenticator, err := newAuthenticator(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+
+	// Create the appropriate transport server. It serves through an http.Server of our own so
+	// that requests are authenticated before they reach the MCP handler.
 	var transportServer interface {
 		Start(string) error
 		Shutdown(context.Context) error
 	}
+	httpServer := &http.Server{Addr: addr}
 
 	switch strings.ToLower(transport) {
-	case transportStreamableHTTP:
+	case config.TransportStreamableHTTP:
 		log.Println("Using streamable-http transport")
-		transportServer = server.NewStreamableHTTPServer(mcpServer)
-	case transportSSE:
+		streamableServer := server.NewStreamableHTTPServer(mcpServer, server.WithStreamableHTTPServer(httpServer))
+		mux := http.NewServeMux()
+		mux.Handle(streamableHTTPEndpoint, streamableServer)
+		httpServer.Handler = authenticate(authenticator, mux)
+		transportServer = streamableServer
+	case config.TransportSSE:
 		log.Println("Using SSE transport")
-		transportServer = server.NewSSEServer(mcpServer)
+		sseServer := server.NewSSEServer(mcpServer, server.WithHTTPServer(httpServer))
+		httpServer.Handler = authenticate(authenticator, sseServer)
+		transportServer = sseServer
 	default:
-		log.Fatalf("Invalid transport: %s. Must be 'sse' or 'streamable-http'", transport)
+		log.Fatalf("Invalid transport: %s. Must be 'sse', 'streamable-http' or 'stdio'", transport)
 	}
 
 	// Start server in a goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		logServerStart(addr, dbPath, readWrite, transport)
+		logServerStart(addr, dbs, transport)
 		errChan <- transportServer.Start(addr)
 	}()
 
@@ -210,63 +295,37 @@ func runServer(ctx context.Context, mcpServer *server.MCPServer, addr, dbPath st
 	log.Println("Server shutdown complete")
 }
 
-// logServerStart logs server startup information
-func logServerStart(addr, dbPath string, readWrite bool, transport string) {
-	mode := "read-only"
-	if readWrite {
-		mode = "read-write"
-	}
+// runStdioServer serves the MCP server over stdin/stdout until the input is closed
+// or the context is cancelled. Nothing else may write to stdout while it runs, as
+// that would corrupt the JSON-RPC stream.
+func runStdioServer(ctx context.Context, mcpServer *server.MCPServer, dbs *database.Databases) {
+	log.Println("Using stdio transport")
+	logServerStart("stdio", dbs, config.TransportStdio)
 
-	log.Printf("Starting SQLite MCP Server on %s (%s mode, %s transport)", addr, mode, transport)
-	log.Printf("Database: %s", dbPath)
+	stdioServer := server.NewStdioServer(mcpServer)
+	stdioServer.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
 
-	if readWrite {
-		log.Printf("Available tools: execute_query, execute_statement, list_tables, describe_table")
-	} else {
-		log.Printf("Available tools: execute_query, list_tables, describe_table")
+	if err := stdioServer.Listen(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("Server error: %v", err)
 	}
-	log.Printf("Available resources: schema://tables, schema://table/{name}")
-}
 
-// getDefaultAddress returns the address to listen on based on MCP_PORT environment variable.
-// If the environment variable is not set, returns ":8080".
-// If set, validates that the port is valid and returns ":<port>".
-func getDefaultAddress() string {
-	port := "8080"
-	if envPort := os.Getenv("MCP_PORT"); envPort != "" {
-		if portNum, err := strconv.Atoi(envPort); err == nil {
-			if portNum >= 0 && portNum <= 65535 {
-				port = envPort
-			} else {
-				log.Printf("Invalid MCP_PORT value: %s (must be between 0 and 65535), using default port 8080", envPort)
-			}
-		} else {
-			log.Printf("Invalid MCP_PORT value: %s (must be a valid number), using default port 8080", envPort)
-		}
-	}
-	return ":" + port
+	log.Println("Server shutdown complete")
 }
 
-// getDefaultTransport returns the transport to use based on MCP_TRANSPORT environment variable.
-// If the environment variable is not set, returns "streamable-http".
-// Valid values are "sse" and "streamable-http".
-func getDefaultTransport() string {
-	defaultTransport := transportStreamableHTTP
-
-	transportEnv := os.Getenv("MCP_TRANSPORT")
-	if transportEnv == "" {
-		return defaultTransport
-	}
-
-	// Normalize the transport value
-	transport := strings.ToLower(strings.TrimSpace(transportEnv))
-
-	// Validate the transport value
-	if transport != transportSSE && transport != transportStreamableHTTP {
-		log.Printf("Invalid MCP_TRANSPORT: %s, using default: %s",
-			transportEnv, defaultTransport)
-		return defaultTransport
+// logServerStart logs server startup information
+func logServerStart(addr string, dbs *database.Databases, transport string) {
+	log.Printf("Starting SQLite MCP Server on %s (%s transport)", addr, transport)
+	for _, name := range dbs.Names() {
+		db, err := dbs.Get(name)
+		if err != nil {
+			continue
+		}
+		mode := "read-only"
+		if db.Writable() {
+			mode = "read-write"
+		}
+		log.Printf("Database %s: %s (%s mode)", name, db.Path(), mode)
 	}
-
-	return transport
+	log.Printf("Available resources: schema://{db}/tables, schema://{db}/table/{name}, schema://{db}/views, " +
+		"schema://{db}/view/{name}, schema://{db}/indexes, schema://{db}/triggers")
 }
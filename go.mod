@@ -5,6 +5,11 @@ go 1.24.3
 require (
 	github.com/mark3labs/mcp-go v0.43.2
 	github.com/stretchr/testify v1.11.1
+	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/libc v1.67.6
+	// internal/database reads unexported fields of the driver's connections and wraps SQLite's
+	// memdb VFS; run TestDriverHandle and TestMemoryDatabase before upgrading modernc.org/sqlite
+	// or modernc.org/libc
 	modernc.org/sqlite v1.44.2
 )
 
@@ -25,8 +30,6 @@ require (
 	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-	modernc.org/libc v1.67.6 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
 )
@@ -0,0 +1,107 @@
+// Package auth authenticates HTTP clients with bearer tokens or JWTs
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidToken is returned when a bearer token is not accepted
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal is an authenticated caller
+type Principal struct {
+	// Name identifies the caller: the token's principal, or the JWT subject
+	Name string
+	// Scopes are the scopes granted to the token
+	Scopes []string
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator validates bearer tokens
+type Authenticator interface {
+	// Authenticate returns the principal a token belongs to, or an error wrapping ErrInvalidToken
+	Authenticate(token string) (*Principal, error)
+}
+
+// Authenticators accepts a token when any of its authenticators does, trying them in order
+type Authenticators []Authenticator
+
+// Authenticate implements Authenticator
+func (a Authenticators) Authenticate(token string) (*Principal, error) {
+	errs := make([]error, 0, len(a))
+	for _, authenticator := range a {
+		principal, err := authenticator.Authenticate(token)
+		if err == nil {
+			return principal, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, ErrInvalidToken
+	}
+	return nil, errors.Join(errs...)
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal authenticated for the request the context belongs to
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// Middleware rejects requests without a valid bearer token with 401 Unauthorized and passes
+// the authenticated principal to next through the request context
+func Middleware(authenticator Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			unauthorized(w, `Bearer realm="sqlite-mcp"`)
+			return
+		}
+
+		principal, err := authenticator.Authenticate(token)
+		if err != nil {
+			log.Printf("Rejected request from %s: %v", r.RemoteAddr, err)
+			unauthorized(w, `Bearer realm="sqlite-mcp", error="invalid_token"`)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// unauthorized writes a 401 response with the given WWW-Authenticate challenge
+func unauthorized(w http.ResponseWriter, challenge string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
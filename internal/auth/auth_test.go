@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticAuthenticator accepts one token
+type staticAuthenticator struct {
+	token     string
+	principal Principal
+}
+
+func (s staticAuthenticator) Authenticate(token string) (*Principal, error) {
+	if token != s.token {
+		return nil, ErrInvalidToken
+	}
+	return &s.principal, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	authenticator := Authenticators{
+		staticAuthenticator{token: "first", principal: Principal{Name: "alice"}},
+		staticAuthenticator{token: "second", principal: Principal{Name: "bob", Scopes: []string{"read"}}},
+	}
+
+	var seen *Principal
+	handler := Middleware(authenticator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		seen = nil
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("valid tokens", func(t *testing.T) {
+		rec := serve("Bearer first")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		require.NotNil(t, seen)
+		assert.Equal(t, "alice", seen.Name)
+
+		rec = serve("bearer second")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		require.NotNil(t, seen)
+		assert.Equal(t, "bob", seen.Name)
+		assert.True(t, seen.HasScope("read"))
+		assert.False(t, seen.HasScope("write"))
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		for _, authorization := range []string{"", "Basic Zm9vOmJhcg==", "Bearer "} {
+			rec := serve(authorization)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, authorization)
+			assert.Equal(t, `Bearer realm="sqlite-mcp"`, rec.Header().Get("WWW-Authenticate"))
+			assert.Nil(t, seen, "the handler must not run")
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		rec := serve("Bearer third")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Header().Get("WWW-Authenticate"), `error="invalid_token"`)
+		assert.Nil(t, seen, "the handler must not run")
+	})
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	_, ok := PrincipalFromContext(t.Context())
+	assert.False(t, ok)
+
+	principal, ok := PrincipalFromContext(WithPrincipal(t.Context(), &Principal{Name: "alice"}))
+	require.True(t, ok)
+	assert.Equal(t, "alice", principal.Name)
+}
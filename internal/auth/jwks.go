@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// publicKey is a verification key read from a JWKS file
+type publicKey struct {
+	id string
+	// alg restricts the key to one signing algorithm when set
+	alg string
+	key crypto.PublicKey
+}
+
+// jwk is a JSON Web Key as defined by RFC 7517 and RFC 8037
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// loadJWKS reads the signature verification keys from a JWKS file. Keys whose use is not
+// "sig" are skipped.
+func loadJWKS(path string) ([]publicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS file %s: %w", path, err)
+	}
+
+	keys := make([]publicKey, 0, len(set.Keys))
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d (kid %q): %w", path, i, k.Kid, err)
+		}
+		keys = append(keys, publicKey{id: k.Kid, alg: k.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no signing keys found", path)
+	}
+	return keys, nil
+}
+
+// publicKey decodes the key material of an RSA, EC or OKP key
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBase64(k.N, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64(k.E, "e")
+		if err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("unsupported RSA exponent")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if key.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits")
+		}
+		return key, nil
+
+	case "EC":
+		curve, ecdhCurve, err := ecCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := decodeBase64(k.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64(k.Y, "y")
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("invalid %s point size", k.Crv)
+		}
+		// ecdh rejects points that are not on the curve
+		if _, err := ecdhCurve.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid %s point: %w", k.Crv, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := decodeBase64(k.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// ecCurve returns the curve named by a JWK crv parameter
+func ecCurve(crv string) (elliptic.Curve, ecdh.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), ecdh.P256(), nil
+	case "P-384":
+		return elliptic.P384(), ecdh.P384(), nil
+	case "P-521":
+		return elliptic.P521(), ecdh.P521(), nil
+	}
+	return nil, nil, fmt.Errorf("unsupported EC curve %q", crv)
+}
+
+// decodeBase64 decodes a base64url parameter without padding
+func decodeBase64(value, param string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing %q", param)
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %q: %w", param, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadJWKSErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "not JSON", content: "keys:", want: "failed to parse JWKS file"},
+		{name: "no keys", content: `{"keys": []}`, want: "no signing keys found"},
+		{name: "unsupported type", content: `{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`, want: `unsupported key type "oct"`},
+		{name: "short RSA key", content: `{"keys": [{"kty": "RSA", "n": "AQAB", "e": "AQAB"}]}`, want: "at least 2048 bits"},
+		{name: "unsupported curve", content: `{"keys": [{"kty": "EC", "crv": "P-192", "x": "AA", "y": "AA"}]}`, want: `unsupported EC curve "P-192"`},
+		{
+			name:    "point not on curve",
+			content: `{"keys": [{"kty": "EC", "crv": "P-256", "x": "` + zeros(32) + `", "y": "` + zeros(32) + `"}]}`,
+			want:    "invalid P-256 point",
+		},
+		{name: "missing parameter", content: `{"keys": [{"kty": "OKP", "crv": "Ed25519"}]}`, want: `missing "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadJWKS(writeFile(t, "jwks.json", tt.content))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+// zeros returns n zero bytes encoded as base64url
+func zeros(n int) string {
+	return base64.RawURLEncoding.EncodeToString(make([]byte, n))
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking a JWT's expiry and not-before times
+const clockSkew = time.Minute
+
+// JWT authenticates JSON Web Tokens signed by one of the keys in a JWKS file
+type JWT struct {
+	keys     []publicKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// JWTOption configures a JWT authenticator
+type JWTOption func(*JWT)
+
+// WithIssuer requires tokens to carry issuer in their iss claim
+func WithIssuer(issuer string) JWTOption {
+	return func(j *JWT) {
+		j.issuer = issuer
+	}
+}
+
+// WithAudience requires tokens to list audience in their aud claim
+func WithAudience(audience string) JWTOption {
+	return func(j *JWT) {
+		j.audience = audience
+	}
+}
+
+// NewJWT creates a JWT authenticator that verifies signatures with the keys in a JWKS file.
+// RS, PS and ES signatures with SHA-256, SHA-384 or SHA-512, and EdDSA with Ed25519 are supported.
+func NewJWT(jwksPath string, opts ...JWTOption) (*JWT, error) {
+	keys, err := loadJWKS(jwksPath)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &JWT{keys: keys, now: time.Now}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j, nil
+}
+
+// jwtHeader is the JOSE header of a JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the claims the authenticator checks
+type jwtClaims struct {
+	Subject   string       `json:"sub"`
+	Issuer    string       `json:"iss"`
+	Audience  stringList   `json:"aud"`
+	ExpiresAt *json.Number `json:"exp"`
+	NotBefore *json.Number `json:"nbf"`
+	Scope     string       `json:"scope"`
+	Scp       stringList   `json:"scp"`
+}
+
+// Authenticate implements Authenticator. The token must be signed by a key from the JWKS file,
+// unexpired, and carry a subject, which becomes the principal's name. Its scopes are read from
+// the scope claim, or else the scp claim.
+func (j *JWT) Authenticate(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT signature encoding", ErrInvalidToken)
+	}
+	if err := j.verify(header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT claims: %v", ErrInvalidToken, err)
+	}
+	if err := j.checkClaims(claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	scopes := strings.Fields(claims.Scope)
+	if claims.Scope == "" {
+		scopes = strings.Fields(strings.Join(claims.Scp, " "))
+	}
+	return &Principal{Name: claims.Subject, Scopes: scopes}, nil
+}
+
+// verify checks the signature against the keys that match the header's key ID and algorithm
+func (j *JWT) verify(header jwtHeader, signed, signature []byte) error {
+	hash, ok := algorithmHash(header.Alg)
+	if !ok {
+		return fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	for _, key := range j.keys {
+		if header.Kid != "" && key.id != header.Kid {
+			continue
+		}
+		if key.alg != "" && key.alg != header.Alg {
+			continue
+		}
+		if verifySignature(header.Alg, hash, key.key, signed, signature) {
+			return nil
+		}
+	}
+	return errors.New("JWT signature does not match any key")
+}
+
+// checkClaims checks the time, issuer, audience and subject claims
+func (j *JWT) checkClaims(claims jwtClaims) error {
+	now := j.now()
+
+	if claims.ExpiresAt == nil {
+		return errors.New("JWT has no expiry")
+	}
+	exp, err := numericDate(*claims.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid exp claim: %w", err)
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return errors.New("JWT has expired")
+	}
+
+	if claims.NotBefore != nil {
+		nbf, err := numericDate(*claims.NotBefore)
+		if err != nil {
+			return fmt.Errorf("invalid nbf claim: %w", err)
+		}
+		if now.Add(clockSkew).Before(nbf) {
+			return errors.New("JWT is not valid yet")
+		}
+	}
+
+	if j.issuer != "" && claims.Issuer != j.issuer {
+		return fmt.Errorf("JWT issuer %q is not accepted", claims.Issuer)
+	}
+	if j.audience != "" && !claims.Audience.contains(j.audience) {
+		return errors.New("JWT is not intended for this server")
+	}
+	if claims.Subject == "" {
+		return errors.New("JWT has no subject")
+	}
+	return nil
+}
+
+// algorithmHash returns the hash a JWS algorithm signs with
+func algorithmHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, true
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, true
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, true
+	case "EdDSA":
+		return 0, true
+	}
+	return 0, false
+}
+
+// verifySignature reports whether signature is a valid alg signature of signed by key
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed, signature []byte) bool {
+	var digest []byte
+	if hash != 0 {
+		h := hash.New()
+		h.Write(signed)
+		digest = h.Sum(nil)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil
+		case "PS":
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+			return rsa.VerifyPSS(k, hash, digest, signature, opts) == nil
+		}
+	case *ecdsa.PublicKey:
+		// ES signatures are r and s as fixed-size big-endian integers, and each algorithm
+		// is tied to one curve
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size || ecdsaHash(k) != hash {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(k, signed, signature)
+	}
+	return false
+}
+
+// ecdsaHash returns the hash the ES algorithm for a key's curve uses
+func ecdsaHash(key *ecdsa.PublicKey) crypto.Hash {
+	switch key.Curve.Params().BitSize {
+	case 256:
+		return crypto.SHA256
+	case 384:
+		return crypto.SHA384
+	case 521:
+		return crypto.SHA512
+	}
+	return 0
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// numericDate converts a JWT NumericDate, in seconds since the epoch, to a time
+func numericDate(n json.Number) (time.Time, error) {
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if seconds < 0 || seconds > 1<<53 {
+		return time.Time{}, errors.New("out of range")
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), nil
+}
+
+// stringList is a claim that may be a single string or an array of strings
+type stringList []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = stringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("expected a string or an array of strings")
+	}
+	*l = list
+	return nil
+}
+
+// contains reports whether the list holds s
+func (l stringList) contains(s string) bool {
+	for _, item := range l {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
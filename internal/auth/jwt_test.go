@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeys holds one key of each supported type and the JWKS file listing them
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	ed      ed25519.PrivateKey
+	jwksDoc string
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	doc, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edKey.Public().(ed25519.PublicKey))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	require.NoError(t, err)
+
+	return testKeys{rsa: rsaKey, ec: ecKey, ed: edKey, jwksDoc: string(doc)}
+}
+
+// sign creates a JWT with the given header and claims signed by key
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := b64(header) + "." + b64(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if alg == "PS256" {
+			signature, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(k, []byte(signed))
+	}
+	require.NoError(t, err)
+	return signed + "." + b64(signature)
+}
+
+func TestJWT(t *testing.T) {
+	keys := newTestKeys(t)
+	now := time.Unix(1_800_000_000, 0)
+
+	authenticator, err := NewJWT(writeFile(t, "jwks.json", keys.jwksDoc),
+		WithIssuer("https://issuer.example"), WithAudience("sqlite-mcp"))
+	require.NoError(t, err)
+	authenticator.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":   "alice",
+			"iss":   "https://issuer.example",
+			"aud":   []string{"other", "sqlite-mcp"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "read write",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	t.Run("signature algorithms", func(t *testing.T) {
+		for _, token := range []string{
+			sign(t, "RS256", "rsa", keys.rsa, claims(nil)),
+			sign(t, "PS256", "rsa", keys.rsa, claims(nil)),
+			sign(t, "ES256", "ec", keys.ec, claims(nil)),
+			sign(t, "EdDSA", "ed", keys.ed, claims(nil)),
+			sign(t, "ES256", "", keys.ec, claims(nil)),
+		} {
+			principal, err := authenticator.Authenticate(token)
+			require.NoError(t, err)
+			assert.Equal(t, &Principal{Name: "alice", Scopes: []string{"read", "write"}}, principal)
+		}
+	})
+
+	t.Run("scp claim", func(t *testing.T) {
+		principal, err := authenticator.Authenticate(sign(t, "EdDSA", "ed", keys.ed,
+			claims(map[string]any{"scope": nil, "scp": []string{"read"}, "aud": "sqlite-mcp"})))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"read"}, principal.Scopes)
+	})
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// A token whose claims were swapped for those of another token keeps its signature
+	valid := strings.Split(sign(t, "EdDSA", "ed", keys.ed, claims(nil)), ".")
+	other := strings.Split(sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"sub": "admin"})), ".")
+	tampered := valid[0] + "." + other[1] + "." + valid[2]
+
+	rejected := map[string]string{
+		"wrong key":       sign(t, "ES256", "ec", otherKey, claims(nil)),
+		"key id mismatch": sign(t, "RS256", "ec", keys.rsa, claims(nil)),
+		"algorithm none":  sign(t, "none", "", keys.ed, claims(nil)),
+		"expired":         sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})),
+		"no expiry":       sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"exp": nil})),
+		"not yet valid":   sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})),
+		"wrong issuer":    sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"iss": "https://evil.example"})),
+		"wrong audience":  sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"aud": "other"})),
+		"no subject":      sign(t, "EdDSA", "ed", keys.ed, claims(map[string]any{"sub": nil})),
+		"not a JWT":       "s3cret",
+		"tampered claims": tampered,
+	}
+	for name, token := range rejected {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(token)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	t.Run("clock skew", func(t *testing.T) {
+		_, err := authenticator.Authenticate(sign(t, "EdDSA", "ed", keys.ed,
+			claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})))
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// staticToken is a token read from a tokens file, stored as its hash
+type staticToken struct {
+	hash      [sha256.Size]byte
+	principal Principal
+}
+
+// Tokens authenticates the static bearer tokens listed in a tokens file
+type Tokens struct {
+	tokens []staticToken
+}
+
+// LoadTokens reads a tokens file. Each line holds a principal name, its token and optionally
+// the scopes granted to it, separated by whitespace. Blank lines and lines starting with #
+// are ignored.
+func LoadTokens(path string) (*Tokens, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	defer file.Close()
+
+	t := &Tokens{}
+	names := make(map[string]bool)
+	hashes := make(map[[sha256.Size]byte]bool)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected a principal name followed by its token", path, line)
+		}
+		if names[fields[0]] {
+			return nil, fmt.Errorf("%s:%d: principal %q is listed twice", path, line, fields[0])
+		}
+		hash := sha256.Sum256([]byte(fields[1]))
+		if hashes[hash] {
+			return nil, fmt.Errorf("%s:%d: the token of %q is already used by another principal", path, line, fields[0])
+		}
+		names[fields[0]], hashes[hash] = true, true
+
+		t.tokens = append(t.tokens, staticToken{
+			hash:      hash,
+			principal: Principal{Name: fields[0], Scopes: fields[2:]},
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	if len(t.tokens) == 0 {
+		return nil, fmt.Errorf("%s: no tokens found", path)
+	}
+	return t, nil
+}
+
+// Authenticate implements Authenticator. Tokens are compared in constant time.
+func (t *Tokens) Authenticate(token string) (*Principal, error) {
+	hash := sha256.Sum256([]byte(token))
+	var match *staticToken
+	for i := range t.tokens {
+		if subtle.ConstantTimeCompare(hash[:], t.tokens[i].hash[:]) == 1 {
+			match = &t.tokens[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: unknown bearer token", ErrInvalidToken)
+	}
+
+	principal := match.principal
+	return &principal, nil
+}
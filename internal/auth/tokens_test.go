@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestTokens(t *testing.T) {
+	tokens, err := LoadTokens(writeFile(t, "tokens", `# principal token scopes
+analyst   s3cret-analyst   read
+
+admin     s3cret-admin     read write
+`))
+	require.NoError(t, err)
+
+	principal, err := tokens.Authenticate("s3cret-admin")
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Name: "admin", Scopes: []string{"read", "write"}}, principal)
+
+	principal, err = tokens.Authenticate("s3cret-analyst")
+	require.NoError(t, err)
+	assert.Equal(t, "analyst", principal.Name)
+
+	_, err = tokens.Authenticate("s3cret")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestLoadTokensErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "missing token", content: "analyst\n", want: ":1: expected a principal name followed by its token"},
+		{name: "duplicate principal", content: "a one\na two\n", want: `:2: principal "a" is listed twice`},
+		{name: "duplicate token", content: "a one\nb one\n", want: `:2: the token of "b" is already used`},
+		{name: "empty", content: "# nothing here\n", want: "no tokens found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTokens(writeFile(t, "tokens", tt.content))
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
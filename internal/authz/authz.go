@@ -0,0 +1,229 @@
+// Package authz decides what authenticated principals may do: which tools they may call, on
+// which databases, with which kinds of statements, and which tables and columns they may use
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// ErrNotAllowed is returned when no policy allows what a principal asked for
+var ErrNotAllowed = errors.New("not allowed")
+
+// Statement kinds a policy may allow
+const (
+	// StatementRead is a query that only reads data
+	StatementRead = "read"
+	// StatementInsert is an INSERT or REPLACE statement
+	StatementInsert = "insert"
+	// StatementUpdate is an UPDATE statement
+	StatementUpdate = "update"
+	// StatementDelete is a DELETE statement
+	StatementDelete = "delete"
+	// StatementDDL is a CREATE, DROP or ALTER statement
+	StatementDDL = "ddl"
+	// StatementOther is any other statement that changes the database, such as a PRAGMA that
+	// changes a setting, VACUUM or ANALYZE
+	StatementOther = "other"
+)
+
+// StatementKinds returns the statement kinds a policy may allow
+func StatementKinds() []string {
+	return []string{StatementRead, StatementInsert, StatementUpdate, StatementDelete, StatementDDL, StatementOther}
+}
+
+// Policy grants principals the use of tools on databases. A principal is granted a policy when
+// its name is listed in Principals, or it holds one of Scopes. Lists left empty do not restrict:
+// a policy without Tools allows every tool, and one without Read and Write every table.
+type Policy struct {
+	// Name identifies the policy in errors and logs
+	Name string `yaml:"name"`
+	// Principals lists the principal names the policy applies to; "*" applies it to every
+	// authenticated principal
+	Principals []string `yaml:"principals,omitempty"`
+	// Scopes applies the policy to principals holding any of them
+	Scopes []string `yaml:"scopes,omitempty"`
+	// Databases lists the databases the policy covers
+	Databases []string `yaml:"databases,omitempty"`
+	// Tools lists the tools the policy allows
+	Tools []string `yaml:"tools,omitempty"`
+	// Statements lists the statement kinds the policy allows, see StatementKinds
+	Statements []string `yaml:"statements,omitempty"`
+	// Read lists the tables and columns that may be read, as table or table.column patterns
+	Read []string `yaml:"read,omitempty"`
+	// Write lists the tables and columns that may be changed; inserting and deleting rows
+	// needs a pattern that covers the whole table
+	Write []string `yaml:"write,omitempty"`
+}
+
+// unrestrictedTables reports whether the policy allows every table
+func (p *Policy) unrestrictedTables() bool {
+	return len(p.Read) == 0 && len(p.Write) == 0
+}
+
+// appliesTo reports whether the policy is granted to principal
+func (p *Policy) appliesTo(principal *auth.Principal) bool {
+	for _, name := range p.Principals {
+		if name == "*" || name == principal.Name {
+			return true
+		}
+	}
+	return slices.ContainsFunc(p.Scopes, principal.HasScope)
+}
+
+// covers reports whether the policy allows tool on database. An empty tool stands for reading
+// the database's schema resources, which every policy for the database allows.
+func (p *Policy) covers(tool, dbName string) bool {
+	if len(p.Databases) > 0 && !slices.ContainsFunc(p.Databases, func(db string) bool {
+		return strings.EqualFold(db, dbName)
+	}) {
+		return false
+	}
+	return tool == "" || len(p.Tools) == 0 || slices.Contains(p.Tools, tool)
+}
+
+// allows reports whether the policy allows statements of kind
+func (p *Policy) allows(kind string) bool {
+	return len(p.Statements) == 0 || slices.Contains(p.Statements, kind)
+}
+
+// Validate checks that the policy applies to someone and that its statement kinds and table
+// patterns are valid
+func (p *Policy) Validate() error {
+	if len(p.Principals) == 0 && len(p.Scopes) == 0 {
+		return errors.New("principals or scopes are required")
+	}
+	for _, kind := range p.Statements {
+		if !slices.Contains(StatementKinds(), kind) {
+			return fmt.Errorf("unknown statement kind %q: must be one of %s", kind, strings.Join(StatementKinds(), ", "))
+		}
+	}
+	_, err := database.NewAccess(p.Read, p.Write, nil)
+	return err
+}
+
+// Authorizer grants principals the union of the policies that apply to them. A principal no
+// policy applies to may do nothing.
+type Authorizer struct {
+	policies []Policy
+}
+
+// New creates an Authorizer from policies after checking them
+func New(policies []Policy) (*Authorizer, error) {
+	for i := range policies {
+		if err := policies[i].Validate(); err != nil {
+			name := policies[i].Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("policy %s: %w", name, err)
+		}
+	}
+	return &Authorizer{policies: policies}, nil
+}
+
+// AllowsTool reports whether principal may call tool on any database
+func (a *Authorizer) AllowsTool(principal *auth.Principal, tool string) bool {
+	for i := range a.policies {
+		p := &a.policies[i]
+		if p.appliesTo(principal) && (len(p.Tools) == 0 || slices.Contains(p.Tools, tool)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant returns what principal may do with tool on a database, or an error wrapping
+// ErrNotAllowed when it may not use the tool there. An empty tool asks for access to the
+// database's schema resources.
+func (a *Authorizer) Grant(principal *auth.Principal, tool, dbName string) (*Grant, error) {
+	g := &Grant{}
+	for i := range a.policies {
+		p := &a.policies[i]
+		if p.appliesTo(principal) && p.covers(tool, dbName) {
+			g.policies = append(g.policies, p)
+		}
+	}
+	if len(g.policies) == 0 {
+		if tool == "" {
+			return nil, fmt.Errorf("%w: %s may not use database '%s'", ErrNotAllowed, principal.Name, dbName)
+		}
+		return nil, fmt.Errorf("%w: %s may not use %s on database '%s'", ErrNotAllowed, principal.Name, tool, dbName)
+	}
+	return g, nil
+}
+
+// AllowsToolContext reports whether the principal authenticated for ctx may call tool. Every
+// tool is allowed when a is nil or ctx carries no principal.
+func (a *Authorizer) AllowsToolContext(ctx context.Context, tool string) bool {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if a == nil || !ok {
+		return true
+	}
+	return a.AllowsTool(principal, tool)
+}
+
+// GrantContext returns what the principal authenticated for ctx may do with tool on a
+// database, see Grant. It returns a nil Grant, which allows everything, when a is nil or ctx
+// carries no principal.
+func (a *Authorizer) GrantContext(ctx context.Context, tool, dbName string) (*Grant, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if a == nil || !ok {
+		return nil, nil
+	}
+	return a.Grant(principal, tool, dbName)
+}
+
+// Grant is what a principal may do with one tool on one database: the policies that allow it.
+// A nil *Grant allows everything.
+type Grant struct {
+	policies []*Policy
+}
+
+// Allows reports whether statements of kind may be run
+func (g *Grant) Allows(kind string) bool {
+	if g == nil {
+		return true
+	}
+	return slices.ContainsFunc(g.policies, func(p *Policy) bool { return p.allows(kind) })
+}
+
+// Access returns the tables and columns statements of kind may use: the union of those of the
+// policies that allow kind. It returns nil when they may use every table.
+func (g *Grant) Access(kind string) *database.Access {
+	if g == nil {
+		return nil
+	}
+	return union(slices.DeleteFunc(slices.Clone(g.policies), func(p *Policy) bool { return !p.allows(kind) }))
+}
+
+// Visibility returns the tables and columns that may be listed: those any of the policies
+// allows. It returns nil when every table may be listed.
+func (g *Grant) Visibility() *database.Access {
+	if g == nil {
+		return nil
+	}
+	return union(g.policies)
+}
+
+// union returns access rules allowing the tables and columns any of policies allows, or nil
+// when one of them allows every table
+func union(policies []*Policy) *database.Access {
+	var read, write []string
+	for _, p := range policies {
+		if p.unrestrictedTables() {
+			return nil
+		}
+		read = append(read, p.Read...)
+		write = append(write, p.Write...)
+	}
+	// The patterns were checked by New
+	access, _ := database.NewAccess(read, write, nil)
+	return access
+}
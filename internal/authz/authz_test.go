@@ -0,0 +1,151 @@
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+)
+
+func TestNew(t *testing.T) {
+	for name, tc := range map[string]struct {
+		policy Policy
+		err    string
+	}{
+		"valid":                {policy: Policy{Name: "ok", Principals: []string{"*"}}},
+		"no principals":        {policy: Policy{Name: "nobody"}, err: "policy nobody: principals or scopes are required"},
+		"unknown kind":         {policy: Policy{Scopes: []string{"a"}, Statements: []string{"merge"}}, err: `policy #1: unknown statement kind "merge"`},
+		"invalid read pattern": {policy: Policy{Scopes: []string{"a"}, Read: []string{"users["}}, err: "invalid table pattern"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := New([]Policy{tc.policy})
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	authorizer, err := New([]Policy{
+		{
+			Name:       "analysts",
+			Scopes:     []string{"sql:read"},
+			Databases:  []string{"sales"},
+			Tools:      []string{"execute_query", "list_tables"},
+			Statements: []string{StatementRead},
+			Read:       []string{"orders", "customers.name"},
+		},
+		{
+			Name:       "order-desk",
+			Principals: []string{"alice"},
+			Databases:  []string{"sales"},
+			Tools:      []string{"execute_query", "execute_statement"},
+			Statements: []string{StatementRead, StatementUpdate},
+			Read:       []string{"orders"},
+			Write:      []string{"orders.status"},
+		},
+		{
+			Name:       "admins",
+			Principals: []string{"root"},
+		},
+	})
+	require.NoError(t, err)
+
+	alice := &auth.Principal{Name: "alice", Scopes: []string{"sql:read"}}
+	bob := &auth.Principal{Name: "bob", Scopes: []string{"sql:read"}}
+	mallory := &auth.Principal{Name: "mallory"}
+	root := &auth.Principal{Name: "root"}
+
+	t.Run("tools", func(t *testing.T) {
+		assert.True(t, authorizer.AllowsTool(bob, "list_tables"))
+		assert.False(t, authorizer.AllowsTool(bob, "execute_statement"))
+		assert.True(t, authorizer.AllowsTool(alice, "execute_statement"))
+		assert.False(t, authorizer.AllowsTool(mallory, "list_tables"))
+		assert.True(t, authorizer.AllowsTool(root, "begin_transaction"))
+	})
+
+	t.Run("databases", func(t *testing.T) {
+		_, err := authorizer.Grant(bob, "execute_query", "SALES")
+		assert.NoError(t, err)
+
+		_, err = authorizer.Grant(bob, "execute_query", "inventory")
+		require.ErrorIs(t, err, ErrNotAllowed)
+		assert.EqualError(t, err, "not allowed: bob may not use execute_query on database 'inventory'")
+
+		_, err = authorizer.Grant(bob, "", "sales")
+		assert.NoError(t, err)
+		_, err = authorizer.Grant(mallory, "", "sales")
+		assert.EqualError(t, err, "not allowed: mallory may not use database 'sales'")
+	})
+
+	t.Run("statements and tables", func(t *testing.T) {
+		grant, err := authorizer.Grant(alice, "execute_query", "sales")
+		require.NoError(t, err)
+		assert.True(t, grant.Allows(StatementRead))
+		assert.True(t, grant.Allows(StatementUpdate))
+		assert.False(t, grant.Allows(StatementDelete))
+
+		// Both policies allow reads, so their tables are combined
+		read := grant.Access(StatementRead)
+		assert.True(t, read.CanRead("orders", "total"))
+		assert.True(t, read.CanRead("customers", "name"))
+		assert.False(t, read.CanRead("customers", "email"))
+
+		update := grant.Access(StatementUpdate)
+		assert.True(t, update.CanWrite("orders", "status"))
+		assert.False(t, update.CanRead("customers", "name"))
+
+		visible := grant.Visibility()
+		assert.True(t, visible.Visible("customers"))
+		assert.False(t, visible.Visible("payments"))
+	})
+
+	t.Run("unrestricted", func(t *testing.T) {
+		grant, err := authorizer.Grant(root, "execute_statement", "anything")
+		require.NoError(t, err)
+		assert.True(t, grant.Allows(StatementDDL))
+		assert.Nil(t, grant.Access(StatementDDL))
+		assert.Nil(t, grant.Visibility())
+
+		var none *Grant
+		assert.True(t, none.Allows(StatementDelete))
+		assert.Nil(t, none.Access(StatementDelete))
+	})
+}
+
+func TestAuthorizerContext(t *testing.T) {
+	authorizer, err := New([]Policy{{Principals: []string{"alice"}, Tools: []string{"list_tables"}}})
+	require.NoError(t, err)
+
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice"})
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob"})
+
+	assert.True(t, authorizer.AllowsToolContext(alice, "list_tables"))
+	assert.False(t, authorizer.AllowsToolContext(alice, "execute_query"))
+	assert.False(t, authorizer.AllowsToolContext(bob, "list_tables"))
+
+	grant, err := authorizer.GrantContext(alice, "list_tables", "main")
+	require.NoError(t, err)
+	assert.NotNil(t, grant)
+	_, err = authorizer.GrantContext(bob, "list_tables", "main")
+	assert.ErrorIs(t, err, ErrNotAllowed)
+
+	// Callers that did not authenticate, and servers without policies, are not restricted
+	assert.True(t, authorizer.AllowsToolContext(context.Background(), "execute_query"))
+	grant, err = authorizer.GrantContext(context.Background(), "execute_query", "main")
+	require.NoError(t, err)
+	assert.Nil(t, grant)
+
+	var none *Authorizer
+	assert.True(t, none.AllowsToolContext(bob, "execute_query"))
+	grant, err = none.GrantContext(bob, "execute_query", "main")
+	require.NoError(t, err)
+	assert.Nil(t, grant)
+}
@@ -0,0 +1,333 @@
+// Package config defines the server configuration and loads it from a YAML file
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/prompts"
+	"github.com/StacklokLabs/sqlite-mcp/internal/tools"
+)
+
+const (
+	// DefaultDB is the database served when none is configured
+	DefaultDB = "./database.db"
+	// DefaultAddr is the address HTTP transports listen on
+	DefaultAddr = ":8080"
+	// DefaultQueryTimeout is how long a single query or statement may run
+	DefaultQueryTimeout = 30 * time.Second
+
+	// Transport types
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+	TransportStdio          = "stdio"
+)
+
+// invalidNameChars matches the characters database names may not contain
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// Config is the server configuration
+type Config struct {
+	// Transport is "sse", "streamable-http" or "stdio"
+	Transport string `yaml:"transport"`
+	// Addr is the address HTTP transports listen on
+	Addr string `yaml:"addr"`
+	// ReadWrite opens databases for writing unless they say otherwise
+	ReadWrite bool `yaml:"read_write"`
+	// Databases are served in order; the first is the default
+	Databases []Database `yaml:"databases"`
+	// DatabaseDir serves every database file in a directory after Databases
+	DatabaseDir string `yaml:"database_dir,omitempty"`
+	// Create creates missing database files, and DatabaseDir, at startup, and lets the
+	// create_database tool add databases to DatabaseDir
+	Create bool `yaml:"create"`
+	// Schema is a script, or a directory of .sql scripts, run on database files as they are
+	// created
+	Schema string `yaml:"schema,omitempty"`
+	// Attach lists databases attached read-only to every served database
+	Attach []database.Attachment `yaml:"attach,omitempty"`
+	// InitSQL lists scripts, or directories of .sql scripts, run on every in-memory database
+	// before it is served
+	InitSQL    []string   `yaml:"init_sql,omitempty"`
+	Durability Durability `yaml:"durability"`
+	Pool       Pool       `yaml:"pool"`
+	Limits     Limits     `yaml:"limits"`
+	Policy     Policy     `yaml:"policy"`
+	Output     Output     `yaml:"output"`
+	Tools      Tools      `yaml:"tools"`
+	Tables     Tables     `yaml:"tables,omitempty"`
+	Auth       Auth       `yaml:"auth,omitempty"`
+	// Masking masks the values of result columns for every client
+	Masking []database.MaskRule `yaml:"masking,omitempty"`
+	// Authorization lists the policies granted to authenticated principals. When it is empty,
+	// every authenticated principal may do anything.
+	Authorization []authz.Policy `yaml:"authorization,omitempty"`
+	// PromptsDir holds Markdown prompt templates served besides the built-in prompts
+	PromptsDir string `yaml:"prompts_dir,omitempty"`
+
+	// source is the parsed file the configuration was loaded from, used to locate errors
+	source *yaml.Node
+	// file is the path of that file
+	file string
+}
+
+// Database is a database to serve
+type Database struct {
+	// Name identifies the database in tool calls and resource URIs; it defaults to the file name
+	Name string `yaml:"name,omitempty"`
+	Path string `yaml:"path"`
+	// ReadWrite overrides Config.ReadWrite for this database when set
+	ReadWrite *bool `yaml:"read_write,omitempty"`
+}
+
+// Durability selects the connection settings databases are opened with. The fields other
+// than Profile override the profile's settings when they are set.
+type Durability struct {
+	// Profile is "container-safe", "wal" or "full"
+	Profile     string    `yaml:"profile"`
+	JournalMode string    `yaml:"journal_mode,omitempty"`
+	Synchronous string    `yaml:"synchronous,omitempty"`
+	BusyTimeout *Duration `yaml:"busy_timeout,omitempty"`
+	// CacheSize is in pages, or in KiB when negative
+	CacheSize *int64 `yaml:"cache_size,omitempty"`
+	MmapSize  *int64 `yaml:"mmap_size,omitempty"`
+}
+
+// Pragmas returns the profile's settings with the overrides applied
+func (d Durability) Pragmas() (database.Pragmas, error) {
+	p, err := database.ProfilePragmas(d.Profile)
+	if err != nil {
+		return p, err
+	}
+	if d.JournalMode != "" {
+		p.JournalMode = strings.ToLower(d.JournalMode)
+	}
+	if d.Synchronous != "" {
+		p.Synchronous = strings.ToLower(d.Synchronous)
+	}
+	if d.BusyTimeout != nil {
+		p.BusyTimeout = time.Duration(*d.BusyTimeout)
+	}
+	if d.CacheSize != nil {
+		p.CacheSize = *d.CacheSize
+	}
+	if d.MmapSize != nil {
+		p.MmapSize = *d.MmapSize
+	}
+	return p, p.Validate()
+}
+
+// Pool sizes the connections kept to each database. Queries run on a pool of read-only
+// connections while writes take turns on a single writer connection.
+type Pool struct {
+	MaxReaders  int `yaml:"max_readers"`
+	IdleReaders int `yaml:"idle_readers"`
+	// MaxQueuedWrites limits the writes waiting for the writer; 0 disables the limit
+	MaxQueuedWrites int `yaml:"max_queued_writes"`
+}
+
+// Limits bounds how much work a single call may do
+type Limits struct {
+	QueryTimeout  Duration `yaml:"query_timeout"`
+	MaxRows       int      `yaml:"max_rows"`
+	PageSize      int      `yaml:"page_size"`
+	TxIdleTimeout Duration `yaml:"tx_idle_timeout"`
+}
+
+// Policy restricts what write statements may do
+type Policy struct {
+	RequireWhere    bool  `yaml:"require_where"`
+	AllowDDL        bool  `yaml:"allow_ddl"`
+	AllowVacuumInto bool  `yaml:"allow_vacuum_into"`
+	MaxAffectedRows int64 `yaml:"max_affected_rows"`
+}
+
+// Output controls how results are encoded
+type Output struct {
+	BigIntStrings bool `yaml:"big_int_strings"`
+}
+
+// Tools selects which tools are registered
+type Tools struct {
+	// Enabled limits the tools to those listed when it is not empty
+	Enabled []string `yaml:"enabled,omitempty"`
+	// Disabled removes the tools listed
+	Disabled []string `yaml:"disabled,omitempty"`
+}
+
+// Tables hides tables and columns from every client. The lists hold table or table.column
+// patterns with * and ? wildcards.
+type Tables struct {
+	// Allow limits clients to the tables listed when it is not empty
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny hides the tables listed
+	Deny []string `yaml:"deny,omitempty"`
+	// DenyColumns hides the columns listed, as table.column
+	DenyColumns []string `yaml:"deny_columns,omitempty"`
+}
+
+// Access returns the access rules for the table lists, or nil when they hide nothing
+func (t Tables) Access() (*database.Access, error) {
+	if len(t.Allow) == 0 && len(t.Deny) == 0 && len(t.DenyColumns) == 0 {
+		return nil, nil
+	}
+	allow := t.Allow
+	if len(allow) == 0 {
+		allow = []string{"*"}
+	}
+	return database.NewAccess(allow, allow, append(slices.Clone(t.Deny), t.DenyColumns...))
+}
+
+// Auth configures authentication for the HTTP transports. When both bearer tokens and JWTs are
+// configured, a request is accepted with either.
+type Auth struct {
+	// TokensFile lists static bearer tokens
+	TokensFile string `yaml:"tokens_file,omitempty"`
+	JWT        JWT    `yaml:"jwt,omitempty"`
+}
+
+// JWT configures JSON Web Token validation
+type JWT struct {
+	// JWKSFile holds the keys tokens are signed with
+	JWKSFile string `yaml:"jwks_file,omitempty"`
+	// Issuer is required in the iss claim when set
+	Issuer string `yaml:"issuer,omitempty"`
+	// Audience is required in the aud claim when set
+	Audience string `yaml:"audience,omitempty"`
+}
+
+// Enabled reports whether any authentication is configured
+func (a Auth) Enabled() bool {
+	return a.TokensFile != "" || a.JWT.JWKSFile != ""
+}
+
+// Default returns the configuration used when nothing is configured
+func Default() *Config {
+	return &Config{
+		Transport:  TransportStreamableHTTP,
+		Addr:       DefaultAddr,
+		Durability: Durability{Profile: database.DefaultDurability},
+		Pool:       Pool{MaxReaders: database.DefaultMaxReaders, IdleReaders: database.DefaultIdleReaders},
+		Limits: Limits{
+			QueryTimeout:  Duration(DefaultQueryTimeout),
+			MaxRows:       tools.DefaultMaxRows,
+			PageSize:      tools.DefaultPageSize,
+			TxIdleTimeout: Duration(tools.DefaultTransactionIdleTimeout),
+		},
+		Policy: Policy{RequireWhere: tools.DefaultWritePolicy().RequireWhere},
+	}
+}
+
+// Prompts returns the prompt templates of PromptsDir, or none when it is not set
+func (c *Config) Prompts() ([]*prompts.Template, error) {
+	if c.PromptsDir == "" {
+		return nil, nil
+	}
+	return prompts.LoadTemplates(c.PromptsDir)
+}
+
+// ToolEnabled reports whether a tool is selected by Tools
+func (c *Config) ToolEnabled(name string) bool {
+	if len(c.Tools.Enabled) > 0 && !contains(c.Tools.Enabled, name) {
+		return false
+	}
+	return !contains(c.Tools.Disabled, name)
+}
+
+// DatabaseReadWrite reports whether a database is opened for writing
+func (c *Config) DatabaseReadWrite(db Database) bool {
+	if db.ReadWrite != nil {
+		return *db.ReadWrite
+	}
+	return c.ReadWrite
+}
+
+// CanCreateDatabases reports whether the create_database tool may add databases
+func (c *Config) CanCreateDatabases() bool {
+	return c.Create && c.DatabaseDir != ""
+}
+
+// DatabaseName returns the name a database is served under, derived from its file name
+// without the extension unless it is named explicitly. The characters a derived name may not
+// contain are replaced with underscores.
+func DatabaseName(db Database) string {
+	if db.Name != "" {
+		return db.Name
+	}
+	if db.Path == database.InMemoryDB {
+		return "memory"
+	}
+	base := filepath.Base(db.Path)
+	name := invalidNameChars.ReplaceAllString(strings.TrimSuffix(base, filepath.Ext(base)), "_")
+	if name == "" || name[0] == '-' {
+		name = "_" + name
+	}
+	return name
+}
+
+// DatabaseNames returns the names dbs are served under, in order. A derived name that an
+// earlier database already uses gets a numbered suffix; explicit names are kept as they are.
+func DatabaseNames(dbs []Database) []string {
+	names := make([]string, len(dbs))
+	taken := make(map[string]bool, len(dbs))
+	for i, db := range dbs {
+		name := DatabaseName(db)
+		for n := 2; db.Name == "" && taken[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", DatabaseName(db), n)
+		}
+		names[i] = name
+		taken[strings.ToLower(name)] = true
+	}
+	return names
+}
+
+// Print returns the configuration as YAML
+func (c *Config) Print() (string, error) {
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c); err != nil {
+		return "", fmt.Errorf("failed to format configuration: %w", err)
+	}
+	return out.String(), nil
+}
+
+// Duration is a time.Duration written as a string such as "30s" or "5m"
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q: use a number with a unit such as 30s or 5m", node.Line, s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
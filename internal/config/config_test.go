@@ -0,0 +1,103 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolEnabled(t *testing.T) {
+	cfg := Default()
+	assert.True(t, cfg.ToolEnabled("execute_query"))
+
+	cfg.Tools.Disabled = []string{"execute_statement"}
+	assert.True(t, cfg.ToolEnabled("execute_query"))
+	assert.False(t, cfg.ToolEnabled("execute_statement"))
+
+	cfg.Tools.Enabled = []string{"list_tables", "execute_statement"}
+	assert.True(t, cfg.ToolEnabled("list_tables"))
+	assert.False(t, cfg.ToolEnabled("execute_query"))
+	assert.False(t, cfg.ToolEnabled("execute_statement"), "disabled wins over enabled")
+}
+
+func TestTablesAccess(t *testing.T) {
+	access, err := Tables{}.Access()
+	require.NoError(t, err)
+	assert.Nil(t, access)
+
+	access, err = Tables{Deny: []string{"secrets"}, DenyColumns: []string{"users.password_hash"}}.Access()
+	require.NoError(t, err)
+	assert.True(t, access.CanRead("orders", "total"))
+	assert.True(t, access.CanWrite("orders", ""))
+	assert.False(t, access.Visible("secrets"))
+	assert.True(t, access.CanRead("users", "name"))
+	assert.False(t, access.CanRead("users", "password_hash"))
+
+	access, err = Tables{Allow: []string{"orders", "order_*"}}.Access()
+	require.NoError(t, err)
+	assert.True(t, access.Visible("order_items"))
+	assert.False(t, access.Visible("users"))
+}
+
+func TestDurabilityPragmas(t *testing.T) {
+	p, err := Default().Durability.Pragmas()
+	require.NoError(t, err)
+	assert.Equal(t, "wal", p.JournalMode)
+	assert.Equal(t, "normal", p.Synchronous)
+
+	busy := Duration(time.Second)
+	mmap := int64(1 << 20)
+	p, err = Durability{Profile: "wal", Synchronous: "FULL", BusyTimeout: &busy, MmapSize: &mmap}.Pragmas()
+	require.NoError(t, err)
+	assert.Equal(t, "wal", p.JournalMode)
+	assert.Equal(t, "full", p.Synchronous)
+	assert.Equal(t, time.Second, p.BusyTimeout)
+	assert.Equal(t, int64(-64000), p.CacheSize)
+	assert.Equal(t, int64(1<<20), p.MmapSize)
+
+	_, err = Durability{Profile: "fast"}.Pragmas()
+	assert.ErrorContains(t, err, `unknown durability profile "fast"`)
+}
+
+func TestDatabaseName(t *testing.T) {
+	assert.Equal(t, "sales", DatabaseName(Database{Path: "/data/sales.db"}))
+	assert.Equal(t, "orders", DatabaseName(Database{Name: "orders", Path: "/data/sales.db"}))
+	assert.Equal(t, "memory", DatabaseName(Database{Path: ":memory:"}))
+	assert.Equal(t, "my_data", DatabaseName(Database{Path: "/tmp/my.data.db"}))
+	assert.Equal(t, "_-sales_2024", DatabaseName(Database{Path: "/data/-sales 2024.sqlite"}))
+	assert.Equal(t, "_", DatabaseName(Database{Path: "/data/.db"}))
+}
+
+func TestDatabaseNames(t *testing.T) {
+	names := DatabaseNames([]Database{
+		{Path: "/data/my.data.db"},
+		{Path: "/archive/my-data.db"},
+		{Path: "/archive/my_data.sqlite"},
+		{Name: "my_data_3", Path: "/data/other.db"},
+		{Path: "/data/MY_DATA.db"},
+	})
+	assert.Equal(t, []string{"my_data", "my-data", "my_data_2", "my_data_3", "MY_DATA_4"}, names)
+}
+
+func TestDatabaseReadWrite(t *testing.T) {
+	readOnly := false
+	cfg := Default()
+	cfg.ReadWrite = true
+	assert.True(t, cfg.DatabaseReadWrite(Database{Path: "a.db"}))
+	assert.False(t, cfg.DatabaseReadWrite(Database{Path: "a.db", ReadWrite: &readOnly}))
+}
+
+func TestPrint(t *testing.T) {
+	cfg := Default()
+	cfg.Databases = []Database{{Name: "sales", Path: "sales.db"}}
+	cfg.Limits.QueryTimeout = Duration(90 * time.Second)
+
+	out, err := cfg.Print()
+	require.NoError(t, err)
+	assert.Contains(t, out, "transport: streamable-http\n")
+	assert.Contains(t, out, "databases:\n  - name: sales\n    path: sales.db\n")
+	assert.Contains(t, out, "query_timeout: 1m30s\n")
+	assert.Contains(t, out, "require_where: true\n")
+}
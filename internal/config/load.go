@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// yamlLine matches the line number yaml.v3 puts at the start of its error messages
+	yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+	// unknownField matches the message yaml.v3 gives for keys with no matching field
+	unknownField = regexp.MustCompile(`field (\S+) not found in type \S+`)
+)
+
+// Load reads a configuration file over the defaults. Unknown keys and values of the wrong type
+// are errors, reported with the file name and line.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := Default()
+	cfg.file = path
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, cfg.yamlError(err)
+	}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	cfg.source = &doc
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, cfg.yamlError(err)
+	}
+	return cfg, nil
+}
+
+// ApplyEnv overrides the configuration with the MCP_PORT and MCP_TRANSPORT environment
+// variables. Invalid values are logged and ignored.
+func (c *Config) ApplyEnv() {
+	if envPort := os.Getenv("MCP_PORT"); envPort != "" {
+		if portNum, err := strconv.Atoi(envPort); err != nil {
+			log.Printf("Invalid MCP_PORT value: %s (must be a valid number), using %s", envPort, c.Addr)
+		} else if portNum < 0 || portNum > 65535 {
+			log.Printf("Invalid MCP_PORT value: %s (must be between 0 and 65535), using %s", envPort, c.Addr)
+		} else {
+			c.Addr = ":" + envPort
+		}
+	}
+
+	if transportEnv := os.Getenv("MCP_TRANSPORT"); transportEnv != "" {
+		transport := strings.ToLower(strings.TrimSpace(transportEnv))
+		if validTransport(transport) {
+			c.Transport = transport
+		} else {
+			log.Printf("Invalid MCP_TRANSPORT: %s, using %s", transportEnv, c.Transport)
+		}
+	}
+}
+
+// yamlError rewrites the line numbers in a yaml.v3 error as file:line
+func (c *Config) yamlError(err error) error {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		errs := make([]error, len(typeErr.Errors))
+		for i, msg := range typeErr.Errors {
+			errs[i] = errors.New(c.locateMessage(msg))
+		}
+		return errors.Join(errs...)
+	}
+	return errors.New(c.locateMessage(err.Error()))
+}
+
+// locateMessage replaces a leading "line N: " in msg with the file name and line
+func (c *Config) locateMessage(msg string) string {
+	msg = unknownField.ReplaceAllString(msg, `unknown key "$1"`)
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		return fmt.Sprintf("%s:%s: %s", c.file, m[1], msg[len(m[0]):])
+	}
+	return fmt.Sprintf("%s: %s", c.file, strings.TrimPrefix(msg, "yaml: "))
+}
+
+// line returns the line of the value at path in the configuration file, or 0 when the value
+// was not set by the file. Path elements are mapping keys or sequence indexes.
+func (c *Config) line(path ...string) int {
+	if c.source == nil || len(c.source.Content) == 0 {
+		return 0
+	}
+
+	node := c.source.Content[0]
+	for _, elem := range path {
+		node = child(node, elem)
+		if node == nil {
+			return 0
+		}
+	}
+	return node.Line
+}
+
+// child returns the value under a mapping key or sequence index, or nil
+func child(node *yaml.Node, elem string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == elem {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(elem); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
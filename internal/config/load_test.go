@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes a configuration file in a temporary directory and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("overrides defaults", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, `
+transport: stdio
+read_write: true
+databases:
+  - name: sales
+    path: ./sales.db
+  - path: ./audit.db
+    read_write: false
+limits:
+  query_timeout: 5s
+  max_rows: 50
+policy:
+  allow_ddl: true
+  allow_vacuum_into: true
+tools:
+  disabled: [execute_statement]
+`))
+		require.NoError(t, err)
+
+		assert.Equal(t, TransportStdio, cfg.Transport)
+		assert.Equal(t, DefaultAddr, cfg.Addr)
+		assert.True(t, cfg.ReadWrite)
+		require.Len(t, cfg.Databases, 2)
+		assert.Equal(t, "sales", DatabaseName(cfg.Databases[0]))
+		assert.Equal(t, "audit", DatabaseName(cfg.Databases[1]))
+		assert.False(t, cfg.DatabaseReadWrite(cfg.Databases[1]))
+		assert.Equal(t, Duration(5*time.Second), cfg.Limits.QueryTimeout)
+		assert.Equal(t, 50, cfg.Limits.MaxRows)
+		assert.Equal(t, Default().Limits.PageSize, cfg.Limits.PageSize)
+		assert.True(t, cfg.Policy.AllowDDL)
+		assert.True(t, cfg.Policy.AllowVacuumInto)
+		assert.True(t, cfg.Policy.RequireWhere)
+		assert.Equal(t, []string{"execute_statement"}, cfg.Tools.Disabled)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		cfg, err := Load(writeConfig(t, ""))
+		require.NoError(t, err)
+		assert.Equal(t, Default(), &Config{
+			Transport: cfg.Transport, Addr: cfg.Addr, Durability: cfg.Durability, Pool: cfg.Pool,
+			Limits: cfg.Limits, Policy: cfg.Policy,
+		})
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeConfig(t, "transport: stdio\nlimits:\n  max_rowz: 10\n")
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+`:3: unknown key "max_rowz"`)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		path := writeConfig(t, "limits:\n  max_rows: lots\n")
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+":2: ")
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		path := writeConfig(t, "limits:\n  query_timeout: 30\n")
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+":2: invalid duration \"30\"")
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		path := writeConfig(t, "transport: stdio\n  addr: :9000\n")
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), path+":2: ")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Run("overrides file", func(t *testing.T) {
+		t.Setenv("MCP_PORT", "9000")
+		t.Setenv("MCP_TRANSPORT", " SSE ")
+		cfg, err := Load(writeConfig(t, "addr: :7000\ntransport: stdio\n"))
+		require.NoError(t, err)
+
+		cfg.ApplyEnv()
+		assert.Equal(t, ":9000", cfg.Addr)
+		assert.Equal(t, TransportSSE, cfg.Transport)
+	})
+
+	t.Run("invalid values are ignored", func(t *testing.T) {
+		t.Setenv("MCP_PORT", "70000")
+		t.Setenv("MCP_TRANSPORT", "carrier-pigeon")
+		cfg := Default()
+
+		cfg.ApplyEnv()
+		assert.Equal(t, DefaultAddr, cfg.Addr)
+		assert.Equal(t, TransportStreamableHTTP, cfg.Transport)
+	})
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/tools"
+)
+
+// validTransport reports whether transport is one of the supported transports
+func validTransport(transport string) bool {
+	switch transport {
+	case TransportSSE, TransportStreamableHTTP, TransportStdio:
+		return true
+	}
+	return false
+}
+
+// validator collects the problems found in a configuration
+type validator struct {
+	config *Config
+	errs   []error
+}
+
+// errorf records a problem with the value at path, prefixed with its location in the file
+// when the file set it
+func (v *validator) errorf(path []string, format string, args ...any) {
+	msg := strings.Join(path, ".") + ": " + fmt.Sprintf(format, args...)
+	if line := v.config.line(path...); line > 0 {
+		msg = fmt.Sprintf("%s:%d: %s", v.config.file, line, msg)
+	}
+	v.errs = append(v.errs, errors.New(msg))
+}
+
+// Validate checks the configuration for values the server cannot start with, including
+// database and attachment files that do not exist. It reports every problem found.
+func (c *Config) Validate() error {
+	v := &validator{config: c}
+
+	if !validTransport(c.Transport) {
+		v.errorf([]string{"transport"}, "must be %q, %q or %q, got %q",
+			TransportSSE, TransportStreamableHTTP, TransportStdio, c.Transport)
+	}
+	if c.Addr == "" && c.Transport != TransportStdio {
+		v.errorf([]string{"addr"}, "is required for the %s transport", c.Transport)
+	}
+
+	v.databases()
+	v.attachments()
+	v.initSQL()
+	v.durability()
+	v.pool()
+	v.limits()
+
+	if c.Policy.MaxAffectedRows < 0 {
+		v.errorf([]string{"policy", "max_affected_rows"}, "must not be negative")
+	}
+
+	v.tools("enabled", c.Tools.Enabled)
+	v.tools("disabled", c.Tools.Disabled)
+	v.tables()
+	v.prompts()
+	v.masking()
+	v.auth()
+	v.authorization()
+
+	return errors.Join(v.errs...)
+}
+
+// databases checks the served databases and database directory
+func (v *validator) databases() {
+	seen := make(map[string]bool)
+	names := DatabaseNames(v.config.Databases)
+	for i, db := range v.config.Databases {
+		path := []string{"databases", strconv.Itoa(i)}
+		if db.Path == "" {
+			v.errorf(append(path, "path"), "is required")
+			continue
+		}
+
+		name := names[i]
+		if err := database.ValidateDatabaseName(name); err != nil {
+			v.errorf(append(path, "name"), "%v", err)
+		} else if seen[strings.ToLower(name)] {
+			v.errorf(append(path, "name"), "database %q is already configured", name)
+		}
+		seen[strings.ToLower(name)] = true
+
+		if db.Path != database.InMemoryDB && !v.config.Create {
+			if _, err := os.Stat(db.Path); err != nil {
+				v.errorf(append(path, "path"), "database file does not exist: %s", db.Path)
+			}
+		}
+	}
+
+	if dir := v.config.DatabaseDir; dir != "" {
+		info, err := os.Stat(dir)
+		missing := os.IsNotExist(err) && v.config.Create
+		if !missing && (err != nil || !info.IsDir()) {
+			v.errorf([]string{"database_dir"}, "not a directory: %s", dir)
+		}
+	}
+
+	if schema := v.config.Schema; schema != "" {
+		if !v.config.Create {
+			v.errorf([]string{"schema"}, "requires create to be set")
+		} else if _, err := database.ScriptFiles(schema); err != nil {
+			v.errorf([]string{"schema"}, "%v", err)
+		}
+	}
+}
+
+// attachments checks the databases attached to every served database
+func (v *validator) attachments() {
+	seen := make(map[string]bool)
+	for i, a := range v.config.Attach {
+		path := []string{"attach", strconv.Itoa(i)}
+		switch alias := strings.ToLower(a.Alias); {
+		case alias == "":
+			v.errorf(append(path, "alias"), "is required")
+		case alias == "main" || alias == "temp":
+			v.errorf(append(path, "alias"), "%q is reserved", a.Alias)
+		case seen[alias]:
+			v.errorf(append(path, "alias"), "%q is already attached", a.Alias)
+		}
+		seen[strings.ToLower(a.Alias)] = true
+
+		if a.Path == "" {
+			v.errorf(append(path, "path"), "is required")
+		} else if _, err := os.Stat(a.Path); err != nil {
+			v.errorf(append(path, "path"), "cannot attach %s: file does not exist", a.Path)
+		}
+	}
+}
+
+// initSQL checks that the scripts run on in-memory databases can be found
+func (v *validator) initSQL() {
+	for i, path := range v.config.InitSQL {
+		if _, err := database.ScriptFiles(path); err != nil {
+			v.errorf([]string{"init_sql", strconv.Itoa(i)}, "%v", err)
+		}
+	}
+}
+
+// durability checks the durability profile and its overrides
+func (v *validator) durability() {
+	d := v.config.Durability
+	path := []string{"durability"}
+	if _, err := database.ProfilePragmas(d.Profile); err != nil {
+		v.errorf(append(path, "profile"), "%v", err)
+		return
+	}
+	if d.JournalMode != "" && !contains(database.JournalModes(), strings.ToLower(d.JournalMode)) {
+		v.errorf(append(path, "journal_mode"), "must be one of %s, got %q",
+			strings.Join(database.JournalModes(), ", "), d.JournalMode)
+	}
+	if d.Synchronous != "" && !contains(database.SynchronousModes(), strings.ToLower(d.Synchronous)) {
+		v.errorf(append(path, "synchronous"), "must be one of %s, got %q",
+			strings.Join(database.SynchronousModes(), ", "), d.Synchronous)
+	}
+	if d.BusyTimeout != nil && *d.BusyTimeout < 0 {
+		v.errorf(append(path, "busy_timeout"), "must not be negative")
+	}
+	if d.MmapSize != nil && *d.MmapSize < 0 {
+		v.errorf(append(path, "mmap_size"), "must not be negative")
+	}
+}
+
+// pool checks the connection pool sizes
+func (v *validator) pool() {
+	pool := v.config.Pool
+	if pool.MaxReaders < 1 {
+		v.errorf([]string{"pool", "max_readers"}, "must be at least 1")
+	}
+	if pool.IdleReaders < 0 {
+		v.errorf([]string{"pool", "idle_readers"}, "must not be negative")
+	}
+	if pool.MaxQueuedWrites < 0 {
+		v.errorf([]string{"pool", "max_queued_writes"}, "must not be negative")
+	}
+}
+
+// limits checks the per-call limits
+func (v *validator) limits() {
+	limits := v.config.Limits
+	if limits.QueryTimeout < 0 {
+		v.errorf([]string{"limits", "query_timeout"}, "must not be negative")
+	}
+	if limits.MaxRows < 0 {
+		v.errorf([]string{"limits", "max_rows"}, "must not be negative")
+	}
+	if limits.PageSize < 1 {
+		v.errorf([]string{"limits", "page_size"}, "must be at least 1")
+	}
+	if limits.TxIdleTimeout < 0 {
+		v.errorf([]string{"limits", "tx_idle_timeout"}, "must not be negative")
+	}
+}
+
+// tools checks that a tool list names only existing tools
+func (v *validator) tools(key string, names []string) {
+	known := tools.ToolNames()
+	for i, name := range names {
+		if !contains(known, name) {
+			v.errorf([]string{"tools", key, strconv.Itoa(i)}, "unknown tool %q", name)
+		}
+	}
+}
+
+// tables checks the table and column patterns of the allow and deny lists
+func (v *validator) tables() {
+	t := v.config.Tables
+	for _, list := range []struct {
+		key      string
+		patterns []string
+	}{{"allow", t.Allow}, {"deny", t.Deny}, {"deny_columns", t.DenyColumns}} {
+		for i, pattern := range list.patterns {
+			path := []string{"tables", list.key, strconv.Itoa(i)}
+			if _, err := database.NewAccess(nil, nil, []string{pattern}); err != nil {
+				v.errorf(path, "%v", err)
+			} else if list.key == "deny_columns" && !strings.Contains(pattern, ".") {
+				v.errorf(path, "column pattern %q must have the form table.column", pattern)
+			}
+		}
+	}
+}
+
+// prompts checks that the prompt templates can be loaded
+func (v *validator) prompts() {
+	if _, err := v.config.Prompts(); err != nil {
+		v.errorf([]string{"prompts_dir"}, "%v", err)
+	}
+}
+
+// masking checks the masking rules
+func (v *validator) masking() {
+	for i, rule := range v.config.Masking {
+		if _, err := database.NewMasks([]database.MaskRule{rule}); err != nil {
+			v.errorf([]string{"masking", strconv.Itoa(i)}, "%v", err)
+		}
+	}
+}
+
+// auth checks that the tokens and JWKS files can be loaded
+func (v *validator) auth() {
+	a := v.config.Auth
+	if a.TokensFile != "" {
+		if _, err := auth.LoadTokens(a.TokensFile); err != nil {
+			v.errorf([]string{"auth", "tokens_file"}, "%v", err)
+		}
+	}
+
+	if a.JWT.JWKSFile != "" {
+		if _, err := auth.NewJWT(a.JWT.JWKSFile); err != nil {
+			v.errorf([]string{"auth", "jwt", "jwks_file"}, "%v", err)
+		}
+	} else if a.JWT.Issuer != "" || a.JWT.Audience != "" {
+		v.errorf([]string{"auth", "jwt"}, "jwks_file is required to check JWT issuer and audience")
+	}
+}
+
+// authorization checks the authorization policies, which need authentication to identify
+// the principals they apply to
+func (v *validator) authorization() {
+	policies := v.config.Authorization
+	if len(policies) > 0 && !v.config.Auth.Enabled() {
+		v.errorf([]string{"authorization"}, "requires auth.tokens_file or auth.jwt.jwks_file to be set")
+	}
+
+	known := tools.ToolNames()
+	for i := range policies {
+		path := []string{"authorization", strconv.Itoa(i)}
+		if err := policies[i].Validate(); err != nil {
+			v.errorf(path, "%v", err)
+		}
+		for j, tool := range policies[i].Tools {
+			if !contains(known, tool) {
+				v.errorf(append(path, "tools", strconv.Itoa(j)), "unknown tool %q", tool)
+			}
+		}
+	}
+}
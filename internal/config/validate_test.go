@@ -0,0 +1,221 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "sales.db")
+	require.NoError(t, os.WriteFile(dbPath, nil, 0o600))
+
+	t.Run("valid", func(t *testing.T) {
+		cfg := Default()
+		cfg.Databases = []Database{{Path: dbPath}, {Name: "scratch", Path: ":memory:"}}
+		cfg.DatabaseDir = dir
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("derived names", func(t *testing.T) {
+		dotted := filepath.Join(dir, "my.data.db")
+		require.NoError(t, os.WriteFile(dotted, nil, 0o600))
+		cfg := Default()
+		cfg.Databases = []Database{{Path: dotted}, {Path: filepath.Join(dir, "my_data.db")}}
+		cfg.Create = true
+		assert.NoError(t, cfg.Validate())
+
+		cfg.Databases = []Database{{Name: "my.data", Path: dotted}}
+		assert.ErrorContains(t, cfg.Validate(), `databases.0.name: invalid database name "my.data"`)
+	})
+
+	t.Run("reports every problem with its line", func(t *testing.T) {
+		path := writeConfig(t, `transport: smoke-signals
+databases:
+  - path: `+dbPath+`
+  - name: Sales
+    path: `+dbPath+`
+  - path: `+filepath.Join(dir, "missing.db")+`
+attach:
+  - alias: main
+    path: `+dbPath+`
+limits:
+  page_size: 0
+tools:
+  enabled: [execute_query, drop_everything]
+pool:
+  max_readers: 0
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		err = cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.Contains(t, msg, path+`:1: transport: must be "sse", "streamable-http" or "stdio", got "smoke-signals"`)
+		assert.Contains(t, msg, path+`:4: databases.1.name: database "Sales" is already configured`)
+		assert.Contains(t, msg, path+":6: databases.2.path: database file does not exist")
+		assert.Contains(t, msg, path+`:8: attach.0.alias: "main" is reserved`)
+		assert.Contains(t, msg, path+":11: limits.page_size: must be at least 1")
+		assert.Contains(t, msg, path+`:13: tools.enabled.1: unknown tool "drop_everything"`)
+		assert.Contains(t, msg, path+":15: pool.max_readers: must be at least 1")
+	})
+
+	t.Run("values not from the file have no line", func(t *testing.T) {
+		cfg := Default()
+		cfg.Limits.MaxRows = -1
+		assert.EqualError(t, cfg.Validate(), "limits.max_rows: must not be negative")
+	})
+
+	t.Run("invalid database name", func(t *testing.T) {
+		cfg := Default()
+		cfg.Databases = []Database{{Path: ":memory:", Name: "no spaces"}}
+		assert.ErrorContains(t, cfg.Validate(), "databases.0.name: invalid database name")
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		tokensPath := filepath.Join(dir, "tokens")
+		require.NoError(t, os.WriteFile(tokensPath, []byte("alice\n"), 0o600))
+
+		cfg := Default()
+		cfg.Auth.TokensFile = tokensPath
+		cfg.Auth.JWT.Issuer = "https://issuer.example"
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "auth.tokens_file: "+tokensPath+":1: expected a principal name")
+		assert.ErrorContains(t, err, "auth.jwt: jwks_file is required")
+	})
+
+	t.Run("authorization", func(t *testing.T) {
+		path := writeConfig(t, `authorization:
+  - name: analysts
+    scopes: [sql:read]
+    tools: [execute_query, drop_everything]
+    statements: [read, merge]
+  - name: nobody
+    read: [orders]
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		err = cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.Contains(t, msg, path+":2: authorization: requires auth.tokens_file or auth.jwt.jwks_file to be set")
+		assert.Contains(t, msg, path+`:2: authorization.0: unknown statement kind "merge"`)
+		assert.Contains(t, msg, path+`:4: authorization.0.tools.1: unknown tool "drop_everything"`)
+		assert.Contains(t, msg, path+":6: authorization.1: principals or scopes are required")
+	})
+
+	t.Run("tables", func(t *testing.T) {
+		path := writeConfig(t, `tables:
+  allow: [orders]
+  deny: ["secrets["]
+  deny_columns: [password_hash]
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		err = cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.Contains(t, msg, path+`:3: tables.deny.0: invalid table pattern "secrets["`)
+		assert.Contains(t, msg, path+`:4: tables.deny_columns.0: column pattern "password_hash" must have the form table.column`)
+	})
+
+	t.Run("durability", func(t *testing.T) {
+		path := writeConfig(t, `durability:
+  profile: wal
+  journal_mode: wall
+  synchronous: sometimes
+  busy_timeout: -1s
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		err = cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.Contains(t, msg, path+`:3: durability.journal_mode: must be one of delete, truncate, persist, memory, wal, off, got "wall"`)
+		assert.Contains(t, msg, path+`:4: durability.synchronous: must be one of off, normal, full, extra, got "sometimes"`)
+		assert.Contains(t, msg, path+":5: durability.busy_timeout: must not be negative")
+
+		cfg = Default()
+		cfg.Durability.Profile = "fast"
+		assert.ErrorContains(t, cfg.Validate(), `durability.profile: unknown durability profile "fast"`)
+	})
+
+	t.Run("init sql", func(t *testing.T) {
+		scripts := filepath.Join(dir, "scripts")
+		require.NoError(t, os.Mkdir(scripts, 0o700))
+		path := writeConfig(t, `init_sql:
+  - `+dbPath+`
+  - `+scripts+`
+  - `+filepath.Join(dir, "missing.sql")+`
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		err = cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.NotContains(t, msg, "init_sql.0")
+		assert.Contains(t, msg, path+":3: init_sql.1: no .sql scripts found in "+scripts)
+		assert.Contains(t, msg, path+":4: init_sql.2: cannot read script")
+	})
+
+	t.Run("create", func(t *testing.T) {
+		missing := filepath.Join(dir, "new", "scratch.db")
+		cfg := Default()
+		cfg.Databases = []Database{{Path: missing}}
+		cfg.DatabaseDir = filepath.Join(dir, "new")
+		cfg.Schema = filepath.Join(dir, "schema.sql")
+		err := cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.Contains(t, msg, "databases.0.path: database file does not exist")
+		assert.Contains(t, msg, "database_dir: not a directory")
+		assert.Contains(t, msg, "schema: requires create to be set")
+
+		cfg.Create = true
+		assert.EqualError(t, cfg.Validate(), "schema: cannot read script: stat "+cfg.Schema+": no such file or directory")
+
+		cfg.Schema = dbPath
+		assert.NoError(t, cfg.Validate())
+		assert.True(t, cfg.CanCreateDatabases())
+	})
+
+	t.Run("prompts", func(t *testing.T) {
+		promptsDir := filepath.Join(dir, "prompts")
+		require.NoError(t, os.Mkdir(promptsDir, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(promptsDir, "report.md"), []byte("Report on {{region}}"), 0o600))
+
+		cfg := Default()
+		cfg.PromptsDir = promptsDir
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "prompts_dir: "+filepath.Join(promptsDir, "report.md")+": prompt report: unknown placeholder {{region}}")
+	})
+
+	t.Run("masking", func(t *testing.T) {
+		path := writeConfig(t, `masking:
+  - column: users.email
+    method: email_domain
+  - column: notes.body
+    method: regex
+  - column: ssn
+    method: scramble
+`)
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		err = cfg.Validate()
+		require.Error(t, err)
+		msg := err.Error()
+		assert.NotContains(t, msg, "masking.0")
+		assert.Contains(t, msg, path+":4: masking.1: the regex method requires a pattern")
+		assert.Contains(t, msg, path+`:6: masking.2: unknown masking method "scramble"`)
+	})
+}
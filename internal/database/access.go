@@ -0,0 +1,338 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ErrAccessDenied is returned when a statement uses a table or column its access rules do not allow
+var ErrAccessDenied = errors.New("access denied")
+
+// accessPattern is a table or table.column pattern of an access rule
+type accessPattern struct {
+	table  string
+	column string
+	// wildcard is true when the table pattern contains glob characters
+	wildcard bool
+}
+
+// parseAccessPattern parses a table or table.column pattern. Names are matched case-insensitively
+// with path.Match globs; sqlite_schema and sqlite_temp_schema are the same tables as sqlite_master
+// and sqlite_temp_master.
+func parseAccessPattern(s string) (accessPattern, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	table, column, hasColumn := strings.Cut(s, ".")
+	if table == "" || (hasColumn && column == "") {
+		return accessPattern{}, fmt.Errorf("invalid table pattern %q: use table or table.column", s)
+	}
+	for _, p := range []string{table, column} {
+		if _, err := path.Match(p, ""); err != nil {
+			return accessPattern{}, fmt.Errorf("invalid table pattern %q: %w", s, err)
+		}
+	}
+
+	switch table {
+	case "sqlite_schema":
+		table = "sqlite_master"
+	case "sqlite_temp_schema":
+		table = "sqlite_temp_master"
+	}
+	return accessPattern{table: table, column: column, wildcard: strings.ContainsAny(table, `*?[\`)}, nil
+}
+
+// matchesTable reports whether the pattern covers table. SQLite's own tables, whose names start
+// with sqlite_, are only matched by patterns that name them: the schema tables hold the
+// definitions of every table, and sqlite_sequence and sqlite_stat1 their names.
+func (p accessPattern) matchesTable(table string) bool {
+	table = strings.ToLower(table)
+	if strings.HasPrefix(table, "sqlite_") {
+		return !p.wildcard && p.table == table
+	}
+	ok, _ := path.Match(p.table, table)
+	return ok
+}
+
+// matchesColumn reports whether the pattern covers a column of table. A table pattern covers
+// every column.
+func (p accessPattern) matchesColumn(table, column string) bool {
+	if !p.matchesTable(table) {
+		return false
+	}
+	if p.column == "" {
+		return true
+	}
+	ok, _ := path.Match(p.column, strings.ToLower(column))
+	return ok
+}
+
+// Access restricts the tables and columns statements may read and write. Statements run with an
+// Access in their context (see WithAccess) are checked by SQLite's authorizer as they are
+// compiled, so the rules also apply to tables used through joins, subqueries, views and
+// triggers. A nil *Access allows everything.
+type Access struct {
+	read, write, deny []accessPattern
+	// all holds the rules this Access is the intersection of, see Intersect
+	all []*Access
+}
+
+// NewAccess creates access rules from table and table.column patterns. read lists what may be
+// read and write what may be inserted, updated or deleted; deny lists what may be neither,
+// whatever read and write allow. A table pattern covers all of the table's columns.
+func NewAccess(read, write, deny []string) (*Access, error) {
+	a := &Access{}
+	for _, list := range []struct {
+		patterns []string
+		dst      *[]accessPattern
+	}{{read, &a.read}, {write, &a.write}, {deny, &a.deny}} {
+		for _, s := range list.patterns {
+			p, err := parseAccessPattern(s)
+			if err != nil {
+				return nil, err
+			}
+			*list.dst = append(*list.dst, p)
+		}
+	}
+	return a, nil
+}
+
+// Intersect returns rules that allow only what both a and b allow
+func (a *Access) Intersect(b *Access) *Access {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return &Access{all: append(a.parts(), b.parts()...)}
+}
+
+// parts returns the rules a is the intersection of
+func (a *Access) parts() []*Access {
+	if a.all != nil {
+		return a.all
+	}
+	return []*Access{a}
+}
+
+// every reports whether allowed holds for each of the rules a is the intersection of
+func (a *Access) every(allowed func(*Access) bool) bool {
+	if a == nil {
+		return true
+	}
+	for _, part := range a.parts() {
+		if !allowed(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanRead reports whether a column of table may be read. An empty column stands for the table
+// as a whole, as when counting its rows, and is allowed when any of its columns may be read.
+func (a *Access) CanRead(table, column string) bool {
+	return a.every(func(a *Access) bool { return a.allows(a.read, table, column) })
+}
+
+// CanWrite reports whether a column of table may be updated. An empty column stands for the
+// table as a whole, as when inserting or deleting rows, and is only allowed by a pattern that
+// covers every column.
+func (a *Access) CanWrite(table, column string) bool {
+	return a.every(func(a *Access) bool {
+		if column == "" {
+			return a.allows(a.write, table, "") && slices.ContainsFunc(a.write, func(p accessPattern) bool {
+				return (p.column == "" || p.column == "*") && p.matchesTable(table)
+			})
+		}
+		return a.allows(a.write, table, column)
+	})
+}
+
+// Visible reports whether table may be read or written at all, and so may be listed
+func (a *Access) Visible(table string) bool {
+	return a.every(func(a *Access) bool {
+		return a.allows(a.read, table, "") || a.allows(a.write, table, "")
+	})
+}
+
+// ColumnVisible reports whether a column of table may be read or written, and so may be listed
+func (a *Access) ColumnVisible(table, column string) bool {
+	return a.every(func(a *Access) bool {
+		return a.allows(a.read, table, column) || a.allows(a.write, table, column)
+	})
+}
+
+// allows reports whether one of the granted patterns covers the column of table and no deny
+// pattern does. Only deny patterns without a column hide a table as a whole.
+func (a *Access) allows(granted []accessPattern, table, column string) bool {
+	for _, p := range a.deny {
+		if p.matchesTable(table) && (p.column == "" || (column != "" && p.matchesColumn(table, column))) {
+			return false
+		}
+	}
+	for _, p := range granted {
+		if column == "" && p.matchesTable(table) || column != "" && p.matchesColumn(table, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessKey is the context key for access rules
+type accessKey struct{}
+
+// WithAccess returns a context whose statements must satisfy a as well as any rules ctx
+// already carries
+func WithAccess(ctx context.Context, a *Access) context.Context {
+	if a == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, accessKey{}, AccessFromContext(ctx).Intersect(a))
+}
+
+// AccessFromContext returns the access rules statements run with ctx must satisfy, or nil when
+// they are unrestricted
+func AccessFromContext(ctx context.Context) *Access {
+	a, _ := ctx.Value(accessKey{}).(*Access)
+	return a
+}
+
+// FilterTables returns the tables that may be listed
+func (a *Access) FilterTables(tables []TableInfo) []TableInfo {
+	return filter(tables, func(t TableInfo) bool { return a.Visible(t.Name) })
+}
+
+// FilterViews returns the views that may be listed. The CREATE statement of a view that uses
+// hidden tables or columns is dropped.
+func (a *Access) FilterViews(views []View) []View {
+	views = filter(views, func(v View) bool { return a.Visible(v.Name) })
+	for i := range views {
+		if !a.referencesVisible(views[i].refs) {
+			views[i].SQL = ""
+		}
+	}
+	return views
+}
+
+// FilterIndexes returns the indexes of visible tables whose named columns are all visible
+func (a *Access) FilterIndexes(indexes []TableIndex) []TableIndex {
+	indexes = filter(indexes, func(idx TableIndex) bool {
+		return a.Visible(idx.Table) && a.indexVisible(idx.Table, idx.Index)
+	})
+	for i := range indexes {
+		indexes[i].Index = a.redactIndex(indexes[i].Index)
+	}
+	return indexes
+}
+
+// FilterTriggers returns the triggers of visible tables and views. The CREATE statement of a
+// trigger that uses hidden tables or columns is dropped.
+func (a *Access) FilterTriggers(triggers []Trigger) []Trigger {
+	triggers = filter(triggers, func(t Trigger) bool { return a.Visible(t.Table) })
+	for i := range triggers {
+		if !a.referencesVisible(triggers[i].refs) {
+			triggers[i].SQL = ""
+		}
+	}
+	return triggers
+}
+
+// FilterTableSchema removes the columns that may not be listed from a table's schema, along
+// with the indexes, foreign keys and CHECK constraints that involve them. The columns of a view
+// read from hidden columns are removed too, as are its computed columns when it uses any hidden
+// table or column. The CREATE statement is dropped when any column is removed or it names a
+// hidden table. It reports false when the table is not visible.
+func (a *Access) FilterTableSchema(ts *TableSchema) bool {
+	if !a.Visible(ts.Name) {
+		return false
+	}
+
+	view := ts.Type == "view"
+	refsVisible := !view || a.referencesVisible(ts.refs)
+	columns := filter(ts.Columns, func(c TableColumn) bool {
+		if !view {
+			return a.ColumnVisible(ts.Name, c.Name)
+		}
+		if c.source.table == "" {
+			return refsVisible
+		}
+		return a.ColumnVisible(ts.Name, c.Name) && a.ColumnVisible(c.source.table, c.source.column)
+	})
+	if len(columns) == len(ts.Columns) {
+		if !refsVisible || slices.ContainsFunc(ts.ForeignKeys, func(fk ForeignKey) bool { return !a.Visible(fk.Table) }) {
+			ts.SQL = ""
+		}
+		ts.ForeignKeys = filter(ts.ForeignKeys, func(fk ForeignKey) bool { return a.Visible(fk.Table) })
+		return true
+	}
+	ts.Columns = columns
+	ts.SQL = ""
+	ts.PrimaryKey = filter(ts.PrimaryKey, func(c string) bool { return a.ColumnVisible(ts.Name, c) })
+	ts.Indexes = filter(ts.Indexes, func(idx Index) bool { return a.indexVisible(ts.Name, idx) })
+	for i := range ts.Indexes {
+		ts.Indexes[i] = a.redactIndex(ts.Indexes[i])
+	}
+	ts.ForeignKeys = filter(ts.ForeignKeys, func(fk ForeignKey) bool {
+		for _, c := range fk.Columns {
+			if !a.ColumnVisible(ts.Name, c) {
+				return false
+			}
+		}
+		return a.Visible(fk.Table)
+	})
+	ts.Checks = filter(ts.Checks, func(c CheckConstraint) bool {
+		return c.Column != "" && a.ColumnVisible(ts.Name, c.Column)
+	})
+	return true
+}
+
+// referencesVisible reports whether every table column the definition of a view or trigger
+// uses may be listed. Unknown references are only visible when access is not restricted.
+func (a *Access) referencesVisible(refs references) bool {
+	if a == nil {
+		return true
+	}
+	if !refs.ok {
+		return false
+	}
+	for _, access := range refs.accesses {
+		if access.column == "" && !a.Visible(access.table) || access.column != "" && !a.ColumnVisible(access.table, access.column) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexVisible reports whether every named column of an index on table is visible
+func (a *Access) indexVisible(table string, idx Index) bool {
+	for _, c := range idx.Columns {
+		if !c.Expression && !a.ColumnVisible(table, c.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// redactIndex drops the CREATE INDEX statement of an index with expressions or a WHERE clause
+// when access is restricted, as they may use columns that are hidden
+func (a *Access) redactIndex(idx Index) Index {
+	if a == nil || !idx.Partial && !slices.ContainsFunc(idx.Columns, func(c IndexColumn) bool { return c.Expression }) {
+		return idx
+	}
+	idx.SQL = ""
+	return idx
+}
+
+// filter returns the elements of s for which keep returns true
+func filter[T any](s []T, keep func(T) bool) []T {
+	kept := make([]T, 0, len(s))
+	for _, v := range s {
+		if keep(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccess(t *testing.T) {
+	access, err := NewAccess(
+		[]string{"users", "orders.id", "orders.total", "log_*"},
+		[]string{"orders.status", "sqlite_schema"},
+		[]string{"users.password_hash", "log_secret"},
+	)
+	require.NoError(t, err)
+
+	t.Run("read", func(t *testing.T) {
+		assert.True(t, access.CanRead("users", "name"))
+		assert.True(t, access.CanRead("USERS", "Name"))
+		assert.False(t, access.CanRead("users", "password_hash"))
+		assert.True(t, access.CanRead("users", ""))
+		assert.True(t, access.CanRead("orders", "total"))
+		assert.False(t, access.CanRead("orders", "status"))
+		assert.True(t, access.CanRead("orders", ""))
+		assert.True(t, access.CanRead("log_2024", "message"))
+		assert.False(t, access.CanRead("log_secret", "message"))
+		assert.False(t, access.CanRead("secrets", ""))
+	})
+
+	t.Run("write", func(t *testing.T) {
+		assert.True(t, access.CanWrite("orders", "status"))
+		assert.False(t, access.CanWrite("orders", "total"))
+		assert.False(t, access.CanWrite("users", "name"))
+	})
+
+	t.Run("visibility", func(t *testing.T) {
+		assert.True(t, access.Visible("users"))
+		assert.True(t, access.Visible("orders"))
+		assert.False(t, access.Visible("log_secret"))
+		assert.False(t, access.Visible("secrets"))
+		assert.True(t, access.ColumnVisible("orders", "status"))
+		assert.False(t, access.ColumnVisible("orders", "customer_id"))
+		assert.False(t, access.ColumnVisible("users", "password_hash"))
+	})
+
+	t.Run("sqlite tables need literal patterns", func(t *testing.T) {
+		assert.True(t, access.CanWrite("sqlite_master", ""))
+		assert.False(t, access.CanRead("sqlite_master", "sql"))
+
+		everything, err := NewAccess([]string{"*"}, nil, nil)
+		require.NoError(t, err)
+		assert.True(t, everything.CanRead("anything", "at_all"))
+		assert.False(t, everything.CanRead("sqlite_master", "sql"))
+		assert.False(t, everything.Visible("sqlite_sequence"))
+	})
+
+	t.Run("nil allows everything", func(t *testing.T) {
+		var unrestricted *Access
+		assert.True(t, unrestricted.CanRead("secrets", "value"))
+		assert.True(t, unrestricted.CanWrite("secrets", "value"))
+		assert.True(t, unrestricted.Visible("secrets"))
+	})
+
+	t.Run("intersection", func(t *testing.T) {
+		other, err := NewAccess([]string{"users.name", "orders"}, nil, nil)
+		require.NoError(t, err)
+		both := access.Intersect(other)
+
+		assert.True(t, both.CanRead("users", "name"))
+		assert.False(t, both.CanRead("users", "email"))
+		assert.True(t, both.CanRead("orders", "total"))
+		assert.False(t, both.CanWrite("orders", "status"))
+		assert.False(t, both.Visible("log_2024"))
+	})
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		for _, pattern := range []string{"", ".name", "users.", "users[", "users.[a"} {
+			_, err := NewAccess([]string{pattern}, nil, nil)
+			assert.Error(t, err, pattern)
+		}
+	})
+}
+
+func TestWithAccess(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, AccessFromContext(ctx))
+	assert.Equal(t, ctx, WithAccess(ctx, nil))
+
+	first, err := NewAccess([]string{"users", "orders"}, nil, nil)
+	require.NoError(t, err)
+	second, err := NewAccess([]string{"users"}, nil, nil)
+	require.NoError(t, err)
+
+	ctx = WithAccess(WithAccess(ctx, first), second)
+	access := AccessFromContext(ctx)
+	assert.True(t, access.CanRead("users", "name"))
+	assert.False(t, access.CanRead("orders", "id"))
+}
+
+func TestAccessFilterSchema(t *testing.T) {
+	ctx := context.Background()
+	db, err := New(InMemoryDB, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range []string{
+		"CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT)",
+		`CREATE TABLE users (
+			id INTEGER PRIMARY KEY,
+			name TEXT,
+			password_hash TEXT CHECK (length(password_hash) > 8),
+			team_id INTEGER REFERENCES teams(id),
+			secret_id INTEGER REFERENCES secrets(id)
+		)`,
+		"CREATE TABLE secrets (id INTEGER PRIMARY KEY, value TEXT)",
+		"CREATE INDEX users_name ON users(name)",
+		"CREATE INDEX users_password ON users(password_hash)",
+		"CREATE INDEX users_lower_name ON users(lower(name))",
+		"CREATE VIEW user_names AS SELECT name FROM users",
+		"CREATE VIEW secret_values AS SELECT value FROM secrets",
+		"CREATE VIEW user_passwords AS SELECT name, upper(password_hash) AS upper_hash, password_hash FROM users",
+		"CREATE TRIGGER secrets_audit AFTER DELETE ON secrets BEGIN SELECT 1; END",
+		"CREATE TRIGGER users_secrets AFTER DELETE ON users BEGIN DELETE FROM secrets WHERE id = old.secret_id; END",
+		"CREATE TRIGGER users_teams AFTER DELETE ON users BEGIN DELETE FROM teams WHERE id = old.team_id; END",
+	} {
+		_, err := db.Execute(stmt)
+		require.NoError(t, err)
+	}
+
+	access, err := NewAccess([]string{"teams", "users", "user_names", "user_passwords"}, nil, []string{"users.password_hash"})
+	require.NoError(t, err)
+
+	tables, err := db.GetTables(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, table := range access.FilterTables(tables) {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"teams", "users"}, names)
+
+	views, err := db.GetViews(ctx)
+	require.NoError(t, err)
+	visibleViews := access.FilterViews(views)
+	require.Len(t, visibleViews, 2)
+	assert.Equal(t, "user_names", visibleViews[0].Name)
+	assert.NotEmpty(t, visibleViews[0].SQL)
+	// The definition of a view that reads a hidden column is hidden
+	assert.Equal(t, "user_passwords", visibleViews[1].Name)
+	assert.Empty(t, visibleViews[1].SQL)
+
+	triggers, err := db.GetTriggers(ctx, "")
+	require.NoError(t, err)
+	visibleTriggers := access.FilterTriggers(triggers)
+	require.Len(t, visibleTriggers, 2)
+	// The definition of a trigger that writes a hidden table is hidden
+	assert.Equal(t, "users_secrets", visibleTriggers[0].Name)
+	assert.Empty(t, visibleTriggers[0].SQL)
+	assert.Equal(t, "users_teams", visibleTriggers[1].Name)
+	assert.NotEmpty(t, visibleTriggers[1].SQL)
+
+	indexes, err := db.GetIndexes(ctx, "users")
+	require.NoError(t, err)
+	visible := access.FilterIndexes(indexes)
+	require.Len(t, visible, 2)
+	assert.Equal(t, "users_lower_name", visible[0].Name)
+	assert.Empty(t, visible[0].SQL)
+	assert.Equal(t, "users_name", visible[1].Name)
+	assert.NotEmpty(t, visible[1].SQL)
+
+	schema, err := db.GetTableSchema(ctx, "users")
+	require.NoError(t, err)
+	require.True(t, access.FilterTableSchema(schema))
+	var columns []string
+	for _, c := range schema.Columns {
+		columns = append(columns, c.Name)
+	}
+	assert.Equal(t, []string{"id", "name", "team_id", "secret_id"}, columns)
+	assert.Empty(t, schema.SQL)
+	assert.Empty(t, schema.Checks)
+	require.Len(t, schema.ForeignKeys, 1)
+	assert.Equal(t, "teams", schema.ForeignKeys[0].Table)
+	for _, idx := range schema.Indexes {
+		assert.NotEqual(t, "users_password", idx.Name)
+	}
+
+	schema, err = db.GetTableSchema(ctx, "user_passwords")
+	require.NoError(t, err)
+	require.True(t, access.FilterTableSchema(schema))
+	columns = nil
+	for _, c := range schema.Columns {
+		columns = append(columns, c.Name)
+	}
+	assert.Equal(t, []string{"name"}, columns)
+	assert.Empty(t, schema.SQL)
+
+	schema, err = db.GetTableSchema(ctx, "user_names")
+	require.NoError(t, err)
+	require.True(t, access.FilterTableSchema(schema))
+	assert.NotEmpty(t, schema.SQL)
+
+	schema, err = db.GetTableSchema(ctx, "secrets")
+	require.NoError(t, err)
+	assert.False(t, access.FilterTableSchema(schema))
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// Attachment is a database file attached read-only to every connection under a schema alias,
+// so that its tables can be queried as alias.table
+type Attachment struct {
+	Alias string
+	Path  string
+}
+
+// Option configures a database opened with New
+type Option func(*options)
+
+// options holds the settings applied by Option
+type options struct {
+	attachments     []Attachment
+	durability      string
+	pragmas         *Pragmas
+	maxReaders      int
+	idleReaders     int
+	maxQueuedWrites int
+	initScripts     []string
+}
+
+// validate checks the attachments and pool sizes
+func (o *options) validate() error {
+	for _, a := range o.attachments {
+		if err := a.validate(); err != nil {
+			return err
+		}
+	}
+	if o.maxReaders < 1 {
+		return fmt.Errorf("at least one reader connection is required, got %d", o.maxReaders)
+	}
+	if o.idleReaders < 0 || o.maxQueuedWrites < 0 {
+		return fmt.Errorf("idle readers and queued writes must not be negative")
+	}
+	return nil
+}
+
+// WithAttachments attaches database files read-only to every connection
+func WithAttachments(attachments ...Attachment) Option {
+	return func(o *options) {
+		o.attachments = append(o.attachments, attachments...)
+	}
+}
+
+// validate checks that the attachment can be attached under its alias
+func (a Attachment) validate() error {
+	if err := validateIdentifier(a.Alias); err != nil {
+		return fmt.Errorf("invalid alias for %s: %w", a.Path, err)
+	}
+	switch strings.ToLower(a.Alias) {
+	case "main", "temp":
+		return fmt.Errorf("cannot attach %s as %q: the name is reserved", a.Path, a.Alias)
+	}
+	if a.Path == "" {
+		return fmt.Errorf("path is required to attach %q", a.Alias)
+	}
+	return nil
+}
+
+// attachHook returns a connection hook that attaches the given databases to each new connection
+func attachHook(attachments []Attachment) sqlite.ConnectionHookFn {
+	return func(conn sqlite.ExecQuerierContext, _ string) error {
+		for _, a := range attachments {
+			args := []driver.NamedValue{{Ordinal: 1, Value: readOnlyURI(a.Path)}}
+			if _, err := conn.ExecContext(context.Background(), "ATTACH DATABASE ? AS "+quoteIdentifier(a.Alias), args); err != nil {
+				return fmt.Errorf("failed to attach %s as %q: %w", a.Path, a.Alias, err)
+			}
+		}
+		return nil
+	}
+}
+
+// readOnlyURI returns a URI filename that opens path read-only
+func readOnlyURI(path string) string {
+	return fileURI(path) + "?mode=ro"
+}
+
+// fileURI returns path as an SQLite URI filename, escaping the characters that have a meaning in URIs
+func fileURI(path string) string {
+	return "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+}
+
+// connector opens connections to one DSN through a driver with its own connection hooks
+type connector struct {
+	driver *sqlite.Driver
+	dsn    string
+}
+
+// Connect implements driver.Connector
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector
+func (c connector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachments(t *testing.T) {
+	ctx := context.Background()
+
+	// A reference database with characters that need escaping in a URI filename
+	refPath := filepath.Join(t.TempDir(), "ref #1?.db")
+	ref, err := New(InMemoryDB, false)
+	require.NoError(t, err)
+	_, err = ref.ExecuteContext(ctx, "VACUUM INTO ?", refPath)
+	require.NoError(t, err)
+	require.NoError(t, ref.Close())
+
+	ref, err = New(refPath, false)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		"CREATE TABLE countries (code TEXT PRIMARY KEY, name TEXT NOT NULL)",
+		"INSERT INTO countries VALUES ('NL', 'Netherlands'), ('BE', 'Belgium')",
+		"CREATE VIEW country_codes AS SELECT code FROM countries",
+		"CREATE TRIGGER countries_guard BEFORE DELETE ON countries BEGIN SELECT 1; END",
+	} {
+		_, err := ref.ExecuteContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, ref.Close())
+
+	db, err := New(createTestDB(t), false, WithAttachments(Attachment{Alias: "ref", Path: refPath}))
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("every connection", func(t *testing.T) {
+		// Pin the writer and several readers so the reader pool has to open new ones
+		tx, err := db.BeginTx(ctx, "")
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+		queryers := []queryer{tx.conn, db.readers}
+		for range 2 {
+			conn, err := db.readers.Conn(ctx)
+			require.NoError(t, err)
+			defer conn.Close()
+			queryers = append(queryers, conn)
+		}
+
+		for _, q := range queryers {
+			result, err := queryLimit(ctx, q, 0,
+				"SELECT u.name, c.name FROM users AS u CROSS JOIN ref.countries AS c ORDER BY 1, 2")
+			require.NoError(t, err)
+			assert.Len(t, result.Rows, 4)
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		_, err := db.ExecuteContext(ctx, "INSERT INTO ref.countries VALUES ('DE', 'Germany')")
+		assert.ErrorContains(t, err, "readonly")
+	})
+
+	t.Run("schema browsing", func(t *testing.T) {
+		schemas, err := db.Schemas(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"main", "ref"}, schemas)
+
+		tables, err := db.GetTables(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, tables, TableInfo{Schema: "ref", Name: "countries"})
+		assert.Contains(t, tables, TableInfo{Schema: "main", Name: "users"})
+
+		schema, err := db.GetTableSchema(ctx, "ref.countries")
+		require.NoError(t, err)
+		assert.Equal(t, "ref", schema.Schema)
+		assert.Equal(t, "countries", schema.Name)
+		assert.Equal(t, []string{"code"}, schema.PrimaryKey)
+		require.Len(t, schema.Indexes, 1)
+
+		// An unqualified name is found in attached databases too
+		schema, err = db.GetTableSchema(ctx, "countries")
+		require.NoError(t, err)
+		assert.Equal(t, "ref", schema.Schema)
+
+		_, err = db.GetTableSchema(ctx, "ref.missing")
+		assert.ErrorIs(t, err, ErrTableNotFound)
+		_, err = db.GetTableSchema(ctx, "nope.users")
+		assert.ErrorIs(t, err, ErrTableNotFound)
+
+		views, err := db.GetViews(ctx)
+		require.NoError(t, err)
+		require.Len(t, views, 1)
+		assert.Equal(t, "ref", views[0].Schema)
+
+		indexes, err := db.GetIndexes(ctx, "ref.countries")
+		require.NoError(t, err)
+		require.Len(t, indexes, 1)
+		assert.Equal(t, "ref", indexes[0].Schema)
+
+		triggers, err := db.GetTriggers(ctx, "ref.countries")
+		require.NoError(t, err)
+		require.Len(t, triggers, 1)
+		assert.Equal(t, "countries_guard", triggers[0].Name)
+		assert.Equal(t, "ref", triggers[0].Schema)
+	})
+
+	t.Run("invalid attachments", func(t *testing.T) {
+		for _, a := range []Attachment{
+			{Alias: "main", Path: refPath},
+			{Alias: "TEMP", Path: refPath},
+			{Alias: "", Path: refPath},
+			{Alias: "ref", Path: ""},
+		} {
+			_, err := New(createTestDB(t), true, WithAttachments(a))
+			assert.Error(t, err, a.Alias)
+		}
+
+		_, err := New(createTestDB(t), true, WithAttachments(Attachment{Alias: "gone", Path: filepath.Join(t.TempDir(), "gone.db")}))
+		assert.ErrorContains(t, err, "failed to attach")
+	})
+}
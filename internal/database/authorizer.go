@@ -0,0 +1,455 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"modernc.org/libc"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+// errUnsupportedDriver is returned when the SQLite connection handle cannot be reached
+var errUnsupportedDriver = errors.New("access rules and masking are not supported by this SQLite driver")
+
+// authorization is the state of the authorizer installed for one call
+type authorization struct {
+	access *Access
+	// schemaChange is set once a schema change has been allowed, as SQLite then reads the
+	// schema table to carry it out
+	schemaChange bool
+	// denied describes the first operation the authorizer rejected
+	denied string
+	// unchecked is set while the package runs statements of its own, see unchecked
+	unchecked bool
+	// indexes holds the indexes PRAGMA index_info and index_xinfo may describe, by lower-cased
+	// name. The callback cannot look them up, as it must not run statements itself.
+	indexes map[string][]indexRef
+	// accesses records the table columns statements read and write while it is set, see compile
+	accesses *[]tableAccess
+}
+
+// tableAccess is a read or write of a table column reported to the authorizer. The column
+// is "" when the table is accessed as a whole, as when deleting rows.
+type tableAccess struct {
+	table  string
+	column string
+	write  bool
+	// by names the trigger or view whose code makes the access, or is "" for the statement itself
+	by string
+}
+
+// indexRef is an index, or the primary key of a WITHOUT ROWID table, as PRAGMA index_info and
+// index_xinfo describe it
+type indexRef struct {
+	schema string
+	table  string
+	// columns are the named columns the index holds
+	columns []string
+}
+
+// indexesQuery lists the key columns of every index in every database, with the primary keys
+// of WITHOUT ROWID tables also under the name of their table
+const indexesQuery = `SELECT i.name, t.schema, t.name, c.name, t.wr AND i.origin = 'pk'
+FROM pragma_table_list AS t
+JOIN pragma_index_list(t.name, t.schema) AS i
+JOIN pragma_index_xinfo(i.name, t.schema) AS c
+WHERE t.type = 'table' AND c.key`
+
+var (
+	// authorizations maps the argument passed to the authorizer callback to its call's state
+	authorizations sync.Map
+	// connAuthorizations maps the connections an authorizer is installed on to its state
+	connAuthorizations sync.Map
+	// lastAuthorization numbers the calls that run with an authorizer
+	lastAuthorization atomic.Uintptr
+	// authorizerCallback is the address of authorize as a C function pointer
+	authorizerCallback = funcPointer(authorize)
+)
+
+// funcPointer returns the address of a Go function in the form the transpiled SQLite library
+// calls function pointers, the same conversion modernc.org/sqlite makes for its own callbacks.
+// TestDriverHandle checks that the authorizer is called through it.
+func funcPointer[T any](f T) uintptr {
+	return *(*uintptr)(unsafe.Pointer(&struct{ f T }{f})) // #nosec G103 -- see TestDriverHandle
+}
+
+// withAuthorizer runs fn with SQLite's authorizer checking every statement compiled on conn
+// against the access rules ctx carries. fn runs unchecked when ctx carries none. An operation
+// the rules do not allow makes the statement fail with an error wrapping ErrAccessDenied.
+// The indexes of the database are read through cache.
+func withAuthorizer(ctx context.Context, cache *indexCache, conn *sql.Conn, fn func() error) error {
+	access := AccessFromContext(ctx)
+	if access == nil {
+		return fn()
+	}
+
+	indexes, err := cache.load(ctx, conn)
+	if err != nil {
+		return err
+	}
+	state := &authorization{access: access, indexes: indexes}
+	id := lastAuthorization.Add(1)
+	authorizations.Store(id, state)
+	defer authorizations.Delete(id)
+
+	if err := setAuthorizer(conn, authorizerCallback, id); err != nil {
+		return err
+	}
+	defer func() {
+		// A connection whose authorizer cannot be removed would reject every later statement
+		_ = setAuthorizer(conn, 0, 0)
+	}()
+	connAuthorizations.Store(conn, state)
+	defer connAuthorizations.Delete(conn)
+
+	err = fn()
+	if err != nil && state.denied != "" {
+		return fmt.Errorf("%w: %s", ErrAccessDenied, state.denied)
+	}
+	return err
+}
+
+// indexCache holds the indexes of a database's connections as last read, along with the schema
+// versions they were read at
+type indexCache struct {
+	mu       sync.Mutex
+	versions string
+	indexes  map[string][]indexRef
+}
+
+// load returns the indexes of the databases open on conn for checking PRAGMA index_info and
+// index_xinfo. They are read again only when the schema of one of the databases has changed.
+// Connections with temporary objects of their own are not cached. A nil cache caches nothing.
+func (c *indexCache) load(ctx context.Context, conn *sql.Conn) (map[string][]indexRef, error) {
+	if c == nil {
+		return loadIndexes(ctx, conn)
+	}
+	versions, temp, err := schemaVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if temp {
+		return loadIndexes(ctx, conn)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.indexes != nil && c.versions == versions {
+		return c.indexes, nil
+	}
+	indexes, err := loadIndexes(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	c.versions, c.indexes = versions, indexes
+	return indexes, nil
+}
+
+// schemaVersions returns the schema versions of the databases open on conn, and whether its
+// temporary database holds any objects
+func schemaVersions(ctx context.Context, conn *sql.Conn) (string, bool, error) {
+	schemas, err := queryLimit(ctx, conn, 0, "SELECT name FROM pragma_database_list")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read schema versions: %w", err)
+	}
+	var versions strings.Builder
+	temp := false
+	for _, row := range schemas.Rows {
+		schema := asString(row[0])
+		var version int64
+		if err := conn.QueryRowContext(ctx, "PRAGMA "+quoteIdentifier(schema)+".schema_version").Scan(&version); err != nil {
+			return "", false, fmt.Errorf("failed to read schema versions: %w", err)
+		}
+		if schema == "temp" {
+			temp = version != 0
+		}
+		fmt.Fprintf(&versions, "%s=%d;", schema, version)
+	}
+	return versions.String(), temp, nil
+}
+
+// loadIndexes reads the indexes of the databases open on conn, see indexCache.load
+func loadIndexes(ctx context.Context, conn *sql.Conn) (map[string][]indexRef, error) {
+	rows, err := conn.QueryContext(ctx, indexesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer rows.Close()
+
+	indexes := make(map[string][]indexRef)
+	add := func(name string, ref indexRef, column sql.NullString) {
+		name = strings.ToLower(name)
+		refs := indexes[name]
+		if n := len(refs); n == 0 || refs[n-1].schema != ref.schema || refs[n-1].table != ref.table {
+			refs = append(refs, ref)
+		}
+		if column.Valid {
+			refs[len(refs)-1].columns = append(refs[len(refs)-1].columns, column.String)
+		}
+		indexes[name] = refs
+	}
+	for rows.Next() {
+		var name string
+		var ref indexRef
+		var column sql.NullString
+		var primaryKey bool
+		if err := rows.Scan(&name, &ref.schema, &ref.table, &column, &primaryKey); err != nil {
+			return nil, fmt.Errorf("failed to list indexes: %w", err)
+		}
+		add(name, ref, column)
+		if primaryKey {
+			add(ref.table, ref, column)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	return indexes, nil
+}
+
+// unchecked runs fn with the authorizer installed on q, if any, allowing every operation. It is
+// for the statements the package runs for its own bookkeeping, which access rules do not apply to.
+func unchecked(q queryer, fn func() error) error {
+	v, ok := connAuthorizations.Load(q)
+	if !ok {
+		return fn()
+	}
+	state := v.(*authorization)
+	state.unchecked = true
+	defer func() { state.unchecked = false }()
+	return fn()
+}
+
+// setAuthorizer installs callback with arg on the SQLite connection underlying conn, or
+// removes the authorizer when callback is 0. The driver does not expose sqlite3_set_authorizer,
+// so the connection handle is read from the driver's connection.
+func setAuthorizer(conn *sql.Conn, callback, arg uintptr) error {
+	return withHandle(conn, func(tls *libc.TLS, db uintptr) error {
+		if rc := sqlite3.Xsqlite3_set_authorizer(tls, db, callback, arg); rc != sqlite3.SQLITE_OK {
+			// Discard the connection rather than return it to the pool in an unknown state
+			return fmt.Errorf("failed to set authorizer (%d): %w", rc, driver.ErrBadConn)
+		}
+		return nil
+	})
+}
+
+// withHandle calls fn with the SQLite connection handle underlying conn and the thread state
+// the transpiled library is called with, read from the driver's connection
+func withHandle(conn *sql.Conn, fn func(tls *libc.TLS, db uintptr) error) error {
+	return conn.Raw(func(driverConn any) error {
+		tls, db, err := connHandle(driverConn)
+		if err != nil {
+			return err
+		}
+		return fn(tls, db)
+	})
+}
+
+// driverPackage is the package of the SQLite driver whose connections connHandle reads
+const driverPackage = "modernc.org/sqlite"
+
+// connHandle returns the SQLite connection handle and thread state of a connection of the
+// modernc.org/sqlite driver, which keeps them in the unexported fields db and tls. It fails
+// with errUnsupportedDriver, rather than read anything else, when the connection is of another
+// driver or the fields are missing or of other types, as a driver upgrade may leave them.
+func connHandle(driverConn any) (*libc.TLS, uintptr, error) {
+	v := reflect.ValueOf(driverConn)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type().PkgPath() != driverPackage {
+		return nil, 0, errUnsupportedDriver
+	}
+	handle, tls := v.Elem().FieldByName("db"), v.Elem().FieldByName("tls")
+	if !handle.IsValid() || handle.Kind() != reflect.Uintptr || handle.Uint() == 0 {
+		return nil, 0, errUnsupportedDriver
+	}
+	if !tls.IsValid() || tls.Type() != reflect.TypeOf((*libc.TLS)(nil)) || tls.IsNil() {
+		return nil, 0, errUnsupportedDriver
+	}
+	return (*libc.TLS)(tls.UnsafePointer()), uintptr(handle.Uint()), nil
+}
+
+// authorize is the authorizer callback. It is called while a statement is compiled, once for
+// each operation the statement performs.
+func authorize(_ *libc.TLS, arg uintptr, action int32, z1, z2, z3, z4 uintptr) int32 {
+	v, ok := authorizations.Load(arg)
+	if !ok {
+		return sqlite3.SQLITE_DENY
+	}
+	state := v.(*authorization)
+	if state.accesses != nil {
+		state.record(action, libc.GoString(z1), libc.GoString(z2), libc.GoString(z4))
+	}
+	if state.unchecked || state.access == nil {
+		return sqlite3.SQLITE_OK
+	}
+
+	if reason := state.check(action, libc.GoString(z1), libc.GoString(z2), libc.GoString(z3)); reason != "" {
+		if state.denied == "" {
+			state.denied = reason
+		}
+		return sqlite3.SQLITE_DENY
+	}
+	return sqlite3.SQLITE_OK
+}
+
+// record records a read or write of a table column
+func (s *authorization) record(action int32, table, column, by string) {
+	switch action {
+	case sqlite3.SQLITE_READ:
+		*s.accesses = append(*s.accesses, tableAccess{table: table, column: column, by: by})
+	case sqlite3.SQLITE_INSERT, sqlite3.SQLITE_UPDATE, sqlite3.SQLITE_DELETE:
+		*s.accesses = append(*s.accesses, tableAccess{table: table, column: column, write: true, by: by})
+	}
+}
+
+// schemaActions maps the authorizer actions that change the schema to the argument naming the
+// table they change: the table or view itself, or the table an index or trigger belongs to
+var schemaActions = map[int32]int{
+	sqlite3.SQLITE_CREATE_TABLE:        1,
+	sqlite3.SQLITE_CREATE_TEMP_TABLE:   1,
+	sqlite3.SQLITE_CREATE_VIEW:         1,
+	sqlite3.SQLITE_CREATE_TEMP_VIEW:    1,
+	sqlite3.SQLITE_CREATE_VTABLE:       1,
+	sqlite3.SQLITE_DROP_TABLE:          1,
+	sqlite3.SQLITE_DROP_TEMP_TABLE:     1,
+	sqlite3.SQLITE_DROP_VIEW:           1,
+	sqlite3.SQLITE_DROP_TEMP_VIEW:      1,
+	sqlite3.SQLITE_DROP_VTABLE:         1,
+	sqlite3.SQLITE_CREATE_INDEX:        2,
+	sqlite3.SQLITE_CREATE_TEMP_INDEX:   2,
+	sqlite3.SQLITE_CREATE_TRIGGER:      2,
+	sqlite3.SQLITE_CREATE_TEMP_TRIGGER: 2,
+	sqlite3.SQLITE_DROP_INDEX:          2,
+	sqlite3.SQLITE_DROP_TEMP_INDEX:     2,
+	sqlite3.SQLITE_DROP_TRIGGER:        2,
+	sqlite3.SQLITE_DROP_TEMP_TRIGGER:   2,
+	sqlite3.SQLITE_ALTER_TABLE:         2,
+}
+
+// check returns why an authorizer action is not allowed, or "" when it is. arg1 and arg2 are
+// the action's first two arguments as described for sqlite3_set_authorizer, and schema is the
+// database the action applies to.
+func (s *authorization) check(action int32, arg1, arg2, schema string) string {
+	a := s.access
+	// The trigger a dry run creates records the rowids of updated rows in a table of its own
+	if action == sqlite3.SQLITE_INSERT && arg1 == dryRunRowids && schema == "temp" {
+		return ""
+	}
+	if arg, ok := schemaActions[action]; ok {
+		table := arg1
+		if arg == 2 {
+			table = arg2
+		}
+		if !a.CanWrite(table, "") {
+			return "changing the schema of " + table + " is not allowed"
+		}
+		s.schemaChange = true
+		return ""
+	}
+
+	switch action {
+	case sqlite3.SQLITE_READ:
+		// Pragma functions are checked by the PRAGMA action they also report
+		if name, ok := strings.CutPrefix(strings.ToLower(arg1), "pragma_"); ok && sqlparse.KnownPragma(name) {
+			return ""
+		}
+		// A schema change reads the schema table, and the table it indexes or alters
+		if s.schemaChange && (isSchemaTable(arg1) || a.CanWrite(arg1, "")) {
+			return ""
+		}
+		if !a.CanRead(arg1, arg2) {
+			return "reading " + qualifiedColumn(arg1, arg2) + " is not allowed"
+		}
+
+	case sqlite3.SQLITE_INSERT, sqlite3.SQLITE_DELETE, sqlite3.SQLITE_UPDATE:
+		// Schema changes also write to the schema table; they are checked by their own action
+		if !isSchemaTable(arg1) && !a.CanWrite(arg1, arg2) {
+			return "writing " + qualifiedColumn(arg1, arg2) + " is not allowed"
+		}
+
+	case sqlite3.SQLITE_ANALYZE:
+		if arg1 != "" && !a.Visible(arg1) {
+			return "analyzing " + arg1 + " is not allowed"
+		}
+
+	case sqlite3.SQLITE_PRAGMA:
+		return s.checkPragma(arg1, arg2, schema)
+
+	case sqlite3.SQLITE_ATTACH, sqlite3.SQLITE_DETACH:
+		return "attaching databases is not allowed"
+	}
+	return ""
+}
+
+// checkPragma returns why a PRAGMA is not allowed, or "" when it is. Pragmas that describe a
+// table need the table to be visible, those that describe an index need its table and columns
+// to be, and those that list every table need the schema table.
+func (s *authorization) checkPragma(name, arg, schema string) string {
+	a := s.access
+	switch strings.ToLower(name) {
+	case "index_info", "index_xinfo":
+		if refs := s.indexes[strings.ToLower(arg)]; len(refs) > 0 {
+			return s.checkIndexPragma(name, arg, schema, refs)
+		}
+	case "table_info", "table_xinfo", "index_list", "foreign_key_list":
+	case "table_list", "foreign_key_check", "integrity_check", "quick_check":
+		if _, err := strconv.Atoi(arg); err == nil {
+			return ""
+		}
+		if arg == "" {
+			arg = "sqlite_master"
+		}
+	default:
+		return ""
+	}
+
+	if !a.Visible(arg) {
+		return "PRAGMA " + name + " on " + arg + " is not allowed"
+	}
+	return ""
+}
+
+// checkIndexPragma returns why a PRAGMA describing the index of one of refs is not allowed, or
+// "" when it is. Without a schema, SQLite may describe the index of any of them.
+func (s *authorization) checkIndexPragma(name, index, schema string, refs []indexRef) string {
+	for _, ref := range refs {
+		if schema != "" && !strings.EqualFold(schema, ref.schema) {
+			continue
+		}
+		visible := s.access.Visible(ref.table)
+		for _, column := range ref.columns {
+			visible = visible && s.access.ColumnVisible(ref.table, column)
+		}
+		if !visible {
+			return "PRAGMA " + name + " on " + index + " is not allowed"
+		}
+	}
+	return ""
+}
+
+// isSchemaTable reports whether table is one of the tables SQLite stores the schema in
+func isSchemaTable(table string) bool {
+	switch strings.ToLower(table) {
+	case "sqlite_master", "sqlite_temp_master", "sqlite_schema", "sqlite_temp_schema":
+		return true
+	}
+	return false
+}
+
+// qualifiedColumn returns table.column, or table when column is ""
+func qualifiedColumn(table, column string) string {
+	if column == "" {
+		return table
+	}
+	return table + "." + column
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"modernc.org/libc"
+)
+
+func TestAuthorizer(t *testing.T) {
+	db, err := New(InMemoryDB, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, password_hash TEXT)",
+		"CREATE TABLE audit (user_id INTEGER, action TEXT)",
+		"CREATE TABLE secrets (id INTEGER PRIMARY KEY, value TEXT)",
+		"CREATE INDEX users_by_name ON users(name)",
+		"CREATE INDEX users_password ON users(name, password_hash)",
+		"CREATE INDEX secrets_value ON secrets(value)",
+		"CREATE TABLE tokens (token TEXT PRIMARY KEY, user_id INTEGER) WITHOUT ROWID",
+		"CREATE VIEW user_passwords AS SELECT name, password_hash FROM users",
+		"CREATE TRIGGER users_audit AFTER INSERT ON users BEGIN INSERT INTO audit VALUES (new.id, 'insert'); END",
+		"INSERT INTO users (name, password_hash) VALUES ('alice', 'x')",
+		"INSERT INTO secrets (value) VALUES ('s')",
+	} {
+		_, err := db.Execute(stmt)
+		require.NoError(t, err)
+	}
+
+	access, err := NewAccess(
+		[]string{"users", "user_passwords", "audit"},
+		[]string{"users.name"},
+		[]string{"users.password_hash"},
+	)
+	require.NoError(t, err)
+	ctx := WithAccess(context.Background(), access)
+
+	t.Run("allowed queries", func(t *testing.T) {
+		for _, query := range []string{
+			"SELECT id, name FROM users",
+			"SELECT count(*) FROM users",
+			"SELECT * FROM pragma_table_info('users')",
+			"PRAGMA table_info(users)",
+			"PRAGMA index_info(users_by_name)",
+			"SELECT * FROM pragma_index_xinfo('users_by_name')",
+			"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 3) SELECT x FROM c",
+		} {
+			_, err := db.QueryLimitContext(ctx, 0, query)
+			assert.NoError(t, err, query)
+		}
+	})
+
+	t.Run("denied queries", func(t *testing.T) {
+		for query, reason := range map[string]string{
+			"SELECT * FROM users":                                      "reading users.password_hash",
+			"SELECT name FROM users WHERE password_hash = 'x'":         "reading users.password_hash",
+			"SELECT password_hash FROM user_passwords":                 "reading users.password_hash",
+			"SELECT value FROM secrets":                                "reading secrets.value",
+			"SELECT u.name FROM users u JOIN secrets s ON s.id = u.id": "reading secrets.id",
+			"SELECT sql FROM sqlite_schema":                            "reading sqlite_master.sql",
+			"SELECT * FROM pragma_table_info('secrets')":               "PRAGMA table_info on secrets",
+			"PRAGMA table_list":                                        "PRAGMA table_list on sqlite_master",
+			"PRAGMA index_info(users_password)":                        "PRAGMA index_info on users_password",
+			"PRAGMA main.index_xinfo(users_password)":                  "PRAGMA index_xinfo on users_password",
+			"SELECT * FROM pragma_index_info('secrets_value')":         "PRAGMA index_info on secrets_value",
+			"PRAGMA index_info(tokens)":                                "PRAGMA index_info on tokens",
+		} {
+			_, err := db.QueryLimitContext(ctx, 0, query)
+			require.ErrorIs(t, err, ErrAccessDenied, query)
+			assert.Contains(t, err.Error(), reason, query)
+		}
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		n, err := db.ExecuteContext(ctx, "UPDATE users SET name = 'bob' WHERE id = 1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		_, err = db.ExecuteContext(ctx, "UPDATE users SET password_hash = 'y'")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+		_, err = db.ExecuteLimitContext(ctx, 10, "DELETE FROM users")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+		_, err = db.ExecuteContext(ctx, "CREATE TABLE other (id)")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+
+		// Inserting rows needs write access to the whole table
+		_, err = db.ExecuteContext(ctx, "INSERT INTO users (name) VALUES ('carol')")
+		require.ErrorIs(t, err, ErrAccessDenied)
+		assert.Contains(t, err.Error(), "writing users")
+	})
+
+	t.Run("triggers", func(t *testing.T) {
+		writer, err := NewAccess([]string{"users"}, []string{"users"}, nil)
+		require.NoError(t, err)
+
+		// The trigger writes to audit, which is not writable
+		_, err = db.ExecuteContext(WithAccess(context.Background(), writer), "INSERT INTO users (name) VALUES ('carol')")
+		require.ErrorIs(t, err, ErrAccessDenied)
+		assert.Contains(t, err.Error(), "writing audit")
+	})
+
+	t.Run("schema changes", func(t *testing.T) {
+		owner, err := NewAccess(nil, []string{"scratch"}, nil)
+		require.NoError(t, err)
+		ownerCtx := WithAccess(context.Background(), owner)
+
+		for _, stmt := range []string{
+			"CREATE TABLE scratch (id INTEGER PRIMARY KEY, note TEXT)",
+			"CREATE INDEX scratch_note ON scratch(note)",
+			"ALTER TABLE scratch ADD COLUMN extra TEXT",
+			"INSERT INTO scratch (note) VALUES ('x')",
+			"DROP TABLE scratch",
+		} {
+			_, err := db.ExecuteContext(ownerCtx, stmt)
+			require.NoError(t, err, stmt)
+		}
+
+		_, err = db.ExecuteContext(ownerCtx, "DROP TABLE secrets")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+		_, err = db.ExecuteContext(ownerCtx, "CREATE INDEX users_name ON users(name)")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		_, err := db.DryRunContext(ctx, 10, "UPDATE users SET password_hash = 'y'")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+
+		owner, err := NewAccess([]string{"users"}, []string{"users"}, nil)
+		require.NoError(t, err)
+		preview, err := db.DryRunContext(WithAccess(context.Background(), owner), 10, "UPDATE users SET id = id + 1")
+		require.NoError(t, err)
+		require.Len(t, preview.Before.Rows, 1)
+		assert.Equal(t, int64(1), preview.Before.Rows[0][0])
+		assert.Equal(t, int64(2), preview.After.Rows[0][0])
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(context.Background(), "")
+		require.NoError(t, err)
+		defer tx.Rollback(context.Background())
+
+		_, err = tx.QueryLimitContext(ctx, 0, "SELECT value FROM secrets")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+		_, err = tx.ExecuteContext(ctx, "UPDATE users SET name = 'dave'")
+		assert.NoError(t, err)
+
+		// The authorizer is removed once the call returns
+		result, err := tx.QueryLimitContext(context.Background(), 0, "SELECT value FROM secrets")
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 1)
+	})
+
+	t.Run("changed indexes", func(t *testing.T) {
+		_, err := db.QueryLimitContext(ctx, 0, "PRAGMA index_info(users_by_name)")
+		require.NoError(t, err)
+
+		// The cached indexes are read again once the schema changes
+		for _, stmt := range []string{"DROP INDEX users_by_name", "CREATE INDEX users_by_name ON users(password_hash)"} {
+			_, err = db.Execute(stmt)
+			require.NoError(t, err)
+		}
+		_, err = db.QueryLimitContext(ctx, 0, "PRAGMA index_info(users_by_name)")
+		assert.ErrorIs(t, err, ErrAccessDenied)
+	})
+
+	t.Run("unrestricted calls", func(t *testing.T) {
+		result, err := db.QueryLimitContext(context.Background(), 0, "SELECT value FROM secrets")
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 1)
+
+		_, err = db.ExecuteContext(context.Background(), "INSERT INTO users (name) VALUES ('erin')")
+		assert.NoError(t, err)
+	})
+}
+
+// TestDriverHandle fails when an upgrade of modernc.org/sqlite changes the connection fields
+// the authorizer reads
+func TestDriverHandle(t *testing.T) {
+	db, err := New(InMemoryDB, false)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Execute("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+
+	err = db.withReader(context.Background(), func(conn *sql.Conn) error {
+		c, err := compile(conn, "SELECT name FROM users")
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, []columnSource{{schema: "main", table: "users", column: "name"}}, c.sources)
+		assert.Equal(t, []tableAccess{{table: "users", column: "name"}}, c.accesses)
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, driverConn := range []any{
+		nil,
+		struct{}{},
+		&struct {
+			db  uintptr
+			tls *libc.TLS
+		}{db: 1, tls: &libc.TLS{}},
+	} {
+		_, _, err := connHandle(driverConn)
+		assert.ErrorIs(t, err, errUnsupportedDriver)
+	}
+}
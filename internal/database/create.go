@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Create creates an empty database file at dbPath along with its parent directories, runs
+// the init scripts of opts on it, see WithInitScripts, and opens it like New. It fails when
+// the file already exists. When a script fails the file is removed again, so that the next
+// attempt starts from an empty database.
+func Create(dbPath string, readOnly bool, opts ...Option) (*DB, error) {
+	if dbPath == InMemoryDB {
+		return nil, errors.New("cannot create an in-memory database")
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Clean(dbPath), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		Remove(dbPath)
+		return nil, fmt.Errorf("failed to create database file: %w", err)
+	}
+
+	db, err := New(dbPath, false, opts...)
+	if err != nil {
+		Remove(dbPath)
+		return nil, err
+	}
+	log.Printf("Created database %s", dbPath)
+	if !readOnly {
+		return db, nil
+	}
+
+	// Reopen the seeded database read-only, without running the scripts again
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+	return New(dbPath, true, append(slices.Clone(opts), withoutScripts())...)
+}
+
+// withoutScripts drops the scripts of the options before it
+func withoutScripts() Option {
+	return func(o *options) {
+		o.initScripts = nil
+	}
+}
+
+// Remove removes a database file and the journal files SQLite keeps beside it. The database
+// must be closed first.
+func Remove(dbPath string) {
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove %s: %v", dbPath+suffix, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.sql")
+	require.NoError(t, os.WriteFile(schema, []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);"), 0o600))
+
+	t.Run("creates parent directories and runs the schema", func(t *testing.T) {
+		path := filepath.Join(dir, "nested", "deeper", "new.db")
+		db, err := Create(path, false, WithInitScripts(schema))
+		require.NoError(t, err)
+		defer db.Close()
+
+		assert.FileExists(t, path)
+		_, err = db.ExecuteContext(ctx, "INSERT INTO items (name) VALUES ('first')")
+		assert.NoError(t, err)
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		path := createTestDB(t)
+		_, err := Create(path, false)
+		assert.ErrorContains(t, err, "file exists")
+
+		// The existing database is left alone
+		db, err := New(path, true)
+		require.NoError(t, err)
+		defer db.Close()
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT count(*) FROM users")
+		require.NoError(t, err)
+		assert.NotZero(t, result.Rows[0][0])
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		db, err := Create(filepath.Join(dir, "readonly.db"), true, WithInitScripts(schema))
+		require.NoError(t, err)
+		defer db.Close()
+
+		assert.False(t, db.Writable())
+		_, err = db.QueryLimitContext(ctx, 0, "SELECT * FROM items")
+		assert.NoError(t, err)
+	})
+
+	t.Run("failing schema removes the file", func(t *testing.T) {
+		broken := filepath.Join(dir, "broken.sql")
+		require.NoError(t, os.WriteFile(broken, []byte("CREATE TABLE items (id INTEGER); CREATE TABLE items (id INTEGER);"), 0o600))
+
+		path := filepath.Join(dir, "broken.db")
+		_, err := Create(path, false, WithInitScripts(broken))
+		assert.ErrorContains(t, err, "already exists")
+		assert.NoFileExists(t, path)
+		assert.NoFileExists(t, path+"-journal")
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		path := filepath.Join(dir, "removed.db")
+		db, err := Create(path, false, WithInitScripts(schema))
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		Remove(path)
+		assert.NoFileExists(t, path)
+		db, err = Create(path, false)
+		require.NoError(t, err)
+		assert.NoError(t, db.Close())
+	})
+
+	t.Run("in-memory", func(t *testing.T) {
+		_, err := Create(InMemoryDB, false)
+		assert.ErrorContains(t, err, "cannot create an in-memory database")
+	})
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrDatabaseNotFound is returned when a database name does not match any served database
+var ErrDatabaseNotFound = errors.New("database not found")
+
+// databaseName restricts database names to ones that can appear in resource URIs unescaped
+var databaseName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]*$`)
+
+// Databases is a set of named databases served by one process. The first database added
+// is the default for calls that do not name one. Databases may be added while it is in use.
+type Databases struct {
+	mu    sync.RWMutex
+	names []string
+	dbs   map[string]*DB
+}
+
+// NewDatabases creates an empty set of databases
+func NewDatabases() *Databases {
+	return &Databases{dbs: make(map[string]*DB)}
+}
+
+// ValidateDatabaseName checks that name can be used to register a database
+func ValidateDatabaseName(name string) error {
+	if !databaseName.MatchString(name) {
+		return fmt.Errorf("invalid database name %q: use letters, digits, underscores and hyphens", name)
+	}
+	return nil
+}
+
+// Add registers db under name. Names are unique and compared case-insensitively.
+func (d *Databases) Add(name string, db *DB) error {
+	if err := ValidateDatabaseName(name); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.dbs[strings.ToLower(name)]; exists {
+		return fmt.Errorf("database %q is already registered", name)
+	}
+
+	d.names = append(d.names, name)
+	d.dbs[strings.ToLower(name)] = db
+	return nil
+}
+
+// Get returns the database registered under name, or the default database when name is ""
+func (d *Databases) Get(name string) (*DB, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	name, err := d.name(name)
+	if err != nil {
+		return nil, err
+	}
+	return d.dbs[strings.ToLower(name)], nil
+}
+
+// Name returns the registered spelling of a database name, or the default database's name
+// when name is ""
+func (d *Databases) Name(name string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.name(name)
+}
+
+// name implements Name for callers holding d.mu
+func (d *Databases) name(name string) (string, error) {
+	if name == "" && len(d.names) > 0 {
+		return d.names[0], nil
+	}
+	for _, registered := range d.names {
+		if strings.EqualFold(registered, name) {
+			return registered, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrDatabaseNotFound, name)
+}
+
+// Names returns the database names in the order they were added
+func (d *Databases) Names() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]string(nil), d.names...)
+}
+
+// Writable reports whether any of the databases accepts writes
+func (d *Databases) Writable() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, db := range d.dbs {
+		if db.Writable() {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes every database, returning the errors of those that failed to close
+func (d *Databases) Close() error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var errs []error
+	for _, name := range d.names {
+		if err := d.dbs[strings.ToLower(name)].Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close database %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabases(t *testing.T) {
+	first, err := New(createTestDB(t), false)
+	require.NoError(t, err)
+	second, err := New(createTestDB(t), true)
+	require.NoError(t, err)
+
+	dbs := NewDatabases()
+	require.NoError(t, dbs.Add("first", first))
+	require.NoError(t, dbs.Add("Second", second))
+	defer func() { assert.NoError(t, dbs.Close()) }()
+
+	t.Run("lookup", func(t *testing.T) {
+		db, err := dbs.Get("")
+		require.NoError(t, err)
+		assert.Same(t, first, db)
+
+		db, err = dbs.Get("second")
+		require.NoError(t, err)
+		assert.Same(t, second, db)
+
+		name, err := dbs.Name("SECOND")
+		require.NoError(t, err)
+		assert.Equal(t, "Second", name)
+
+		_, err = dbs.Get("third")
+		assert.ErrorIs(t, err, ErrDatabaseNotFound)
+
+		assert.Equal(t, []string{"first", "Second"}, dbs.Names())
+	})
+
+	t.Run("writable", func(t *testing.T) {
+		assert.True(t, first.Writable())
+		assert.False(t, second.Writable())
+		assert.True(t, dbs.Writable())
+
+		readOnly := NewDatabases()
+		require.NoError(t, readOnly.Add("second", second))
+		assert.False(t, readOnly.Writable())
+	})
+
+	t.Run("invalid names", func(t *testing.T) {
+		assert.Error(t, dbs.Add("FIRST", first))
+		for _, name := range []string{"", "has space", "a/b", "-leading"} {
+			assert.Error(t, dbs.Add(name, first), name)
+		}
+	})
+}
@@ -2,11 +2,16 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 
-	_ "modernc.org/sqlite" // Pure Go SQLite driver
+	"modernc.org/sqlite" // Pure Go SQLite driver
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
 )
 
 const (
@@ -14,14 +19,55 @@ const (
 	InMemoryDB = ":memory:"
 )
 
-// DB wraps a SQLite database connection with common operations
+// queryer is the subset of *sql.DB and *sql.Conn used to run statements
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Executor runs statements either on the database's connections or inside a transaction
+type Executor interface {
+	QueryLimitContext(ctx context.Context, limit int, query string, args ...interface{}) (*Result, error)
+	ExecuteContext(ctx context.Context, statement string, args ...interface{}) (int64, error)
+	ExecuteLimitContext(ctx context.Context, limit int64, statement string, args ...interface{}) (int64, error)
+	IsReadOnly(ctx context.Context, statement string, args ...interface{}) (bool, error)
+	DryRunContext(ctx context.Context, sample int, statement string, args ...interface{}) (*Preview, error)
+}
+
+var (
+	_ Executor = (*DB)(nil)
+	_ Executor = (*Tx)(nil)
+)
+
+// DB wraps a SQLite database with a pool of read-only connections that queries run on and a
+// single writer connection that writes and transactions take turns on. Read-only database
+// files use a single pool for both.
 type DB struct {
-	conn *sql.DB
-	path string
+	readers    *sql.DB
+	writer     *sql.DB
+	writes     *writeQueue
+	path       string
+	readOnly   bool
+	durability string
+	// keep holds a read-only in-memory database open
+	keep       *sql.DB
+	maxReaders int
+	// journalMode is the journal mode of the writer connection
+	journalMode string
+	// indexes caches the indexes the authorizer checks index pragmas against
+	indexes indexCache
 }
 
 // New creates a new database connection
-func New(dbPath string, readOnly bool) (*DB, error) {
+func New(dbPath string, readOnly bool, opts ...Option) (*DB, error) {
+	o := options{maxReaders: DefaultMaxReaders, idleReaders: DefaultIdleReaders}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
 	// Check if database file exists (skip check for in-memory databases)
 	if dbPath != InMemoryDB {
 		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
@@ -29,100 +75,326 @@ func New(dbPath string, readOnly bool) (*DB, error) {
 		}
 	}
 
-	// Construct the database URI with appropriate parameters
-	var dsn string
-	if dbPath == InMemoryDB {
-		dsn = InMemoryDB
-	} else {
-		// Convert file path to URI format
-		dsn = fmt.Sprintf("file:%s", dbPath)
+	if o.pragmas == nil {
+		pragmas, err := ProfilePragmas(DefaultDurability)
+		if err != nil {
+			return nil, err
+		}
+		o.durability, o.pragmas = DefaultDurability, &pragmas
+	}
+	if err := o.pragmas.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Each database gets its own driver so that its connection hooks only apply to its pools
+	drv := &sqlite.Driver{}
+	if len(o.attachments) > 0 {
+		drv.RegisterConnectionHook(attachHook(o.attachments))
+	}
+
+	db := &DB{
+		writes:     &writeQueue{max: o.maxQueuedWrites},
+		path:       dbPath,
+		readOnly:   readOnly,
+		durability: o.durability,
+	}
+
+	if err := db.openPools(drv, &o); err != nil {
+		return nil, err
+	}
+	if err := db.writer.QueryRow("PRAGMA journal_mode").Scan(&db.journalMode); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to read journal mode: %w", err)
+	}
+	db.checkPragmas(*o.pragmas)
+	return db, nil
+}
+
+// openPools opens the reader pool and the writer connection, and runs the init scripts
+func (db *DB) openPools(drv *sqlite.Driver, o *options) error {
+	if db.path == InMemoryDB {
+		return db.openMemory(drv, o)
+	}
 
-		// Add query parameters based on mode
-		if readOnly {
-			dsn += "?mode=ro"
-		} else {
-			dsn += "?mode=rwc"
+	if db.readOnly {
+		if len(o.initScripts) > 0 {
+			return fmt.Errorf("cannot run scripts on read-only database %s", db.path)
 		}
+		pool, err := openPool(drv, dsn(db.path, true, *o.pragmas))
+		if err != nil {
+			return err
+		}
+		pool.SetMaxOpenConns(o.maxReaders)
+		pool.SetMaxIdleConns(o.idleReaders)
+		db.readers, db.writer, db.maxReaders = pool, pool, o.maxReaders
+		return nil
+	}
 
-		// Configure SQLite for container environments with restricted filesystem access
-		dsn += "&_journal_mode=off&_temp_store=memory&_synchronous=off&_cache_size=-64000&_mmap_size=0"
+	// The writer connects first so that it sets the journal mode readers find
+	writer, err := openPool(drv, dsn(db.path, false, *o.pragmas))
+	if err != nil {
+		return err
+	}
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	if err := runScripts(context.Background(), writer, o.initScripts); err != nil {
+		_ = writer.Close()
+		return err
 	}
 
-	fmt.Printf("Connecting to database: %s\n", dsn)
-	conn, err := sql.Open("sqlite", dsn)
+	readers, err := openPool(drv, dsn(db.path, true, *o.pragmas))
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		_ = writer.Close()
+		return err
 	}
+	readers.SetMaxOpenConns(o.maxReaders)
+	readers.SetMaxIdleConns(o.idleReaders)
+	db.readers, db.writer, db.maxReaders = readers, writer, o.maxReaders
+	return nil
+}
+
+// dsn returns the data source name that opens a database file with the given settings
+func dsn(dbPath string, readOnly bool, pragmas Pragmas) string {
+	mode := "rwc"
+	if readOnly {
+		mode = "ro"
+	}
+	return fileURI(dbPath) + "?mode=" + mode + "&" + pragmas.query(readOnly, false)
+}
+
+// openPool opens a connection pool to a data source and checks that it can connect
+func openPool(drv *sqlite.Driver, source string) (*sql.DB, error) {
+	log.Printf("Connecting to database: %s", source)
+	pool := sql.OpenDB(connector{driver: drv, dsn: source})
 
 	// Test the connection
-	if err := conn.Ping(); err != nil {
-		if err := conn.Close(); err != nil {
+	if err := pool.Ping(); err != nil {
+		if err := pool.Close(); err != nil {
 			return nil, fmt.Errorf("failed to close database connection after ping error: %w", err)
 		}
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
+	return pool, nil
+}
 
-	return &DB{
-		conn: conn,
-		path: dbPath,
-	}, nil
+// checkPragmas reads back the settings in effect after connecting and logs them, warning
+// when SQLite did not apply the requested journal mode, as when the filesystem does not
+// support write-ahead logging
+func (db *DB) checkPragmas(requested Pragmas) {
+	d, err := db.Diagnostics(context.Background())
+	if err != nil {
+		log.Printf("Failed to read the settings of %s: %v", db.path, err)
+		return
+	}
+	log.Printf("Database %s: journal_mode=%s synchronous=%s busy_timeout=%dms cache_size=%d mmap_size=%d",
+		db.path, d.JournalMode, d.Synchronous, d.BusyTimeoutMS, d.CacheSize, d.MmapSize)
+	if !db.readOnly && db.path != InMemoryDB && db.journalMode != requested.JournalMode {
+		log.Printf("Warning: %s uses journal_mode=%s instead of the requested %s", db.path, db.journalMode, requested.JournalMode)
+	}
 }
 
-// Close closes the database connection
+// Close closes the database connections
 func (db *DB) Close() error {
-	if db.conn != nil {
-		return db.conn.Close()
+	var errs []error
+	if db.readers != nil {
+		errs = append(errs, db.readers.Close())
 	}
-	return nil
+	if db.writer != nil && db.writer != db.readers {
+		errs = append(errs, db.writer.Close())
+	}
+	if db.keep != nil {
+		errs = append(errs, db.keep.Close())
+	}
+	return errors.Join(errs...)
 }
 
 // Query executes a SELECT query and returns the results
 func (db *DB) Query(query string, args ...interface{}) ([]map[string]interface{}, error) {
-	rows, err := db.conn.Query(query, args...)
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a SELECT query and returns the results with values encoded by
+// the default Encoding. The running statement is interrupted when ctx is cancelled or its
+// deadline passes.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	result, err := db.QueryLimitContext(ctx, 0, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	Encoding{}.EncodeResult(result)
+
+	var results []map[string]interface{}
+	for _, values := range result.Rows {
+		row := make(map[string]interface{}, len(result.Columns))
+		for i, col := range result.Columns {
+			row[col.Name] = values[i]
+		}
+		results = append(results, row)
+	}
+
+	return results, nil
+}
+
+// Column describes a column of a query result
+type Column struct {
+	// Name is the column name or alias
+	Name string `json:"name"`
+	// Type is the declared type of the underlying table column, or "" for expressions
+	Type string `json:"type"`
+	// Nullable reports whether the column may hold NULL; true when the driver cannot tell
+	Nullable bool `json:"nullable"`
+	// StorageClasses lists the storage classes of the column's values, filled in by Encoding.EncodeResult
+	StorageClasses []StorageClass `json:"storage_classes,omitempty"`
+	// Masked names the masking method applied to the column's values, see Masks
+	Masked string `json:"masked,omitempty"`
+}
+
+// Result is a query result with its columns and rows in statement order
+type Result struct {
+	Columns []Column
+	// Rows holds the values as scanned from the driver until encoded with Encoding.EncodeResult
+	Rows [][]interface{}
+	// Truncated is true when the query produced more rows than were returned
+	Truncated bool
+}
+
+// QueryLimitContext executes a SELECT query and returns at most limit rows along with the
+// result's column metadata. A limit of zero or less returns every row. Values are returned
+// as scanned from the driver, masked by the masks ctx carries (see WithMasks); use
+// Encoding.EncodeResult to prepare them for JSON.
+func (db *DB) QueryLimitContext(ctx context.Context, limit int, query string, args ...interface{}) (*Result, error) {
+	var result *Result
+	err := db.withRules(ctx, func(q queryer) (err error) {
+		result, err = queryMasked(ctx, q, limit, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// withRules runs fn on the reader pool, or on a dedicated reader connection when ctx carries
+// access rules or masks. Its statements are then checked against the access rules, see WithAccess.
+func (db *DB) withRules(ctx context.Context, fn func(queryer) error) error {
+	if AccessFromContext(ctx) == nil && MasksFromContext(ctx) == nil {
+		return fn(db.readers)
+	}
+
+	return db.withReader(ctx, func(conn *sql.Conn) error {
+		return withAuthorizer(ctx, &db.indexes, conn, func() error { return fn(conn) })
+	})
+}
+
+// queryMasked runs a query on q like queryLimit and masks its result by the masks ctx
+// carries. q must be a dedicated connection when ctx carries masks.
+func queryMasked(ctx context.Context, q queryer, limit int, query string, args ...interface{}) (*Result, error) {
+	masks := MasksFromContext(ctx)
+	if masks == nil {
+		return queryLimit(ctx, q, limit, query, args...)
+	}
+
+	c, err := compile(q, query)
+	if err != nil {
+		return nil, err
+	}
+	if masks.computedRule(c.accesses) != nil {
+		if err := c.markIndirect(ctx, q, query); err != nil {
+			return nil, err
+		}
+	}
+	result, err := queryLimit(ctx, q, limit, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	masks.apply(result, c)
+	return result, nil
+}
+
+// queryLimit runs a query on q and scans at most limit rows
+func queryLimit(ctx context.Context, q queryer, limit int, query string, args ...interface{}) (*Result, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	columns, err := rows.Columns()
+	columns, err := resultColumns(rows)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
+		return nil, err
 	}
 
-	var results []map[string]interface{}
+	result := &Result{Columns: columns}
 	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		valuePtrs := make([]interface{}, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
+		if limit > 0 && len(result.Rows) == limit {
+			result.Truncated = true
+			break
 		}
 
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+		values, err := scanRow(rows, len(columns))
+		if err != nil {
+			return nil, err
 		}
-
-		row := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-		results = append(results, row)
+		result.Rows = append(result.Rows, values)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("row iteration error: %w", err)
 	}
 
-	return results, nil
+	return result, nil
+}
+
+// resultColumns returns the column metadata of rows
+func resultColumns(rows *sql.Rows) ([]Column, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	columns := make([]Column, len(columnTypes))
+	for i, ct := range columnTypes {
+		nullable, ok := ct.Nullable()
+		columns[i] = Column{
+			Name:     ct.Name(),
+			Type:     ct.DatabaseTypeName(),
+			Nullable: nullable || !ok,
+		}
+	}
+	return columns, nil
+}
+
+// scanRow scans the current row of rows into a slice of n driver values
+func scanRow(rows *sql.Rows, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	valuePtrs := make([]interface{}, n)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	return values, nil
 }
 
 // Execute runs an INSERT, UPDATE, or DELETE statement
 func (db *DB) Execute(statement string, args ...interface{}) (int64, error) {
-	result, err := db.conn.Exec(statement, args...)
+	return db.ExecuteContext(context.Background(), statement, args...)
+}
+
+// ExecuteContext runs an INSERT, UPDATE, or DELETE statement on the writer connection once
+// the writes queued before it are done. The statement is interrupted, or given up on while
+// it waits for the writer, when ctx is cancelled or its deadline passes.
+func (db *DB) ExecuteContext(ctx context.Context, statement string, args ...interface{}) (int64, error) {
+	var n int64
+	err := db.withWriter(ctx, func(conn *sql.Conn) (err error) {
+		n, err = execute(ctx, conn, statement, args...)
+		return err
+	})
+	return n, err
+}
+
+// execute runs a statement on q and returns the number of affected rows
+func execute(ctx context.Context, q queryer, statement string, args ...interface{}) (int64, error) {
+	result, err := q.ExecContext(ctx, statement, args...)
 	if err != nil {
 		return 0, fmt.Errorf("execution failed: %w", err)
 	}
@@ -135,31 +407,115 @@ func (db *DB) Execute(statement string, args ...interface{}) (int64, error) {
 	return rowsAffected, nil
 }
 
-// GetTables returns a list of all tables in the database
-func (db *DB) GetTables() ([]string, error) {
-	query := "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
-	rows, err := db.Query(query)
-	if err != nil {
-		return nil, err
+// limitSavepoint is the savepoint a row-limited statement executes under
+const limitSavepoint = "sqlite_mcp_limit"
+
+// AffectedRowsError is returned when a statement changes more rows than allowed
+type AffectedRowsError struct {
+	RowsAffected int64
+	Limit        int64
+}
+
+// Error implements error
+func (e *AffectedRowsError) Error() string {
+	return fmt.Sprintf("statement would affect %d rows, more than the limit of %d; its changes were rolled back",
+		e.RowsAffected, e.Limit)
+}
+
+// ExecuteLimitContext runs a statement like ExecuteContext, but rolls it back and returns an
+// *AffectedRowsError when it changes more than limit rows. A limit of zero or less disables the check.
+func (db *DB) ExecuteLimitContext(ctx context.Context, limit int64, statement string, args ...interface{}) (int64, error) {
+	if limit <= 0 {
+		return db.ExecuteContext(ctx, statement, args...)
+	}
+
+	var n int64
+	err := db.withWriter(ctx, func(conn *sql.Conn) (err error) {
+		n, err = executeLimit(ctx, conn, limit, statement, args...)
+		return err
+	})
+	return n, err
+}
+
+// executeLimit runs statement on q under a savepoint that is rolled back when more than limit rows change
+func executeLimit(ctx context.Context, q queryer, limit int64, statement string, args ...interface{}) (int64, error) {
+	if _, err := q.ExecContext(ctx, "SAVEPOINT "+limitSavepoint); err != nil {
+		return 0, fmt.Errorf("failed to start statement: %w", err)
 	}
 
-	var tables []string
-	for _, row := range rows {
-		if name, ok := row["name"].(string); ok {
-			tables = append(tables, name)
+	n, err := executeCounted(ctx, q, statement, args...)
+	if err == nil && n > limit {
+		err = &AffectedRowsError{RowsAffected: n, Limit: limit}
+	}
+	if err == nil {
+		if _, err = q.ExecContext(ctx, "RELEASE "+limitSavepoint); err == nil {
+			return n, nil
 		}
+		err = fmt.Errorf("failed to release savepoint: %w", err)
 	}
 
-	return tables, nil
+	// The rollback must happen even when ctx has been cancelled
+	if undoErr := undoSavepoint(context.WithoutCancel(ctx), q, limitSavepoint); undoErr != nil {
+		return 0, errors.Join(err, undoErr)
+	}
+	return 0, err
 }
 
-// GetTableSchema returns the schema information for a specific table
-func (db *DB) GetTableSchema(tableName string) ([]map[string]interface{}, error) {
-	query := fmt.Sprintf("PRAGMA table_info(%s)", tableName)
-	return db.Query(query)
+// executeCounted runs a statement on q and returns the number of changed rows. The driver
+// does not report the changes of a statement with a RETURNING clause, so its rows are counted.
+func executeCounted(ctx context.Context, q queryer, statement string, args ...interface{}) (int64, error) {
+	if dml, ok := parseDML(statement); ok && dml.Returning {
+		_, n, err := queryReturning(ctx, q, 0, statement, args...)
+		return n, err
+	}
+	return execute(ctx, q, statement, args...)
+}
+
+// parseDML parses statement as a single INSERT, UPDATE or DELETE statement
+func parseDML(statement string) (sqlparse.DML, bool) {
+	return parseOne(statement).DML()
 }
 
 // Path returns the database file path
 func (db *DB) Path() string {
 	return db.path
 }
+
+// Writable reports whether the database was opened for writing
+func (db *DB) Writable() bool {
+	return !db.readOnly
+}
+
+// IsReadOnly reports whether SQLite considers a single prepared statement read-only.
+// It mirrors sqlite3_stmt_readonly by compiling the statement with EXPLAIN and looking
+// for opcodes that open a write transaction or modify the database file. The statement
+// is only prepared, never executed; args are bound as they would be for execution.
+// ATTACH, DETACH and transaction control statements are read-only by this definition,
+// and PRAGMA statements must not be passed here as some of them take effect while
+// being prepared.
+func (db *DB) IsReadOnly(ctx context.Context, statement string, args ...interface{}) (bool, error) {
+	return isReadOnly(ctx, db.readers, statement, args...)
+}
+
+// isReadOnly compiles statement with EXPLAIN on q and inspects the program, see DB.IsReadOnly
+func isReadOnly(ctx context.Context, q queryer, statement string, args ...interface{}) (bool, error) {
+	program, err := queryLimit(ctx, q, 0, "EXPLAIN "+statement, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+
+	// EXPLAIN returns addr, opcode, p1, p2, ... columns
+	for _, op := range program.Rows {
+		switch op[1] {
+		case "Transaction":
+			// P2 is non-zero when the statement starts a write transaction
+			if p2, ok := op[3].(int64); ok && p2 != 0 {
+				return false, nil
+			}
+		case "Checkpoint", "JournalMode", "Vacuum":
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -1,8 +1,10 @@
 package database
 
 import (
+	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -129,29 +131,335 @@ func TestExecute(t *testing.T) {
 	})
 }
 
+func TestExecuteLimitContext(t *testing.T) {
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	t.Run("over the limit is rolled back", func(t *testing.T) {
+		n, err := db.ExecuteLimitContext(ctx, 1, "UPDATE users SET age = 0")
+		var limitErr *AffectedRowsError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, int64(2), limitErr.RowsAffected)
+		assert.Equal(t, int64(0), n)
+
+		results, err := db.Query("SELECT count(*) AS n FROM users WHERE age = 0")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), results[0]["n"])
+	})
+
+	t.Run("within the limit is kept", func(t *testing.T) {
+		n, err := db.ExecuteLimitContext(ctx, 2, "UPDATE users SET age = age + 1 RETURNING id")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+
+		results, err := db.Query("SELECT age FROM users WHERE name = 'Bob'")
+		require.NoError(t, err)
+		assert.Equal(t, int64(26), results[0]["age"])
+	})
+}
+
 func TestGetTables(t *testing.T) {
+	ctx := context.Background()
 	dbPath := createTestDB(t)
 	db, err := New(dbPath, false)
 	require.NoError(t, err)
 	defer db.Close()
 
-	tables, err := db.GetTables()
+	tables, err := db.GetTables(ctx)
 	require.NoError(t, err)
-	assert.Contains(t, tables, "users")
+	assert.Contains(t, tables, TableInfo{Schema: "main", Name: "users"})
+
+	t.Run("virtual tables", func(t *testing.T) {
+		_, err := db.Execute("CREATE VIRTUAL TABLE docs USING fts5(body)")
+		require.NoError(t, err)
+		_, err = db.Execute("CREATE VIRTUAL TABLE boxes USING rtree(id, min_x, max_x)")
+		require.NoError(t, err)
+
+		tables, err := db.GetTables(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, tables, TableInfo{Schema: "main", Name: "docs", Virtual: true, Module: "fts5"})
+		assert.Contains(t, tables, TableInfo{Schema: "main", Name: "boxes", Virtual: true, Module: "rtree"})
+		assert.Contains(t, tables, TableInfo{Schema: "main", Name: "docs_content", Shadow: true})
+	})
+}
+
+func TestSchemaObjects(t *testing.T) {
+	ctx := context.Background()
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range []string{
+		"CREATE VIEW adults AS SELECT * FROM users WHERE age >= 18",
+		"CREATE UNIQUE INDEX users_by_email ON users (email)",
+		"CREATE TABLE audit (user_id INTEGER, at TEXT)",
+		"CREATE TRIGGER users_audit AFTER UPDATE OF age ON users BEGIN INSERT INTO audit VALUES (new.id, 'now'); END",
+	} {
+		_, err := db.Execute(stmt)
+		require.NoError(t, err)
+	}
+
+	views, err := db.GetViews(ctx)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, "adults", views[0].Name)
+	assert.Contains(t, views[0].SQL, "CREATE VIEW")
+
+	indexes, err := db.GetIndexes(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, indexes, 1)
+	assert.Equal(t, "users", indexes[0].Table)
+	assert.Equal(t, "users_by_email", indexes[0].Name)
+	assert.True(t, indexes[0].Unique)
+
+	indexes, err = db.GetIndexes(ctx, "audit")
+	require.NoError(t, err)
+	assert.Empty(t, indexes)
+
+	triggers, err := db.GetTriggers(ctx, "users")
+	require.NoError(t, err)
+	require.Len(t, triggers, 1)
+	assert.Equal(t, Trigger{
+		Schema: "main",
+		Name:   "users_audit",
+		Table:  "users",
+		Timing: "AFTER",
+		Event:  "UPDATE",
+		SQL:    triggers[0].SQL,
+		refs: references{accesses: []tableAccess{
+			{table: "audit", write: true, by: "users_audit"},
+			{table: "users", column: "id", by: "users_audit"},
+		}, ok: true},
+	}, triggers[0])
+
+	triggers, err = db.GetTriggers(ctx, "audit")
+	require.NoError(t, err)
+	assert.Empty(t, triggers)
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := db.GetTables(cancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = db.GetViews(cancelled)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = db.GetIndexes(cancelled, "users")
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = db.GetTriggers(cancelled, "")
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = db.ResolveTable(cancelled, "users")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 }
 
 func TestGetTableSchema(t *testing.T) {
+	ctx := context.Background()
 	dbPath := createTestDB(t)
 	db, err := New(dbPath, false)
 	require.NoError(t, err)
 	defer db.Close()
 
-	schema, err := db.GetTableSchema("users")
+	schema, err := db.GetTableSchema(ctx, "users")
 	require.NoError(t, err)
-	assert.Len(t, schema, 4) // id, name, email, age
+	require.NotNil(t, schema)
+	assert.Len(t, schema.Columns, 4) // id, name, email, age
 
 	// Check first column (id)
-	assert.Equal(t, "id", schema[0]["name"])
-	assert.Contains(t, []string{"INTEGER", "INT"}, schema[0]["type"]) // SQLite may return either
-	assert.Equal(t, int64(0), schema[0]["pk"])                        // Primary key flag
+	assert.Equal(t, "id", schema.Columns[0].Name)
+	assert.Contains(t, []string{"INTEGER", "INT"}, schema.Columns[0].Type) // SQLite may return either
+	assert.Equal(t, int64(0), schema.Columns[0].PK)                        // Primary key flag
+	assert.Equal(t, "main", schema.Schema)
+	assert.Equal(t, "table", schema.Type)
+
+	t.Run("keys, indexes and constraints", func(t *testing.T) {
+		_, err := db.Execute(`CREATE TABLE orders (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code TEXT COLLATE NOCASE UNIQUE,
+			qty INTEGER DEFAULT 1 CHECK (qty > 0),
+			total REAL GENERATED ALWAYS AS (qty * 2.5) VIRTUAL,
+			CHECK (code <> '')
+		) STRICT`)
+		require.NoError(t, err)
+		_, err = db.Execute("CREATE INDEX orders_by_user ON orders (user_id, qty DESC) WHERE qty > 1")
+		require.NoError(t, err)
+
+		schema, err := db.GetTableSchema(ctx, "orders")
+		require.NoError(t, err)
+		require.NotNil(t, schema)
+
+		assert.True(t, schema.Strict)
+		assert.False(t, schema.WithoutRowid)
+		assert.Contains(t, schema.SQL, "CREATE TABLE orders")
+		assert.Equal(t, []string{"id"}, schema.PrimaryKey)
+
+		require.Len(t, schema.Columns, 5)
+		assert.True(t, schema.Columns[1].NotNull)
+		assert.Equal(t, "NOCASE", schema.Columns[2].Collation)
+		assert.Equal(t, "1", schema.Columns[3].Default)
+		assert.Equal(t, "virtual", schema.Columns[4].Generated)
+
+		require.Len(t, schema.Indexes, 2)
+		byName := map[string]Index{}
+		for _, idx := range schema.Indexes {
+			byName[idx.Name] = idx
+		}
+		partial := byName["orders_by_user"]
+		assert.True(t, partial.Partial)
+		assert.Equal(t, "c", partial.Origin)
+		assert.Contains(t, partial.SQL, "CREATE INDEX")
+		require.Len(t, partial.Columns, 2)
+		assert.Equal(t, "qty", partial.Columns[1].Name)
+		assert.True(t, partial.Columns[1].Desc)
+		assert.Equal(t, "u", byName["sqlite_autoindex_orders_1"].Origin)
+
+		require.Len(t, schema.ForeignKeys, 1)
+		assert.Equal(t, "users", schema.ForeignKeys[0].Table)
+		assert.Equal(t, []string{"user_id"}, schema.ForeignKeys[0].Columns)
+		assert.Equal(t, []string{"id"}, schema.ForeignKeys[0].References)
+		assert.Equal(t, "CASCADE", schema.ForeignKeys[0].OnDelete)
+
+		assert.Equal(t, []CheckConstraint{
+			{Column: "qty", Expression: "qty > 0"},
+			{Expression: "code <> ''"},
+		}, schema.Checks)
+	})
+
+	t.Run("without rowid", func(t *testing.T) {
+		_, err := db.Execute("CREATE TABLE kv (k TEXT, v TEXT, PRIMARY KEY (v, k)) WITHOUT ROWID")
+		require.NoError(t, err)
+
+		schema, err := db.GetTableSchema(ctx, "kv")
+		require.NoError(t, err)
+		require.NotNil(t, schema)
+		assert.True(t, schema.WithoutRowid)
+		assert.Equal(t, []string{"v", "k"}, schema.PrimaryKey)
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		schema, err := db.GetTableSchema(ctx, "missing")
+		assert.ErrorIs(t, err, ErrTableNotFound)
+		assert.Nil(t, schema)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := db.GetTableSchema(cancelled, "users")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestIsReadOnly(t *testing.T) {
+	ctx := context.Background()
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tests := []struct {
+		statement string
+		readOnly  bool
+	}{
+		{"SELECT * FROM users", true},
+		{"WITH adults AS (SELECT * FROM users WHERE age >= 18) SELECT name FROM adults", true},
+		{"VALUES (1, 2)", true},
+		{"INSERT INTO users (name) VALUES ('Eve')", false},
+		{"WITH x AS (SELECT 1) DELETE FROM users", false},
+		{"CREATE TEMP TABLE scratch (a)", false},
+		{"VACUUM", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.statement, func(t *testing.T) {
+			readOnly, err := db.IsReadOnly(ctx, tt.statement)
+			require.NoError(t, err)
+			assert.Equal(t, tt.readOnly, readOnly)
+		})
+	}
+
+	t.Run("with parameters", func(t *testing.T) {
+		readOnly, err := db.IsReadOnly(ctx, "SELECT * FROM users WHERE age > ?", 27)
+		require.NoError(t, err)
+		assert.True(t, readOnly)
+	})
+
+	t.Run("invalid statement", func(t *testing.T) {
+		_, err := db.IsReadOnly(ctx, "SELECT * FROM non_existent_table")
+		assert.Error(t, err)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := db.IsReadOnly(cancelled, "SELECT * FROM users")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestQueryContext(t *testing.T) {
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("interrupted by deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := db.QueryContext(ctx,
+			"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c")
+		assert.Error(t, err)
+	})
+
+	t.Run("interrupted statement", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := db.ExecuteContext(ctx,
+			"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) UPDATE users SET age = (SELECT count(*) FROM c)")
+		assert.Error(t, err)
+	})
+}
+
+func TestQueryLimitContext(t *testing.T) {
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("ordered columns", func(t *testing.T) {
+		result, err := db.QueryLimitContext(context.Background(), 0, "SELECT name, id, age + 1 AS next_age FROM users ORDER BY id")
+		require.NoError(t, err)
+		require.Len(t, result.Columns, 3)
+
+		assert.Equal(t, "name", result.Columns[0].Name)
+		assert.Equal(t, "TEXT", result.Columns[0].Type)
+		assert.Equal(t, "id", result.Columns[1].Name)
+		assert.Contains(t, []string{"INTEGER", "INT"}, result.Columns[1].Type) // CREATE TABLE AS declares INT
+		assert.Equal(t, "next_age", result.Columns[2].Name)
+		assert.Equal(t, "", result.Columns[2].Type)
+
+		assert.Equal(t, []interface{}{"Alice", int64(1), int64(31)}, result.Rows[0])
+		assert.False(t, result.Truncated)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		result, err := db.QueryLimitContext(context.Background(), 1, "SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 1)
+		assert.True(t, result.Truncated)
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		result, err := db.QueryLimitContext(context.Background(), 2, "SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 2)
+		assert.False(t, result.Truncated)
+	})
 }
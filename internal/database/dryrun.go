@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+const (
+	// dryRunSavepoint is the savepoint a dry run executes under before rolling back
+	dryRunSavepoint = "sqlite_mcp_dry_run"
+	// dryRunRowids is the temporary table a dry run of an UPDATE records the rowids of the
+	// changed rows in, as they were and as they are after the update
+	dryRunRowids = "mcp_dry_run_rowids"
+	// dryRunTrigger is the temporary trigger that fills dryRunRowids
+	dryRunTrigger = "mcp_dry_run_trigger"
+)
+
+// Preview describes what a statement would change. It is produced by executing the
+// statement and rolling it back.
+type Preview struct {
+	// RowsAffected is the number of rows the statement changed
+	RowsAffected int64
+	// Before holds a sample of the changed rows as they were before the statement, or nil
+	// when the statement does not remove or modify existing rows or they cannot be sampled
+	Before *Result
+	// After holds a sample of the changed rows as the statement left them, or nil when the
+	// statement does not insert or modify rows or they cannot be sampled
+	After *Result
+}
+
+// DryRunContext executes a statement on the writer connection, records its effect with a
+// sample of at most sample changed rows, and rolls it back. Samples are taken for INSERT,
+// UPDATE and DELETE statements on ordinary tables that have no RETURNING clause of their own.
+func (db *DB) DryRunContext(ctx context.Context, sample int, statement string, args ...interface{}) (*Preview, error) {
+	var preview *Preview
+	err := db.withWriter(ctx, func(conn *sql.Conn) (err error) {
+		preview, err = dryRun(ctx, conn, sample, statement, args...)
+		return err
+	})
+	return preview, err
+}
+
+// DryRunContext previews a statement inside the transaction, see DB.DryRunContext. The
+// transaction is left as it was.
+func (tx *Tx) DryRunContext(ctx context.Context, sample int, statement string, args ...interface{}) (*Preview, error) {
+	var preview *Preview
+	err := withAuthorizer(ctx, tx.indexes, tx.conn, func() (err error) {
+		preview, err = dryRun(ctx, tx.conn, sample, statement, args...)
+		return err
+	})
+	return preview, err
+}
+
+// dryRun previews statement under a savepoint on q and rolls it back
+func dryRun(ctx context.Context, q queryer, sample int, statement string, args ...interface{}) (preview *Preview, err error) {
+	if _, err := q.ExecContext(ctx, "SAVEPOINT "+dryRunSavepoint); err != nil {
+		return nil, fmt.Errorf("failed to start dry run: %w", err)
+	}
+	defer func() {
+		// The rollback must happen even when ctx has been cancelled
+		undoErr := undoSavepoint(context.WithoutCancel(ctx), q, dryRunSavepoint)
+		if undoErr != nil {
+			preview, err = nil, errors.Join(err, undoErr)
+		}
+	}()
+
+	preview, err = previewStatement(ctx, q, sample, statement, args...)
+	if err == nil {
+		maskPreview(ctx, preview, statement)
+	}
+	return preview, err
+}
+
+// maskPreview masks the sampled rows of a preview by the masks ctx carries. The samples hold
+// the columns of the statement's target table.
+func maskPreview(ctx context.Context, preview *Preview, statement string) {
+	masks := MasksFromContext(ctx)
+	if dml, ok := parseDML(statement); ok && masks != nil {
+		masks.applyTable(preview.Before, dml.Table)
+		masks.applyTable(preview.After, dml.Table)
+	}
+}
+
+// undoSavepoint rolls back and releases a savepoint. If that fails, the whole transaction
+// is rolled back so that the changes made under the savepoint can never be committed.
+func undoSavepoint(ctx context.Context, q queryer, name string) error {
+	_, err := q.ExecContext(ctx, "ROLLBACK TO "+name)
+	if err == nil {
+		_, err = q.ExecContext(ctx, "RELEASE "+name)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if _, rbErr := q.ExecContext(ctx, "ROLLBACK"); rbErr != nil {
+		return fmt.Errorf("failed to roll back savepoint: %w", errors.Join(err, rbErr))
+	}
+	return fmt.Errorf("failed to roll back savepoint, the transaction was rolled back: %w", err)
+}
+
+// previewStatement executes statement on q and collects the changed rows it can sample
+func previewStatement(ctx context.Context, q queryer, sample int, statement string, args ...interface{}) (*Preview, error) {
+	dml, ok := parseDML(statement)
+	var rowid bool
+	if ok && !dml.Returning {
+		rowid, ok = sampleTable(ctx, q, dml)
+	}
+	if !ok || dml.Returning {
+		n, err := executeCounted(ctx, q, statement, args...)
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{RowsAffected: n}, nil
+	}
+
+	switch dml.Verb {
+	case "DELETE":
+		// RETURNING reports deleted rows as they were
+		before, n, err := queryReturning(ctx, q, sample, statement+" RETURNING *", args...)
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{RowsAffected: n, Before: before}, nil
+	case "UPDATE":
+		if rowid {
+			return previewUpdate(ctx, q, sample, dml, statement, args...)
+		}
+	}
+
+	// INSERT, and UPDATE of a WITHOUT ROWID table whose previous values cannot be looked up
+	after, n, err := queryReturning(ctx, q, sample, statement+" RETURNING *", args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Preview{RowsAffected: n, After: after}, nil
+}
+
+// previewUpdate samples the rows changed by an UPDATE of a rowid table. The new values come
+// from RETURNING; the old values are read after rolling the update back, by the rowids the
+// rows had before it, which a temporary trigger records.
+func previewUpdate(
+	ctx context.Context, q queryer, sample int, dml sqlparse.DML, statement string, args ...interface{},
+) (*Preview, error) {
+	if err := recordRowids(ctx, q, dml); err != nil {
+		return nil, err
+	}
+	after, n, err := queryReturning(ctx, q, sample, statement+" RETURNING rowid, *", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split off the rowid column and find the rowid each row had before the update
+	keys := make([]interface{}, len(after.Rows))
+	for i, row := range after.Rows {
+		keys[i] = row[0]
+		after.Rows[i] = row[1:]
+	}
+	after.Columns = after.Columns[1:]
+	oldKeys, err := oldRowids(ctx, q, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := q.ExecContext(ctx, "ROLLBACK TO "+dryRunSavepoint); err != nil {
+		return nil, fmt.Errorf("failed to roll back dry run: %w", err)
+	}
+
+	before := &Result{Columns: after.Columns, Truncated: after.Truncated}
+	if len(oldKeys) > 0 {
+		query := fmt.Sprintf("SELECT rowid, * FROM %s WHERE rowid IN (?%s)",
+			qualifiedName(dml.Schema, dml.Table), strings.Repeat(", ?", len(oldKeys)-1))
+		old, err := queryLimit(ctx, q, 0, query, oldKeys...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the rows in the same order as the after sample
+		byKey := make(map[interface{}][]interface{}, len(old.Rows))
+		for _, row := range old.Rows {
+			byKey[row[0]] = row[1:]
+		}
+		for _, key := range oldKeys {
+			if row, ok := byKey[key]; ok {
+				before.Rows = append(before.Rows, row)
+			}
+		}
+		before.Columns = old.Columns[1:]
+	}
+
+	return &Preview{RowsAffected: n, Before: before, After: after}, nil
+}
+
+// recordRowids creates the temporary table and trigger that record the old and new rowids of
+// the rows an UPDATE of the target table changes. They are dropped when the dry run's
+// savepoint is rolled back.
+func recordRowids(ctx context.Context, q queryer, dml sqlparse.DML) error {
+	statements := []string{
+		fmt.Sprintf("CREATE TEMP TABLE %s (old_rowid INTEGER, new_rowid INTEGER)", dryRunRowids),
+		fmt.Sprintf("CREATE TEMP TRIGGER %s AFTER UPDATE ON %s BEGIN INSERT INTO %s VALUES (OLD.rowid, NEW.rowid); END",
+			dryRunTrigger, qualifiedName(dml.Schema, dml.Table), dryRunRowids),
+	}
+	return unchecked(q, func() error {
+		for _, statement := range statements {
+			if _, err := q.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to prepare dry run: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// oldRowids returns the rowids the rows with the given new rowids had before the update, in
+// the same order
+func oldRowids(ctx context.Context, q queryer, keys []interface{}) ([]interface{}, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	query := fmt.Sprintf("SELECT old_rowid, new_rowid FROM temp.%s WHERE new_rowid IN (?%s) ORDER BY rowid",
+		dryRunRowids, strings.Repeat(", ?", len(keys)-1))
+	var pairs *Result
+	err := unchecked(q, func() (err error) {
+		pairs, err = queryLimit(ctx, q, 0, query, keys...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changed rowids: %w", err)
+	}
+
+	byNew := make(map[interface{}]interface{}, len(pairs.Rows))
+	for _, row := range pairs.Rows {
+		if _, ok := byNew[row[1]]; !ok {
+			byNew[row[1]] = row[0]
+		}
+	}
+	old := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if rowid, ok := byNew[key]; ok {
+			old = append(old, rowid)
+		}
+	}
+	return old, nil
+}
+
+// sampleTable reports whether the changed rows of the target table can be sampled with
+// RETURNING, and whether the table has a rowid
+func sampleTable(ctx context.Context, q queryer, dml sqlparse.DML) (rowid, ok bool) {
+	// RETURNING is not supported on views and virtual tables. The statement itself is checked
+	// against the access rules when it runs.
+	var ref *tableRef
+	err := unchecked(q, func() (err error) {
+		ref, err = lookupTable(ctx, q, dml.Schema, dml.Table)
+		return err
+	})
+	if err != nil || ref.Type != "table" {
+		return false, false
+	}
+
+	return !ref.WithoutRowid, true
+}
+
+// queryReturning runs a statement with a RETURNING clause, keeping at most sample rows while
+// counting all of them
+func queryReturning(ctx context.Context, q queryer, sample int, statement string, args ...interface{}) (*Result, int64, error) {
+	rows, err := q.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("execution failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := resultColumns(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := &Result{Columns: columns}
+	var n int64
+	for rows.Next() {
+		n++
+		if len(result.Rows) == sample {
+			result.Truncated = true
+			continue
+		}
+
+		values, err := scanRow(rows, len(columns))
+		if err != nil {
+			return nil, 0, err
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("execution failed: %w", err)
+	}
+	return result, n, nil
+}
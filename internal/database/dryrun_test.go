@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunContext(t *testing.T) {
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	snapshot := func(t *testing.T) [][]interface{} {
+		t.Helper()
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT * FROM users ORDER BY id")
+		require.NoError(t, err)
+		return result.Rows
+	}
+	original := snapshot(t)
+
+	t.Run("update", func(t *testing.T) {
+		preview, err := db.DryRunContext(ctx, 10, "UPDATE users SET age = age + 1 WHERE name = ?", "Bob")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), preview.RowsAffected)
+		require.NotNil(t, preview.Before)
+		require.NotNil(t, preview.After)
+		require.Len(t, preview.Before.Rows, 1)
+		require.Len(t, preview.After.Rows, 1)
+		assert.Equal(t, "name", preview.Before.Columns[1].Name)
+		assert.Equal(t, int64(25), preview.Before.Rows[0][3])
+		assert.Equal(t, int64(26), preview.After.Rows[0][3])
+		assert.Equal(t, original, snapshot(t))
+	})
+
+	t.Run("update of the primary key", func(t *testing.T) {
+		preview, err := db.DryRunContext(ctx, 10, "UPDATE users SET id = id + 100, age = 99 WHERE id = 1")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), preview.RowsAffected)
+		require.Len(t, preview.Before.Rows, 1)
+		require.Len(t, preview.After.Rows, 1)
+		assert.Equal(t, []interface{}{int64(1), int64(30)}, []interface{}{preview.Before.Rows[0][0], preview.Before.Rows[0][3]})
+		assert.Equal(t, []interface{}{int64(101), int64(99)}, []interface{}{preview.After.Rows[0][0], preview.After.Rows[0][3]})
+		assert.Equal(t, original, snapshot(t))
+
+		// The temporary table and trigger are gone with the rollback
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT name FROM sqlite_temp_master")
+		require.NoError(t, err)
+		assert.Empty(t, result.Rows)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		preview, err := db.DryRunContext(ctx, 1, "DELETE FROM users")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), preview.RowsAffected)
+		require.NotNil(t, preview.Before)
+		assert.Len(t, preview.Before.Rows, 1)
+		assert.True(t, preview.Before.Truncated)
+		assert.Nil(t, preview.After)
+		assert.Equal(t, original, snapshot(t))
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		preview, err := db.DryRunContext(ctx, 10, "INSERT INTO users (name, email, age) VALUES ('Carol', 'carol@example.com', 41)")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), preview.RowsAffected)
+		assert.Nil(t, preview.Before)
+		require.NotNil(t, preview.After)
+		assert.Equal(t, "Carol", preview.After.Rows[0][1])
+		assert.Equal(t, original, snapshot(t))
+	})
+
+	t.Run("statement with its own RETURNING clause", func(t *testing.T) {
+		preview, err := db.DryRunContext(ctx, 10, "DELETE FROM users RETURNING id")
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), preview.RowsAffected)
+		assert.Nil(t, preview.Before)
+		assert.Nil(t, preview.After)
+		assert.Equal(t, original, snapshot(t))
+	})
+
+	t.Run("failing statement is rolled back", func(t *testing.T) {
+		_, err := db.DryRunContext(ctx, 10, "UPDATE users SET age = CASE WHEN name = 'Bob' THEN abs(-9223372036854775807 - 1) ELSE 0 END")
+		assert.Error(t, err)
+		assert.Equal(t, original, snapshot(t))
+	})
+
+	t.Run("inside a transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, "")
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		_, err = tx.ExecuteContext(ctx, "DELETE FROM users WHERE name = 'Alice'")
+		require.NoError(t, err)
+
+		preview, err := tx.DryRunContext(ctx, 10, "DELETE FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), preview.RowsAffected)
+
+		// The dry run leaves the transaction's own changes in place
+		result, err := tx.QueryLimitContext(ctx, 0, "SELECT name FROM users")
+		require.NoError(t, err)
+		require.Len(t, result.Rows, 1)
+		assert.Equal(t, "Bob", result.Rows[0][0])
+	})
+}
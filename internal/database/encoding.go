@@ -0,0 +1,132 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StorageClass is the SQLite storage class of a single value
+type StorageClass string
+
+// SQLite storage classes
+const (
+	StorageNull    StorageClass = "NULL"
+	StorageInteger StorageClass = "INTEGER"
+	StorageReal    StorageClass = "REAL"
+	StorageText    StorageClass = "TEXT"
+	StorageBlob    StorageClass = "BLOB"
+)
+
+// maxSafeInteger is the largest integer a float64-based JSON client can represent exactly (2^53 - 1)
+const maxSafeInteger = 1<<53 - 1
+
+// sqliteTimeFormat is the layout the driver uses when it stores a time.Time as TEXT
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// Blob is the JSON envelope for a BLOB value
+type Blob struct {
+	// Base64 holds the standard base64 encoding of the value
+	Base64 string `json:"$blob"`
+	// Size is the length of the value in bytes
+	Size int `json:"size"`
+}
+
+// Real is a REAL value. It always marshals with a fractional part or exponent so that
+// JSON clients can tell it apart from an INTEGER, and non-finite values marshal as strings.
+type Real float64
+
+// MarshalJSON implements json.Marshaler
+func (r Real) MarshalJSON() ([]byte, error) {
+	f := float64(r)
+	switch {
+	case math.IsNaN(f):
+		return json.Marshal("NaN")
+	case math.IsInf(f, 1):
+		return json.Marshal("Infinity")
+	case math.IsInf(f, -1):
+		return json.Marshal("-Infinity")
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return []byte(s), nil
+}
+
+// Encoding controls how result values are represented in JSON output
+type Encoding struct {
+	// BigIntsAsStrings emits integers outside the exactly representable float64 range as strings
+	BigIntsAsStrings bool
+}
+
+// StorageClassOf returns the SQLite storage class of a value scanned from the driver
+func StorageClassOf(v interface{}) StorageClass {
+	switch v.(type) {
+	case nil:
+		return StorageNull
+	case int64:
+		return StorageInteger
+	case float64:
+		return StorageReal
+	case []byte:
+		return StorageBlob
+	default:
+		// Strings, and TEXT values the driver parsed into time.Time
+		return StorageText
+	}
+}
+
+// Encode converts a value scanned from the driver into its JSON representation:
+// NULL as null, INTEGER as a number (or a string when too large and BigIntsAsStrings
+// is set), REAL as Real, TEXT as a string and BLOB as a Blob envelope.
+func (e Encoding) Encode(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int64:
+		if e.BigIntsAsStrings && (val > maxSafeInteger || val < -maxSafeInteger) {
+			return strconv.FormatInt(val, 10)
+		}
+		return val
+	case float64:
+		return Real(val)
+	case []byte:
+		return Blob{
+			Base64: base64.StdEncoding.EncodeToString(val),
+			Size:   len(val),
+		}
+	case time.Time:
+		return val.Format(sqliteTimeFormat)
+	default:
+		return val
+	}
+}
+
+// EncodeResult encodes every value of r in place and records the storage classes seen in each column
+func (e Encoding) EncodeResult(r *Result) {
+	seen := make([]map[StorageClass]bool, len(r.Columns))
+	for i := range seen {
+		seen[i] = make(map[StorageClass]bool)
+	}
+
+	for _, row := range r.Rows {
+		for i, v := range row {
+			seen[i][StorageClassOf(v)] = true
+			row[i] = e.Encode(v)
+		}
+	}
+
+	// Report classes in SQLite's sort order for mixed-type columns
+	order := []StorageClass{StorageNull, StorageInteger, StorageReal, StorageText, StorageBlob}
+	for i := range r.Columns {
+		r.Columns[i].StorageClasses = nil
+		for _, class := range order {
+			if seen[i][class] {
+				r.Columns[i].StorageClasses = append(r.Columns[i].StorageClasses, class)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealMarshalJSON(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1, "1.0"},
+		{-2, "-2.0"},
+		{9.99, "9.99"},
+		{1e21, "1e+21"},
+		{math.Inf(1), `"Infinity"`},
+		{math.Inf(-1), `"-Infinity"`},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(Real(tt.value))
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, string(data))
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("default encoding", func(t *testing.T) {
+		var e Encoding
+		assert.Nil(t, e.Encode(nil))
+		assert.Equal(t, int64(1<<60), e.Encode(int64(1<<60)))
+		assert.Equal(t, Real(1.5), e.Encode(1.5))
+		assert.Equal(t, "text", e.Encode("text"))
+		assert.Equal(t, Blob{Base64: "AP8=", Size: 2}, e.Encode([]byte{0x00, 0xff}))
+	})
+
+	t.Run("big integers as strings", func(t *testing.T) {
+		e := Encoding{BigIntsAsStrings: true}
+		assert.Equal(t, "9007199254740993", e.Encode(int64(9007199254740993)))
+		assert.Equal(t, "-9007199254740993", e.Encode(int64(-9007199254740993)))
+		assert.Equal(t, int64(9007199254740991), e.Encode(int64(9007199254740991)))
+	})
+}
+
+func TestEncodeResult(t *testing.T) {
+	db, err := New(InMemoryDB, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	result, err := db.QueryLimitContext(context.Background(), 0,
+		"VALUES (NULL, 1, 2.0, 'two', x'00ff'), (3, 9007199254740993, 4.5, 'four', NULL)")
+	require.NoError(t, err)
+
+	Encoding{BigIntsAsStrings: true}.EncodeResult(result)
+
+	assert.Equal(t, []interface{}{nil, int64(1), Real(2), "two", Blob{Base64: "AP8=", Size: 2}}, result.Rows[0])
+	assert.Equal(t, []interface{}{int64(3), "9007199254740993", Real(4.5), "four", nil}, result.Rows[1])
+
+	assert.Equal(t, []StorageClass{StorageNull, StorageInteger}, result.Columns[0].StorageClasses)
+	assert.Equal(t, []StorageClass{StorageInteger}, result.Columns[1].StorageClasses)
+	assert.Equal(t, []StorageClass{StorageReal}, result.Columns[2].StorageClasses)
+	assert.Equal(t, []StorageClass{StorageText}, result.Columns[3].StorageClasses)
+	assert.Equal(t, []StorageClass{StorageNull, StorageBlob}, result.Columns[4].StorageClasses)
+
+	data, err := json.Marshal(result.Rows[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[null, 1, 2.0, "two", {"$blob": "AP8=", "size": 2}]`, string(data))
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTableNotFound is returned when a table or view named by the caller does not exist
+var ErrTableNotFound = errors.New("table not found")
+
+// tableRef identifies a table or view that exists in one of the connection's databases
+type tableRef struct {
+	// Schema is the database the table belongs to, e.g. "main" or "temp"
+	Schema string
+	// Name is the table name as stored in the schema table
+	Name string
+	// Type is "table", "view", "virtual" or "shadow"
+	Type         string
+	WithoutRowid bool
+	Strict       bool
+}
+
+// lookupTable resolves a table or view name, optionally qualified by schema, against the
+// schema tables of the connection's databases. An unqualified name resolves to temp before
+// main before attached databases, as it does in SQL. Without a schema, a name of the form
+// schema.table that matches no table as a whole is looked up in that schema. The returned
+// error wraps ErrTableNotFound when there is no such table.
+func lookupTable(ctx context.Context, q queryer, schema, name string) (*tableRef, error) {
+	if err := validateIdentifier(name); err != nil {
+		return nil, err
+	}
+
+	// pragma_table_list reports the tables and views recorded in each database's sqlite_schema
+	query := "SELECT schema, name, type, wr, strict FROM pragma_table_list WHERE name = ? COLLATE NOCASE"
+	args := []interface{}{name}
+	if schema != "" {
+		if err := validateIdentifier(schema); err != nil {
+			return nil, err
+		}
+		query += " AND schema = ? COLLATE NOCASE"
+		args = append(args, schema)
+	}
+	query += " ORDER BY schema = 'temp' DESC, schema = 'main' DESC"
+
+	info, err := queryLimit(ctx, q, 1, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up table: %w", err)
+	}
+	if len(info.Rows) == 0 {
+		if prefix, rest, ok := strings.Cut(name, "."); ok && schema == "" && prefix != "" && rest != "" {
+			return lookupTable(ctx, q, prefix, rest)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, qualifiedName(schema, name))
+	}
+
+	row := info.Rows[0]
+	return &tableRef{
+		Schema:       asString(row[0]),
+		Name:         asString(row[1]),
+		Type:         asString(row[2]),
+		WithoutRowid: asInt(row[3]) != 0,
+		Strict:       asInt(row[4]) != 0,
+	}, nil
+}
+
+// ResolveTable returns the name of the table or view name refers to; name may be qualified
+// with the schema of an attached database. The returned error wraps ErrTableNotFound when there
+// is none.
+func (db *DB) ResolveTable(ctx context.Context, name string) (string, error) {
+	ref, err := lookupTable(catalogContext(ctx), db.readers, "", name)
+	if err != nil {
+		return "", err
+	}
+	return ref.Name, nil
+}
+
+// schemaTable returns the quoted name of the table holding a database's definitions
+func schemaTable(schema string) string {
+	if schema == "temp" {
+		return "sqlite_temp_schema"
+	}
+	return quoteIdentifier(schema) + ".sqlite_schema"
+}
+
+// validateIdentifier rejects names that cannot be used as SQL identifiers even when quoted
+func validateIdentifier(name string) error {
+	switch {
+	case name == "":
+		return errors.New("identifier must not be empty")
+	case strings.ContainsRune(name, 0):
+		return fmt.Errorf("identifier %q must not contain NUL characters", name)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("identifier %q is not valid UTF-8", name)
+	}
+	return nil
+}
+
+// qualifiedName quotes a table name and its optional schema for use in SQL text
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return quoteIdentifier(name)
+	}
+	return quoteIdentifier(schema) + "." + quoteIdentifier(name)
+}
+
+// quoteIdentifier quotes an SQL identifier, doubling any embedded quotes. Every table, column,
+// index or schema name that is interpolated into SQL text must go through it.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, `"users"`, quoteIdentifier("users"))
+	assert.Equal(t, `"order items"`, quoteIdentifier("order items"))
+	assert.Equal(t, `"say ""hi"""`, quoteIdentifier(`say "hi"`))
+	assert.Equal(t, `"main"."users"`, qualifiedName("main", "users"))
+	assert.Equal(t, `"users"`, qualifiedName("", "users"))
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	assert.NoError(t, validateIdentifier("users"))
+	assert.NoError(t, validateIdentifier(`odd "name"; DROP TABLE users`))
+	assert.Error(t, validateIdentifier(""))
+	assert.Error(t, validateIdentifier("bad\x00name"))
+	assert.Error(t, validateIdentifier("bad\xffname"))
+}
+
+func TestUnusualTableNames(t *testing.T) {
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE "order items" ("the id" INTEGER PRIMARY KEY, "select" TEXT UNIQUE)`,
+		`CREATE TABLE "say ""hi""" (x INTEGER)`,
+		`CREATE INDEX "idx ""quoted""" ON "say ""hi""" (x)`,
+		`CREATE TRIGGER "order trigger" AFTER INSERT ON "order items" BEGIN SELECT 1; END`,
+	} {
+		_, err := db.ExecuteContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+
+	t.Run("spaces and keywords", func(t *testing.T) {
+		schema, err := db.GetTableSchema(ctx, "order items")
+		require.NoError(t, err)
+		assert.Equal(t, "order items", schema.Name)
+		assert.Equal(t, []string{"the id"}, schema.PrimaryKey)
+		require.Len(t, schema.Columns, 2)
+		assert.Equal(t, "select", schema.Columns[1].Name)
+		require.Len(t, schema.Indexes, 1)
+	})
+
+	t.Run("embedded quotes", func(t *testing.T) {
+		schema, err := db.GetTableSchema(ctx, `say "hi"`)
+		require.NoError(t, err)
+		require.Len(t, schema.Indexes, 1)
+		assert.Equal(t, `idx "quoted"`, schema.Indexes[0].Name)
+		assert.Equal(t, "x", schema.Indexes[0].Columns[0].Name)
+
+		indexes, err := db.GetIndexes(ctx, `say "hi"`)
+		require.NoError(t, err)
+		assert.Len(t, indexes, 1)
+	})
+
+	t.Run("case insensitive", func(t *testing.T) {
+		schema, err := db.GetTableSchema(ctx, "ORDER ITEMS")
+		require.NoError(t, err)
+		assert.Equal(t, "order items", schema.Name)
+
+		triggers, err := db.GetTriggers(ctx, "Order Items")
+		require.NoError(t, err)
+		require.Len(t, triggers, 1)
+		assert.Equal(t, "order trigger", triggers[0].Name)
+	})
+
+	t.Run("injection attempts", func(t *testing.T) {
+		for _, name := range []string{
+			"users); DROP TABLE users; --",
+			`users" ; DROP TABLE users; --`,
+			"users' OR '1'='1",
+		} {
+			_, err := db.GetTableSchema(ctx, name)
+			assert.ErrorIs(t, err, ErrTableNotFound, name)
+		}
+
+		_, err := db.GetTableSchema(ctx, "users")
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		_, err := db.GetIndexes(ctx, "missing")
+		assert.ErrorIs(t, err, ErrTableNotFound)
+
+		_, err = db.GetTriggers(ctx, "missing")
+		assert.ErrorIs(t, err, ErrTableNotFound)
+
+		_, err = db.GetTableSchema(ctx, "")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrTableNotFound)
+	})
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Masking methods
+const (
+	// MaskRedact replaces every value with MaskRedacted
+	MaskRedact = "redact"
+	// MaskHash replaces values with the hex SHA-256 of their text and the rule's salt, so
+	// equal values can still be matched up
+	MaskHash = "hash"
+	// MaskLast4 keeps the last four characters and replaces the others with *
+	MaskLast4 = "last4"
+	// MaskEmailDomain keeps the domain of an email address and redacts the rest
+	MaskEmailDomain = "email_domain"
+	// MaskRegex replaces the matches of the rule's pattern with its replacement
+	MaskRegex = "regex"
+)
+
+// MaskRedacted is the value redacted values are replaced with
+const MaskRedacted = "[REDACTED]"
+
+// MaskMethods returns the masking methods a rule may use
+func MaskMethods() []string {
+	return []string{MaskRedact, MaskHash, MaskLast4, MaskEmailDomain, MaskRegex}
+}
+
+// MaskRule masks the values of the result columns Column matches. Column is a table.column
+// pattern, matched against the table column a result column is read from, or a column name
+// pattern, which is also matched against the result column's name or alias.
+type MaskRule struct {
+	Column string `yaml:"column"`
+	Method string `yaml:"method"`
+	// Pattern and Replacement are the regular expression and replacement of MaskRegex; the
+	// replacement may refer to submatches as $1
+	Pattern     string `yaml:"pattern,omitempty"`
+	Replacement string `yaml:"replacement,omitempty"`
+	// Salt is hashed along with the values of MaskHash
+	Salt string `yaml:"salt,omitempty"`
+}
+
+// maskRule is a parsed MaskRule
+type maskRule struct {
+	MaskRule
+	// table is the table pattern of a table.column rule, or nil for a column name rule
+	table  *accessPattern
+	column string
+	re     *regexp.Regexp
+}
+
+// Masks masks the values of query results. Queries run with Masks in their context (see
+// WithMasks) are compiled once more to find the table column each result column is read
+// from, so that columns are masked through aliases, subqueries and views. Columns computed
+// by expressions have no such column: they are masked by the first rule that matches a
+// table column the query reads, so a masked column is never returned verbatim through an
+// expression. So are the columns of a query that reads a masked column and is compound,
+// uses a view or reads a virtual table, unless their own column is masked. A nil *Masks
+// masks nothing.
+type Masks struct {
+	rules []maskRule
+}
+
+// NewMasks creates masks from rules. When several rules match a column, the first one applies.
+func NewMasks(rules []MaskRule) (*Masks, error) {
+	m := &Masks{}
+	for _, rule := range rules {
+		r, err := parseMaskRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, r)
+	}
+	return m, nil
+}
+
+// parseMaskRule checks a rule and parses its column pattern and regular expression
+func parseMaskRule(rule MaskRule) (maskRule, error) {
+	r := maskRule{MaskRule: rule}
+	switch rule.Method {
+	case MaskRedact, MaskHash, MaskLast4, MaskEmailDomain:
+	case MaskRegex:
+		if rule.Pattern == "" {
+			return r, errors.New("the regex method requires a pattern")
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return r, fmt.Errorf("invalid pattern %q: %w", rule.Pattern, err)
+		}
+		r.re = re
+	default:
+		return r, fmt.Errorf("unknown masking method %q: must be one of %s", rule.Method, strings.Join(MaskMethods(), ", "))
+	}
+
+	if strings.Contains(rule.Column, ".") {
+		p, err := parseAccessPattern(rule.Column)
+		if err != nil {
+			return r, err
+		}
+		r.table, r.column = &p, p.column
+		return r, nil
+	}
+	r.column = strings.ToLower(strings.TrimSpace(rule.Column))
+	if _, err := path.Match(r.column, ""); err != nil || r.column == "" {
+		return r, fmt.Errorf("invalid column pattern %q: use column or table.column", rule.Column)
+	}
+	return r, nil
+}
+
+// matches reports whether the rule masks a result column named name that is read from
+// column of table, or is computed when table is ""
+func (r *maskRule) matches(table, column, name string) bool {
+	if r.table != nil {
+		return table != "" && r.table.matchesColumn(table, column)
+	}
+	for _, c := range []string{column, name} {
+		if ok, _ := path.Match(r.column, strings.ToLower(c)); ok && c != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// mask returns the masked form of a value scanned from the driver. NULL stays NULL.
+func (r *maskRule) mask(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	s := maskText(v)
+
+	switch r.Method {
+	case MaskHash:
+		sum := sha256.Sum256([]byte(r.Salt + s))
+		return hex.EncodeToString(sum[:])
+	case MaskLast4:
+		n := utf8.RuneCountInString(s)
+		if n <= 4 {
+			return strings.Repeat("*", n)
+		}
+		runes := []rune(s)
+		return strings.Repeat("*", n-4) + string(runes[n-4:])
+	case MaskEmailDomain:
+		if i := strings.LastIndex(s, "@"); i >= 0 {
+			return MaskRedacted + s[i:]
+		}
+		return MaskRedacted
+	case MaskRegex:
+		return r.re.ReplaceAllString(s, r.Replacement)
+	default:
+		return MaskRedacted
+	}
+}
+
+// maskText returns the text a value is masked as
+func maskText(v interface{}) string {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(sqliteTimeFormat)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// rule returns the rule that masks a result column, or nil
+func (m *Masks) rule(table, column, name string) *maskRule {
+	if m == nil {
+		return nil
+	}
+	i := slices.IndexFunc(m.rules, func(r maskRule) bool { return r.matches(table, column, name) })
+	if i < 0 {
+		return nil
+	}
+	return &m.rules[i]
+}
+
+// computedRule returns the rule that masks the columns a statement computes: the first rule
+// that matches a table column the statement reads, or nil
+func (m *Masks) computedRule(accesses []tableAccess) *maskRule {
+	for _, access := range accesses {
+		if access.write || access.column == "" {
+			continue
+		}
+		if rule := m.rule(access.table, access.column, ""); rule != nil {
+			return rule
+		}
+	}
+	return nil
+}
+
+// apply masks the values of r, the result of the compiled statement c, and marks the masked
+// columns
+func (m *Masks) apply(r *Result, c *compiled) {
+	if m == nil || r == nil {
+		return
+	}
+	computed := m.computedRule(c.accesses)
+	for i := range r.Columns {
+		var source columnSource
+		if i < len(c.sources) {
+			source = c.sources[i]
+		}
+		rule := m.rule(source.table, source.column, r.Columns[i].Name)
+		if rule == nil && (source.table == "" || source.indirect) {
+			rule = computed
+		}
+		if rule == nil {
+			continue
+		}
+		r.Columns[i].Masked = rule.Method
+		for _, row := range r.Rows {
+			row[i] = rule.mask(row[i])
+		}
+	}
+}
+
+// applyTable masks the values of r, whose columns are the columns of table
+func (m *Masks) applyTable(r *Result, table string) {
+	if m == nil || r == nil {
+		return
+	}
+	c := &compiled{sources: make([]columnSource, len(r.Columns))}
+	for i, column := range r.Columns {
+		c.sources[i] = columnSource{table: table, column: column.Name}
+	}
+	m.apply(r, c)
+}
+
+// masksKey is the context key for masks
+type masksKey struct{}
+
+// WithMasks returns a context whose query results are masked by m
+func WithMasks(ctx context.Context, m *Masks) context.Context {
+	if m == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, masksKey{}, m)
+}
+
+// MasksFromContext returns the masks query results run with ctx are masked by, or nil
+func MasksFromContext(ctx context.Context) *Masks {
+	m, _ := ctx.Value(masksKey{}).(*Masks)
+	return m
+}
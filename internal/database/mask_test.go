@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMasks(t *testing.T) {
+	for name, tc := range map[string]struct {
+		rule MaskRule
+		err  string
+	}{
+		"table column": {rule: MaskRule{Column: "users.email", Method: MaskEmailDomain}},
+		"column name":  {rule: MaskRule{Column: "*ssn*", Method: MaskLast4}},
+		"regex":        {rule: MaskRule{Column: "notes", Method: MaskRegex, Pattern: `\d+`, Replacement: "#"}},
+		"unknown":      {rule: MaskRule{Column: "email", Method: "scramble"}, err: `unknown masking method "scramble"`},
+		"no pattern":   {rule: MaskRule{Column: "notes", Method: MaskRegex}, err: "requires a pattern"},
+		"bad pattern":  {rule: MaskRule{Column: "notes", Method: MaskRegex, Pattern: "("}, err: "invalid pattern"},
+		"no column":    {rule: MaskRule{Method: MaskRedact}, err: "invalid column pattern"},
+		"bad column":   {rule: MaskRule{Column: "users.", Method: MaskRedact}, err: "invalid table pattern"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewMasks([]MaskRule{tc.rule})
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestMaskValues(t *testing.T) {
+	for _, tc := range []struct {
+		rule MaskRule
+		in   interface{}
+		want interface{}
+	}{
+		{MaskRule{Method: MaskRedact}, "secret", MaskRedacted},
+		{MaskRule{Method: MaskRedact}, nil, nil},
+		{MaskRule{Method: MaskLast4}, "4111111111111111", "************1111"},
+		{MaskRule{Method: MaskLast4}, int64(123), "***"},
+		{MaskRule{Method: MaskEmailDomain}, "alice@example.com", MaskRedacted + "@example.com"},
+		{MaskRule{Method: MaskEmailDomain}, "not an email", MaskRedacted},
+		{MaskRule{Method: MaskHash}, "alice", "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90"},
+		{MaskRule{Method: MaskRegex, Pattern: `(\d{3})-\d{2}-\d{4}`, Replacement: "$1-**-****"}, "ssn 123-45-6789", "ssn 123-**-****"},
+	} {
+		tc.rule.Column = "c"
+		rule, err := parseMaskRule(tc.rule)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, rule.mask(tc.in), "%s %v", tc.rule.Method, tc.in)
+	}
+
+	salted, err := parseMaskRule(MaskRule{Column: "c", Method: MaskHash, Salt: "pepper"})
+	require.NoError(t, err)
+	assert.NotEqual(t, "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90", salted.mask("alice"))
+}
+
+func TestMasks(t *testing.T) {
+	db, err := New(InMemoryDB, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT, ssn TEXT)",
+		"CREATE VIEW contacts AS SELECT name, email AS address FROM users",
+		"INSERT INTO users (name, email, ssn) VALUES ('alice', 'alice@example.com', '123-45-6789')",
+	} {
+		_, err := db.Execute(stmt)
+		require.NoError(t, err)
+	}
+
+	masks, err := NewMasks([]MaskRule{
+		{Column: "users.email", Method: MaskEmailDomain},
+		{Column: "*ssn*", Method: MaskLast4},
+	})
+	require.NoError(t, err)
+	ctx := WithMasks(context.Background(), masks)
+
+	t.Run("queries", func(t *testing.T) {
+		for query, want := range map[string][]interface{}{
+			"SELECT name, email, ssn FROM users":                      {"alice", "[REDACTED]@example.com", "*******6789"},
+			"SELECT email AS contact FROM users":                      {"[REDACTED]@example.com"},
+			"SELECT address FROM contacts":                            {"[REDACTED]@example.com"},
+			"SELECT e FROM (SELECT email AS e FROM users)":            {"[REDACTED]@example.com"},
+			"WITH c AS (SELECT email FROM users) SELECT email FROM c": {"[REDACTED]@example.com"},
+			"SELECT u.email FROM users u JOIN users v ON v.id = u.id": {"[REDACTED]@example.com"},
+			"SELECT upper(ssn) AS ssn FROM users":                     {"*******6789"},
+			"SELECT name FROM users WHERE email LIKE '%@example.com'": {"alice"},
+			"SELECT lower(email) FROM users":                          {"[REDACTED]@example.com"},
+			"SELECT email || '' AS x FROM users":                      {"[REDACTED]@example.com"},
+			"SELECT upper(address) FROM contacts":                     {"[REDACTED]@EXAMPLE.COM"},
+			"SELECT (SELECT lower(email) FROM users) AS e":            {"[REDACTED]@example.com"},
+			"SELECT count(*) AS n FROM users WHERE name = 'alice'":    {int64(1)},
+			// A computed column is masked whenever the query reads a masked column
+			"SELECT count(*) AS n FROM users WHERE ssn IS NOT NULL": {"*"},
+		} {
+			result, err := db.QueryLimitContext(ctx, 0, query)
+			require.NoError(t, err, query)
+			require.Len(t, result.Rows, 1, query)
+			assert.Equal(t, want, result.Rows[0], query)
+		}
+	})
+
+	t.Run("compound queries and table-valued functions", func(t *testing.T) {
+		_, err := db.Execute("CREATE VIEW people AS SELECT name FROM users UNION ALL SELECT ssn FROM users")
+		require.NoError(t, err)
+
+		for _, query := range []string{
+			"SELECT name FROM users UNION ALL SELECT ssn FROM users",
+			"SELECT name FROM users UNION SELECT ssn FROM users",
+			"SELECT name FROM users WHERE 0 UNION SELECT ssn FROM users",
+			"SELECT x FROM (SELECT name AS x FROM users UNION ALL SELECT email FROM users)",
+			"SELECT name FROM people",
+			"SELECT value FROM json_each((SELECT json_group_array(ssn) FROM users))",
+			"SELECT u.name, j.value FROM users u, json_each(json_array(u.email)) j",
+		} {
+			result, err := db.QueryLimitContext(ctx, 0, query)
+			require.NoError(t, err, query)
+			require.NotEmpty(t, result.Rows, query)
+			for _, row := range result.Rows {
+				for _, v := range row {
+					assert.NotContains(t, []interface{}{"123-45-6789", "alice@example.com"}, v, query)
+				}
+			}
+		}
+	})
+
+	t.Run("marks masked columns", func(t *testing.T) {
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT name, email FROM users")
+		require.NoError(t, err)
+		assert.Empty(t, result.Columns[0].Masked)
+		assert.Equal(t, MaskEmailDomain, result.Columns[1].Masked)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(context.Background(), "")
+		require.NoError(t, err)
+		defer tx.Rollback(context.Background())
+
+		result, err := tx.QueryLimitContext(ctx, 0, "SELECT email FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, "[REDACTED]@example.com", result.Rows[0][0])
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		preview, err := db.DryRunContext(ctx, 10, "UPDATE users SET email = 'a@example.org' WHERE id = 1")
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{int64(1), "alice", "[REDACTED]@example.com", "*******6789"}, preview.Before.Rows[0])
+		assert.Equal(t, []interface{}{int64(1), "alice", "[REDACTED]@example.org", "*******6789"}, preview.After.Rows[0])
+	})
+
+	t.Run("unmasked calls", func(t *testing.T) {
+		result, err := db.QueryLimitContext(context.Background(), 0, "SELECT email FROM users")
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", result.Rows[0][0])
+	})
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"modernc.org/libc"
+	"modernc.org/libc/sys/types"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// memoryPrefix starts the names of the in-memory databases the process opens
+const memoryPrefix = "/sqlite-mcp-memory-"
+
+// memoryVFS registers the VFS that in-memory databases are opened with, see registerMemoryVFS
+var memoryVFS = sync.OnceValues(registerMemoryVFS)
+
+// memoryDatabases numbers the in-memory databases opened by the process
+var memoryDatabases atomic.Int64
+
+// openMemory opens the pools of an in-memory database. Each one is a named database of the
+// memdb VFS, which every connection of the process that opens the name shares, so that the
+// readers see what the writer writes. It lasts until its last connection is closed, which
+// the writer pool keeps open until Close. Shared-cache memory databases are not used: their
+// readers wait on table locks without a timeout while a transaction is open. The files the
+// connections attach are opened as files, see registerMemoryVFS.
+func (db *DB) openMemory(drv *sqlite.Driver, o *options) error {
+	vfs, err := memoryVFS()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("file:%s%d?vfs=%s", memoryPrefix, memoryDatabases.Add(1), vfs)
+
+	writer, err := openPool(drv, name+"&"+o.pragmas.query(false, true))
+	if err != nil {
+		return err
+	}
+	writer.SetMaxOpenConns(1)
+	writer.SetMaxIdleConns(1)
+	if err := runScripts(context.Background(), writer, o.initScripts); err != nil {
+		_ = writer.Close()
+		return err
+	}
+
+	readers, err := openPool(drv, name+"&mode=ro&"+o.pragmas.query(true, true))
+	if err != nil {
+		_ = writer.Close()
+		return err
+	}
+	readers.SetMaxOpenConns(o.maxReaders)
+	readers.SetMaxIdleConns(o.idleReaders)
+	db.readers, db.writer, db.maxReaders = readers, writer, o.maxReaders
+
+	// A read-only database is only written by its scripts
+	if db.readOnly {
+		db.keep, db.writer = writer, readers
+	}
+	return nil
+}
+
+// Offsets of the fields of sqlite3_vfs that the VFS of registerMemoryVFS sets
+const (
+	vfsSzOsFile     = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FszOsFile)      // #nosec G103 -- a field of a C struct
+	vfsNext         = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FpNext)         // #nosec G103 -- a field of a C struct
+	vfsName         = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FzName)         // #nosec G103 -- a field of a C struct
+	vfsOpen         = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FxOpen)         // #nosec G103 -- a field of a C struct
+	vfsDelete       = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FxDelete)       // #nosec G103 -- a field of a C struct
+	vfsAccess       = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FxAccess)       // #nosec G103 -- a field of a C struct
+	vfsFullPathname = unsafe.Offsetof(sqlite3.Tsqlite3_vfs{}.FxFullPathname) // #nosec G103 -- a field of a C struct
+)
+
+// memoryVFSFiles and memoryVFSMemdb are the VFSs the VFS of registerMemoryVFS hands files to
+var memoryVFSFiles, memoryVFSMemdb uintptr
+
+// registerMemoryVFS registers a VFS that opens the in-memory databases of the process with
+// memdb and every other file with the default VFS, and returns its name. A connection opens
+// the files it attaches, and the file VACUUM INTO writes, with the VFS of its main database,
+// so with memdb alone ATTACH and VACUUM INTO would create in-memory databases instead of files.
+func registerMemoryVFS() (string, error) {
+	const name = "sqlite-mcp-memory"
+
+	tls := libc.NewTLS()
+	defer tls.Close()
+	if rc := sqlite3.Xsqlite3_initialize(tls); rc != sqlite3.SQLITE_OK {
+		return "", fmt.Errorf("failed to initialize SQLite: error code %d", rc)
+	}
+	zMemdb, err := libc.CString("memdb")
+	if err != nil {
+		return "", err
+	}
+	defer libc.Xfree(tls, zMemdb)
+	files, memdb := sqlite3.Xsqlite3_vfs_find(tls, 0), sqlite3.Xsqlite3_vfs_find(tls, zMemdb)
+	if files == 0 || memdb == 0 {
+		return "", errors.New("the memdb VFS is not available")
+	}
+	memoryVFSFiles, memoryVFSMemdb = files, memdb
+
+	// The VFS is a copy of the default one with the methods that take a file name replaced.
+	// It is registered for the life of the process, so its memory is never freed.
+	size := unsafe.Sizeof(sqlite3.Tsqlite3_vfs{}) // #nosec G103 -- the size of a C struct
+	vfs := libc.Xcalloc(tls, 1, types.Size_t(size))
+	zName, err := libc.CString(name)
+	if vfs == 0 || err != nil {
+		return "", errors.New("failed to allocate the VFS")
+	}
+	for off := uintptr(0); off < size; off += unsafe.Sizeof(off) { // #nosec G103 -- the size of a C pointer
+		libc.AtomicStorePUintptr(vfs+off, libc.AtomicLoadPUintptr(files+off))
+	}
+	libc.AtomicStorePInt32(vfs+vfsSzOsFile, max(libc.AtomicLoadPInt32(files+vfsSzOsFile), libc.AtomicLoadPInt32(memdb+vfsSzOsFile)))
+	for offset, value := range map[uintptr]uintptr{
+		vfsNext:         0,
+		vfsName:         zName,
+		vfsOpen:         funcPointer(memoryVFSOpen),
+		vfsDelete:       funcPointer(memoryVFSDelete),
+		vfsAccess:       funcPointer(memoryVFSAccess),
+		vfsFullPathname: funcPointer(memoryVFSFullPathname),
+	} {
+		libc.AtomicStorePUintptr(vfs+offset, value)
+	}
+
+	if rc := sqlite3.Xsqlite3_vfs_register(tls, vfs, 0); rc != sqlite3.SQLITE_OK {
+		return "", fmt.Errorf("failed to register the %s VFS: error code %d", name, rc)
+	}
+	return name, nil
+}
+
+// memoryVFSMethod returns the VFS that handles the file zName and its method at offset in
+// sqlite3_vfs: memdb for the in-memory databases, the default VFS for every other file
+func memoryVFSMethod(zName, offset uintptr) (vfs, method uintptr) {
+	vfs = memoryVFSFiles
+	if zName != 0 && strings.HasPrefix(libc.GoString(zName), memoryPrefix) {
+		vfs = memoryVFSMemdb
+	}
+	return vfs, libc.AtomicLoadPUintptr(vfs + offset)
+}
+
+// memoryVFSOpen implements xOpen of the VFS of registerMemoryVFS
+func memoryVFSOpen(tls *libc.TLS, _, zName, pFile uintptr, flags int32, pOutFlags uintptr) int32 {
+	vfs, xOpen := memoryVFSMethod(zName, vfsOpen)
+	return cFunc[func(*libc.TLS, uintptr, uintptr, uintptr, int32, uintptr) int32](xOpen)(
+		tls, vfs, zName, pFile, flags, pOutFlags)
+}
+
+// memoryVFSDelete implements xDelete of the VFS of registerMemoryVFS
+func memoryVFSDelete(tls *libc.TLS, _, zName uintptr, syncDir int32) int32 {
+	vfs, xDelete := memoryVFSMethod(zName, vfsDelete)
+	if xDelete == 0 {
+		// memdb has no files to delete
+		return sqlite3.SQLITE_OK
+	}
+	return cFunc[func(*libc.TLS, uintptr, uintptr, int32) int32](xDelete)(tls, vfs, zName, syncDir)
+}
+
+// memoryVFSAccess implements xAccess of the VFS of registerMemoryVFS
+func memoryVFSAccess(tls *libc.TLS, _, zName uintptr, flags int32, pResOut uintptr) int32 {
+	vfs, xAccess := memoryVFSMethod(zName, vfsAccess)
+	return cFunc[func(*libc.TLS, uintptr, uintptr, int32, uintptr) int32](xAccess)(tls, vfs, zName, flags, pResOut)
+}
+
+// memoryVFSFullPathname implements xFullPathname of the VFS of registerMemoryVFS
+func memoryVFSFullPathname(tls *libc.TLS, _, zName uintptr, nOut int32, zOut uintptr) int32 {
+	vfs, xFullPathname := memoryVFSMethod(zName, vfsFullPathname)
+	return cFunc[func(*libc.TLS, uintptr, uintptr, int32, uintptr) int32](xFullPathname)(tls, vfs, zName, nOut, zOut)
+}
+
+// cFunc returns the Go function a function pointer of the transpiled SQLite library points
+// to, the reverse of funcPointer
+func cFunc[T any](p uintptr) T {
+	return *(*T)(unsafe.Pointer(&struct{ p uintptr }{p})) // #nosec G103 -- see funcPointer
+}
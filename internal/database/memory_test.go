@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDatabase(t *testing.T) {
+	ctx := context.Background()
+	pragmas, err := ProfilePragmas(DefaultDurability)
+	require.NoError(t, err)
+	pragmas.BusyTimeout = 50 * time.Millisecond
+	db, err := New(InMemoryDB, false, WithPragmas(DefaultDurability, pragmas), WithReaders(3, 3))
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("readers see writes", func(t *testing.T) {
+		_, err := db.ExecuteContext(ctx, "CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)")
+		require.NoError(t, err)
+		_, err = db.ExecuteContext(ctx, "INSERT INTO notes (body) VALUES ('hello')")
+		require.NoError(t, err)
+
+		// Pin several readers so the query runs on more than one connection
+		for range 3 {
+			conn, err := db.readers.Conn(ctx)
+			require.NoError(t, err)
+			var count int
+			require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM notes").Scan(&count))
+			assert.Equal(t, 1, count)
+			defer conn.Close()
+		}
+	})
+
+	t.Run("readers do not wait forever for a transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, TxImmediate)
+		require.NoError(t, err)
+		defer func() { _ = tx.Rollback(ctx) }()
+		_, err = tx.ExecuteContext(ctx, "INSERT INTO notes (body) VALUES ('pending')")
+		require.NoError(t, err)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		_, err = db.QueryLimitContext(timeout, 0, "SELECT count(*) FROM notes")
+		assert.ErrorContains(t, err, "locked")
+	})
+
+	t.Run("attached files and VACUUM INTO", func(t *testing.T) {
+		dir := t.TempDir()
+		attached, copied := filepath.Join(dir, "attached.db"), filepath.Join(dir, "copy.db")
+
+		// File names without a VFS open files, not more in-memory databases
+		_, err := db.ExecuteContext(ctx, "ATTACH DATABASE ? AS disk", attached)
+		require.NoError(t, err)
+		_, err = db.ExecuteContext(ctx, "CREATE TABLE disk.notes AS SELECT * FROM notes")
+		require.NoError(t, err)
+		_, err = db.ExecuteContext(ctx, "DETACH DATABASE disk")
+		require.NoError(t, err)
+		_, err = db.ExecuteContext(ctx, "VACUUM INTO ?", copied)
+		require.NoError(t, err)
+
+		for _, path := range []string{attached, copied} {
+			file, err := New(path, false)
+			require.NoError(t, err)
+			result, err := file.QueryLimitContext(ctx, 0, "SELECT body FROM notes")
+			require.NoError(t, err)
+			assert.Equal(t, [][]interface{}{{"hello"}}, result.Rows, path)
+			require.NoError(t, file.Close())
+		}
+	})
+
+	t.Run("separate databases", func(t *testing.T) {
+		other, err := New(InMemoryDB, false)
+		require.NoError(t, err)
+		defer other.Close()
+
+		_, err = other.QueryLimitContext(ctx, 0, "SELECT * FROM notes")
+		assert.ErrorContains(t, err, "no such table")
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "memory", d.JournalMode)
+		assert.Equal(t, 3, d.MaxReaders)
+	})
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unsafe"
+
+	"modernc.org/libc"
+	"modernc.org/libc/sys/types"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+// columnSource is the table column a result column is read from. Both are "" for a column
+// computed by an expression.
+type columnSource struct {
+	schema string
+	table  string
+	column string
+	// indirect is set when the result column may hold values other than those of the table
+	// column, see markIndirect
+	indirect bool
+}
+
+// compiled describes a statement as SQLite compiles it
+type compiled struct {
+	// sources holds the source of each result column, following aliases, subqueries and views
+	// to the underlying table
+	sources []columnSource
+	// accesses holds the table columns the statement, and the views and triggers it uses,
+	// read and write
+	accesses []tableAccess
+}
+
+// compile compiles query on q without running it. The authorizer installed on q, if any,
+// checks the statement as usual.
+func compile(q queryer, query string) (*compiled, error) {
+	conn, ok := q.(*sql.Conn)
+	if !ok {
+		return nil, errors.New("compiling a statement needs a dedicated connection")
+	}
+
+	c := &compiled{}
+	err := withHandle(conn, func(tls *libc.TLS, db uintptr) error {
+		// Record the accesses with the installed authorizer, or with one that allows everything
+		state := &authorization{}
+		if v, ok := connAuthorizations.Load(conn); ok {
+			state = v.(*authorization)
+		} else {
+			id := lastAuthorization.Add(1)
+			authorizations.Store(id, state)
+			defer authorizations.Delete(id)
+			if rc := sqlite3.Xsqlite3_set_authorizer(tls, db, authorizerCallback, id); rc != sqlite3.SQLITE_OK {
+				return fmt.Errorf("failed to set authorizer (%d)", rc)
+			}
+			defer sqlite3.Xsqlite3_set_authorizer(tls, db, 0, 0)
+		}
+		state.accesses = &c.accesses
+		defer func() { state.accesses = nil }()
+
+		return prepare(tls, db, query, func(stmt uintptr) {
+			c.sources = make([]columnSource, sqlite3.Xsqlite3_column_count(tls, stmt))
+			for i := range c.sources {
+				c.sources[i] = columnSource{
+					schema: libc.GoString(sqlite3.Xsqlite3_column_database_name(tls, stmt, int32(i))),
+					table:  libc.GoString(sqlite3.Xsqlite3_column_table_name(tls, stmt, int32(i))),
+					column: libc.GoString(sqlite3.Xsqlite3_column_origin_name(tls, stmt, int32(i))),
+				}
+			}
+		})
+	})
+	return c, err
+}
+
+// markIndirect marks the sources of the result columns that SQLite does not report faithfully.
+// The sources of a compound SELECT are those of its first SELECT, including in views, and
+// the columns of virtual tables such as json_each are computed by the table.
+func (c *compiled) markIndirect(ctx context.Context, q queryer, query string) error {
+	statements, err := sqlparse.Split(query)
+	if err != nil || len(statements) != 1 || statements[0].Compound() ||
+		slices.ContainsFunc(c.accesses, func(a tableAccess) bool { return a.by != "" }) {
+		for i := range c.sources {
+			c.sources[i].indirect = true
+		}
+		return nil
+	}
+
+	var tables *Result
+	err = unchecked(q, func() (err error) {
+		tables, err = queryLimit(ctx, q, 0, "SELECT schema, name FROM pragma_table_list WHERE type = 'table'")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	ordinary := make(map[[2]string]bool, len(tables.Rows))
+	for _, row := range tables.Rows {
+		ordinary[[2]string{strings.ToLower(asString(row[0])), strings.ToLower(asString(row[1]))}] = true
+	}
+	for i, s := range c.sources {
+		if s.table != "" && !ordinary[[2]string{strings.ToLower(s.schema), strings.ToLower(s.table)}] {
+			c.sources[i].indirect = true
+		}
+	}
+	return nil
+}
+
+// prepare compiles query on the connection handle db and calls fn with the statement, which
+// is finalized afterwards. fn is not called for an empty query.
+func prepare(tls *libc.TLS, db uintptr, query string, fn func(stmt uintptr)) error {
+	zSQL, err := libc.CString(query)
+	if err != nil {
+		return err
+	}
+	defer libc.Xfree(tls, zSQL)
+	ppStmt := libc.Xmalloc(tls, types.Size_t(unsafe.Sizeof(uintptr(0)))) // #nosec G103 -- the size of a C pointer
+	if ppStmt == 0 {
+		return errors.New("failed to allocate statement handle")
+	}
+	defer libc.Xfree(tls, ppStmt)
+
+	if rc := sqlite3.Xsqlite3_prepare_v2(tls, db, zSQL, -1, ppStmt, 0); rc != sqlite3.SQLITE_OK {
+		return fmt.Errorf("query failed: %s", libc.GoString(sqlite3.Xsqlite3_errmsg(tls, db)))
+	}
+	stmt := libc.AtomicLoadPUintptr(ppStmt)
+	if stmt == 0 {
+		return nil
+	}
+	defer sqlite3.Xsqlite3_finalize(tls, stmt)
+
+	fn(stmt)
+	return nil
+}
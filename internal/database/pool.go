@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const (
+	// DefaultMaxReaders is the number of read-only connections a database keeps open at most
+	DefaultMaxReaders = 4
+	// DefaultIdleReaders is the number of unused read-only connections kept open
+	DefaultIdleReaders = 2
+)
+
+// ErrWriteQueueFull is returned when a write cannot wait for the writer connection because
+// the write queue is full
+var ErrWriteQueueFull = errors.New("too many writes are waiting for the database")
+
+// ErrWriterBusy is returned when a write cannot wait for the writer connection because a
+// transaction holds it, and may hold it until the transaction is committed or rolled back
+var ErrWriterBusy = errors.New("the database is busy with a transaction; retry once it ends")
+
+// WithReaders sizes the pool of read-only connections queries run on
+func WithReaders(maxOpen, maxIdle int) Option {
+	return func(o *options) {
+		o.maxReaders, o.idleReaders = maxOpen, maxIdle
+	}
+}
+
+// WithMaxQueuedWrites limits the number of writes waiting for the writer connection. Writes
+// beyond the limit fail with ErrWriteQueueFull; 0 disables the limit.
+func WithMaxQueuedWrites(n int) Option {
+	return func(o *options) {
+		o.maxQueuedWrites = n
+	}
+}
+
+// writeQueue hands the writer connection to one write at a time, in the order the writes
+// asked for it. Writes do not wait while a transaction holds the writer; they fail with
+// ErrWriterBusy instead.
+type writeQueue struct {
+	mu sync.Mutex
+	// busy is true while a write holds the writer
+	busy bool
+	// tx is true while a transaction holds the writer
+	tx bool
+	// waiters are closed in order to hand the writer to the next write
+	waiters []*writeWaiter
+	// max limits len(waiters) when it is positive
+	max int
+}
+
+// writeWaiter is a write waiting for the writer connection
+type writeWaiter struct {
+	// ready is closed when the write gets the writer, or when err is set
+	ready chan struct{}
+	// tx is true when the write is a transaction
+	tx bool
+	// err is set when the writer went to a transaction while the write was waiting
+	err error
+}
+
+// acquire waits until the writer is free and the writes queued before are done, or until
+// ctx is done. tx tells whether the writer is taken for a transaction. The returned function
+// hands the writer on; it may be called more than once.
+func (q *writeQueue) acquire(ctx context.Context, tx bool) (func(), error) {
+	q.mu.Lock()
+	if !q.busy {
+		q.busy, q.tx = true, tx
+		q.mu.Unlock()
+		return sync.OnceFunc(q.release), nil
+	}
+	if q.tx {
+		q.mu.Unlock()
+		return nil, ErrWriterBusy
+	}
+	if q.max > 0 && len(q.waiters) >= q.max {
+		q.mu.Unlock()
+		return nil, ErrWriteQueueFull
+	}
+	w := &writeWaiter{ready: make(chan struct{}), tx: tx}
+	q.waiters = append(q.waiters, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		if w.err != nil {
+			return nil, w.err
+		}
+		return sync.OnceFunc(q.release), nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	i := slices.Index(q.waiters, w)
+	if i >= 0 {
+		q.waiters = slices.Delete(q.waiters, i, i+1)
+	}
+	handed := i < 0 && w.err == nil
+	q.mu.Unlock()
+	if handed {
+		// The writer was handed over while giving up
+		q.release()
+	}
+	return nil, fmt.Errorf("gave up waiting for the database writer: %w", ctx.Err())
+}
+
+// release hands the writer to the longest waiting write, or frees it. When that write is a
+// transaction, the writes still waiting fail with ErrWriterBusy.
+func (q *writeQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiters) == 0 {
+		q.busy, q.tx = false, false
+		return
+	}
+	next := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	q.tx = next.tx
+	if next.tx {
+		for _, w := range q.waiters {
+			w.err = ErrWriterBusy
+			close(w.ready)
+		}
+		q.waiters = nil
+	}
+	close(next.ready)
+}
+
+// queued returns the number of writes waiting for the writer
+func (q *writeQueue) queued() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiters)
+}
+
+// writerConn takes the writer connection once the writes queued before are done, for a
+// transaction when tx is true. The returned function returns the connection and hands the
+// writer to the next write.
+func (db *DB) writerConn(ctx context.Context, tx bool) (*sql.Conn, func(), error) {
+	release, err := db.writes.acquire(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := db.writer.Conn(ctx)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	return conn, func() {
+		_ = conn.Close()
+		release()
+	}, nil
+}
+
+// withWriter runs fn on the writer connection, see writerConn. Its statements are checked
+// against the access rules ctx carries, see WithAccess.
+func (db *DB) withWriter(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, done, err := db.writerConn(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	return withAuthorizer(ctx, &db.indexes, conn, func() error { return fn(conn) })
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("fair ordering", func(t *testing.T) {
+		q := &writeQueue{}
+		release, err := q.acquire(ctx, false)
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var order []int
+		var wg sync.WaitGroup
+		for i := range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := q.acquire(ctx, false)
+				assert.NoError(t, err)
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				release()
+			}()
+			// Queue the writes one after the other
+			require.Eventually(t, func() bool { return q.queued() == i+1 }, time.Second, time.Millisecond)
+		}
+
+		release()
+		release() // releasing twice has no effect
+		wg.Wait()
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, order)
+		assert.Zero(t, q.queued())
+		assert.False(t, q.busy)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		q := &writeQueue{max: 1}
+		release, err := q.acquire(ctx, false)
+		require.NoError(t, err)
+		defer release()
+
+		go func() { _, _ = q.acquire(ctx, false) }()
+		require.Eventually(t, func() bool { return q.queued() == 1 }, time.Second, time.Millisecond)
+
+		_, err = q.acquire(ctx, false)
+		assert.ErrorIs(t, err, ErrWriteQueueFull)
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		q := &writeQueue{}
+		release, err := q.acquire(ctx, false)
+		require.NoError(t, err)
+
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = q.acquire(timeout, false)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Zero(t, q.queued())
+
+		release()
+		release, err = q.acquire(ctx, false)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("transactions do not queue writes", func(t *testing.T) {
+		q := &writeQueue{}
+		release, err := q.acquire(ctx, false)
+		require.NoError(t, err)
+
+		// A transaction waits for the write before it, and the writes after it fail once it starts
+		txReady := make(chan func())
+		go func() {
+			release, err := q.acquire(ctx, true)
+			assert.NoError(t, err)
+			txReady <- release
+		}()
+		require.Eventually(t, func() bool { return q.queued() == 1 }, time.Second, time.Millisecond)
+		writeErr := make(chan error)
+		go func() {
+			_, err := q.acquire(ctx, false)
+			writeErr <- err
+		}()
+		require.Eventually(t, func() bool { return q.queued() == 2 }, time.Second, time.Millisecond)
+
+		release()
+		releaseTx := <-txReady
+		assert.ErrorIs(t, <-writeErr, ErrWriterBusy)
+		assert.Zero(t, q.queued())
+
+		_, err = q.acquire(ctx, false)
+		assert.ErrorIs(t, err, ErrWriterBusy)
+		_, err = q.acquire(ctx, true)
+		assert.ErrorIs(t, err, ErrWriterBusy)
+
+		releaseTx()
+		release, err = q.acquire(ctx, false)
+		require.NoError(t, err)
+		release()
+		assert.False(t, q.busy)
+		assert.False(t, q.tx)
+	})
+}
+
+func TestPools(t *testing.T) {
+	ctx := context.Background()
+	pragmas, err := ProfilePragmas(DurabilityWAL)
+	require.NoError(t, err)
+	db, err := New(createTestDB(t), false, WithPragmas(DurabilityWAL, pragmas), WithReaders(2, 1))
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("concurrent writes", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 10 {
+					_, err := db.ExecuteContext(ctx, "INSERT INTO users (name, email, age) VALUES (?, ?, 1)",
+						fmt.Sprintf("user%d-%d", i, j), fmt.Sprintf("user%d-%d@example.com", i, j))
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT count(*) FROM users WHERE age = 1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(80), result.Rows[0][0])
+	})
+
+	t.Run("readers are read-only", func(t *testing.T) {
+		_, err := db.QueryLimitContext(ctx, 0, "INSERT INTO users (name, email) VALUES ('x', 'x@example.com') RETURNING id")
+		assert.ErrorContains(t, err, "readonly")
+	})
+
+	t.Run("reads during a transaction", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, TxImmediate)
+		require.NoError(t, err)
+		_, err = tx.ExecuteContext(ctx, "DELETE FROM users WHERE age = 1")
+		require.NoError(t, err)
+
+		// Readers see the last committed data while the transaction holds the writer
+		timeout, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		result, err := db.QueryLimitContext(timeout, 0, "SELECT count(*) FROM users WHERE age = 1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(80), result.Rows[0][0])
+
+		// Writes fail instead of waiting for the transaction to end
+		_, err = db.ExecuteContext(ctx, "DELETE FROM users WHERE age = 1")
+		assert.ErrorIs(t, err, ErrWriterBusy)
+
+		require.NoError(t, tx.Commit(ctx))
+		result, err = db.QueryLimitContext(ctx, 0, "SELECT count(*) FROM users WHERE age = 1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), result.Rows[0][0])
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "wal", d.JournalMode)
+		assert.Equal(t, 2, d.MaxReaders)
+		assert.Zero(t, d.QueuedWrites)
+	})
+
+	t.Run("invalid sizes", func(t *testing.T) {
+		_, err := New(InMemoryDB, false, WithReaders(0, 0))
+		assert.ErrorContains(t, err, "at least one reader connection is required")
+		_, err = New(InMemoryDB, false, WithMaxQueuedWrites(-1))
+		assert.ErrorContains(t, err, "must not be negative")
+	})
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Durability profiles
+const (
+	// DurabilityContainerSafe uses a rollback journal, which works on any filesystem, and
+	// syncs every commit. Memory mapping is disabled.
+	DurabilityContainerSafe = "container-safe"
+	// DurabilityWAL uses write-ahead logging so readers do not block the writer. Commits are
+	// not synced, so the last transactions may be lost, but the database is not corrupted,
+	// on power loss.
+	DurabilityWAL = "wal"
+	// DurabilityFull uses write-ahead logging and syncs every commit
+	DurabilityFull = "full"
+)
+
+// DefaultDurability is the profile databases are opened with unless configured otherwise
+const DefaultDurability = DurabilityWAL
+
+// Durabilities returns the durability profiles
+func Durabilities() []string {
+	return []string{DurabilityContainerSafe, DurabilityWAL, DurabilityFull}
+}
+
+// JournalModes returns the journal modes SQLite accepts
+func JournalModes() []string {
+	return []string{"delete", "truncate", "persist", "memory", "wal", "off"}
+}
+
+// synchronousModes lists the synchronous settings in the order of their numeric values
+var synchronousModes = []string{"off", "normal", "full", "extra"}
+
+// SynchronousModes returns the synchronous settings SQLite accepts
+func SynchronousModes() []string {
+	return slices.Clone(synchronousModes)
+}
+
+// Pragmas are the connection settings that trade durability for speed
+type Pragmas struct {
+	JournalMode string
+	Synchronous string
+	// BusyTimeout is how long a statement waits for a lock held by another connection
+	BusyTimeout time.Duration
+	// CacheSize is the page cache size in pages, or in KiB when negative
+	CacheSize int64
+	// MmapSize is the number of bytes of the database file read through memory mapping
+	MmapSize int64
+}
+
+// ProfilePragmas returns the settings of a durability profile
+func ProfilePragmas(profile string) (Pragmas, error) {
+	p := Pragmas{BusyTimeout: 5 * time.Second, CacheSize: -64000}
+	switch profile {
+	case DurabilityContainerSafe:
+		p.JournalMode, p.Synchronous = "delete", "full"
+	case DurabilityWAL:
+		p.JournalMode, p.Synchronous = "wal", "normal"
+	case DurabilityFull:
+		p.JournalMode, p.Synchronous = "wal", "full"
+	default:
+		return p, fmt.Errorf("unknown durability profile %q: must be one of %s", profile, strings.Join(Durabilities(), ", "))
+	}
+	return p, nil
+}
+
+// Validate checks the journal mode and synchronous setting
+func (p Pragmas) Validate() error {
+	if !slices.Contains(JournalModes(), p.JournalMode) {
+		return fmt.Errorf("unknown journal mode %q: must be one of %s", p.JournalMode, strings.Join(JournalModes(), ", "))
+	}
+	if !slices.Contains(synchronousModes, p.Synchronous) {
+		return fmt.Errorf("unknown synchronous setting %q: must be one of %s", p.Synchronous, strings.Join(synchronousModes, ", "))
+	}
+	if p.BusyTimeout < 0 {
+		return fmt.Errorf("busy timeout must not be negative")
+	}
+	return nil
+}
+
+// query returns the DSN query parameters that apply the settings to every connection. The
+// journal mode is a property of the database file that read-only connections cannot change,
+// and in-memory databases always keep their journal in memory, so it is left out for both.
+func (p Pragmas) query(readOnly, inMemory bool) string {
+	pragmas := []string{
+		fmt.Sprintf("busy_timeout(%d)", p.BusyTimeout.Milliseconds()),
+		"synchronous(" + p.Synchronous + ")",
+		fmt.Sprintf("cache_size(%d)", p.CacheSize),
+		fmt.Sprintf("mmap_size(%d)", p.MmapSize),
+		"temp_store(memory)",
+	}
+	if !readOnly && !inMemory {
+		pragmas = append(pragmas, "journal_mode("+p.JournalMode+")")
+	}
+	return "_pragma=" + strings.Join(pragmas, "&_pragma=")
+}
+
+// WithPragmas opens the database with the given connection settings instead of those of the
+// DefaultDurability profile
+func WithPragmas(profile string, p Pragmas) Option {
+	return func(o *options) {
+		o.durability, o.pragmas = profile, &p
+	}
+}
+
+// Diagnostics describes the state of a database connection
+type Diagnostics struct {
+	SQLiteVersion string `json:"sqlite_version"`
+	// Durability is the profile the database was opened with
+	Durability    string `json:"durability"`
+	JournalMode   string `json:"journal_mode"`
+	Synchronous   string `json:"synchronous"`
+	BusyTimeoutMS int64  `json:"busy_timeout_ms"`
+	CacheSize     int64  `json:"cache_size"`
+	MmapSize      int64  `json:"mmap_size"`
+	TempStore     string `json:"temp_store"`
+	ForeignKeys   bool   `json:"foreign_keys"`
+	PageSize      int64  `json:"page_size"`
+	PageCount     int64  `json:"page_count"`
+	FreelistCount int64  `json:"freelist_count"`
+	// MaxReaders is the size of the read-only connection pool
+	MaxReaders int `json:"max_readers"`
+	// QueuedWrites is the number of writes waiting for the writer connection
+	QueuedWrites int `json:"queued_writes"`
+}
+
+// Diagnostics reads back the settings in effect on a reader connection of the database. The
+// journal mode reported is that of the writer connection, as only write-ahead logging is a
+// property of the database file that readers see.
+func (db *DB) Diagnostics(ctx context.Context) (*Diagnostics, error) {
+	conn, err := db.readers.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	d := &Diagnostics{Durability: db.durability, MaxReaders: db.maxReaders, QueuedWrites: db.writes.queued()}
+	var synchronous, tempStore, foreignKeys int64
+	for _, v := range []struct {
+		query string
+		dst   any
+	}{
+		{"SELECT sqlite_version()", &d.SQLiteVersion},
+		{"PRAGMA journal_mode", &d.JournalMode},
+		{"PRAGMA synchronous", &synchronous},
+		{"PRAGMA busy_timeout", &d.BusyTimeoutMS},
+		{"PRAGMA cache_size", &d.CacheSize},
+		{"PRAGMA mmap_size", &d.MmapSize},
+		{"PRAGMA temp_store", &tempStore},
+		{"PRAGMA foreign_keys", &foreignKeys},
+		{"PRAGMA page_size", &d.PageSize},
+		{"PRAGMA page_count", &d.PageCount},
+		{"PRAGMA freelist_count", &d.FreelistCount},
+	} {
+		// PRAGMAs that do not apply to the database, such as mmap_size in memory, return no row
+		err := conn.QueryRowContext(ctx, v.query).Scan(v.dst)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to read %s: %w", strings.TrimPrefix(v.query, "PRAGMA "), err)
+		}
+	}
+
+	d.Synchronous = strconv.FormatInt(synchronous, 10)
+	if synchronous >= 0 && synchronous < int64(len(synchronousModes)) {
+		d.Synchronous = synchronousModes[synchronous]
+	}
+	if db.writer != db.readers {
+		d.JournalMode = db.journalMode
+	}
+	d.TempStore = [...]string{"default", "file", "memory"}[min(max(tempStore, 0), 2)]
+	d.ForeignKeys = foreignKeys != 0
+	return d, nil
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfilePragmas(t *testing.T) {
+	for profile, want := range map[string][2]string{
+		DurabilityContainerSafe: {"delete", "full"},
+		DurabilityWAL:           {"wal", "normal"},
+		DurabilityFull:          {"wal", "full"},
+	} {
+		p, err := ProfilePragmas(profile)
+		require.NoError(t, err, profile)
+		assert.Equal(t, want, [2]string{p.JournalMode, p.Synchronous}, profile)
+		assert.NoError(t, p.Validate(), profile)
+	}
+
+	_, err := ProfilePragmas("fast")
+	assert.ErrorContains(t, err, `unknown durability profile "fast"`)
+}
+
+func TestPragmasValidate(t *testing.T) {
+	p, err := ProfilePragmas(DefaultDurability)
+	require.NoError(t, err)
+
+	bad := p
+	bad.JournalMode = "wall"
+	assert.ErrorContains(t, bad.Validate(), `unknown journal mode "wall"`)
+
+	bad = p
+	bad.Synchronous = "2"
+	assert.ErrorContains(t, bad.Validate(), `unknown synchronous setting "2"`)
+
+	bad = p
+	bad.BusyTimeout = -time.Second
+	assert.ErrorContains(t, bad.Validate(), "busy timeout must not be negative")
+}
+
+func TestDiagnostics(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	t.Run("default", func(t *testing.T) {
+		db, err := New(path, false)
+		require.NoError(t, err)
+		defer db.Close()
+
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, DurabilityWAL, d.Durability)
+		assert.Equal(t, "wal", d.JournalMode)
+		assert.Equal(t, "normal", d.Synchronous)
+		assert.Equal(t, int64(5000), d.BusyTimeoutMS)
+		assert.Equal(t, int64(-64000), d.CacheSize)
+		assert.Equal(t, "memory", d.TempStore)
+		assert.NotEmpty(t, d.SQLiteVersion)
+		assert.Positive(t, d.PageSize)
+	})
+
+	t.Run("container-safe", func(t *testing.T) {
+		p, err := ProfilePragmas(DurabilityContainerSafe)
+		require.NoError(t, err)
+		db, err := New(path, false, WithPragmas(DurabilityContainerSafe, p))
+		require.NoError(t, err)
+		defer db.Close()
+
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, DurabilityContainerSafe, d.Durability)
+		assert.Equal(t, "delete", d.JournalMode)
+		assert.Equal(t, "full", d.Synchronous)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		p, err := ProfilePragmas(DurabilityWAL)
+		require.NoError(t, err)
+		p.BusyTimeout = 250 * time.Millisecond
+		p.CacheSize = 500
+		p.MmapSize = 1 << 20
+		db, err := New(path, false, WithPragmas(DurabilityWAL, p))
+		require.NoError(t, err)
+		defer db.Close()
+
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, DurabilityWAL, d.Durability)
+		assert.Equal(t, "wal", d.JournalMode)
+		assert.Equal(t, "normal", d.Synchronous)
+		assert.Equal(t, int64(250), d.BusyTimeoutMS)
+		assert.Equal(t, int64(500), d.CacheSize)
+		assert.Equal(t, int64(1<<20), d.MmapSize)
+	})
+
+	t.Run("read-only keeps the journal mode", func(t *testing.T) {
+		// The file was left in WAL mode by the previous subtest
+		p, err := ProfilePragmas(DurabilityContainerSafe)
+		require.NoError(t, err)
+		db, err := New(path, true, WithPragmas(DurabilityContainerSafe, p))
+		require.NoError(t, err)
+		defer db.Close()
+
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "wal", d.JournalMode)
+		assert.Equal(t, "full", d.Synchronous)
+	})
+
+	t.Run("in-memory", func(t *testing.T) {
+		db, err := New(InMemoryDB, false)
+		require.NoError(t, err)
+		defer db.Close()
+
+		d, err := db.Diagnostics(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "memory", d.JournalMode)
+	})
+}
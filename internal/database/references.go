@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// references are the table columns the definition of a view or trigger reads and writes. They
+// are found by compiling a statement that uses the view or fires the trigger, without running it.
+type references struct {
+	accesses []tableAccess
+	// ok is false when the statement could not be compiled, and the references are unknown
+	ok bool
+}
+
+// viewReferences returns the references of each view, and the source of each column of the
+// views, in order
+func (db *DB) viewReferences(ctx context.Context, views []View) ([]references, [][]columnSource, error) {
+	refs := make([]references, len(views))
+	sources := make([][]columnSource, len(views))
+	err := db.withReader(ctx, func(conn *sql.Conn) error {
+		for i, v := range views {
+			c, err := compile(conn, "SELECT * FROM "+qualifiedName(v.Schema, v.Name))
+			if err != nil {
+				continue
+			}
+			// The outer statement reads the view itself; the view reads everything else
+			refs[i] = references{accesses: filter(c.accesses, func(a tableAccess) bool { return a.by != "" }), ok: true}
+			sources[i] = c.sources
+		}
+		return nil
+	})
+	return refs, sources, err
+}
+
+// triggerReferences returns the references of each trigger
+func (db *DB) triggerReferences(ctx context.Context, triggers []Trigger) ([]references, error) {
+	refs := make([]references, len(triggers))
+	err := db.withReader(ctx, func(conn *sql.Conn) error {
+		for i, t := range triggers {
+			statement, err := firingStatement(ctx, conn, t)
+			if err != nil {
+				return err
+			}
+			c, err := compile(conn, statement)
+			if err != nil {
+				continue
+			}
+			// Other triggers on the same table and event are compiled along with this one
+			refs[i] = references{
+				accesses: filter(c.accesses, func(a tableAccess) bool { return strings.EqualFold(a.by, t.Name) }),
+				ok:       true,
+			}
+		}
+		return nil
+	})
+	return refs, err
+}
+
+// firingStatement returns a statement that fires a trigger. An UPDATE sets every column, so that
+// triggers limited to the updates of some columns fire too.
+func firingStatement(ctx context.Context, q queryer, t Trigger) (string, error) {
+	table := qualifiedName(t.Schema, t.Table)
+	switch t.Event {
+	case "INSERT":
+		return "INSERT INTO " + table + " DEFAULT VALUES", nil
+	case "UPDATE":
+		columns, err := queryLimit(ctx, q, 0, "SELECT name FROM pragma_table_xinfo(?, ?) WHERE hidden = 0", t.Table, t.Schema)
+		if err != nil {
+			return "", fmt.Errorf("failed to read columns: %w", err)
+		}
+		set := make([]string, len(columns.Rows))
+		for i, row := range columns.Rows {
+			set[i] = quoteIdentifier(asString(row[0])) + " = " + quoteIdentifier(asString(row[0]))
+		}
+		return "UPDATE " + table + " SET " + strings.Join(set, ", "), nil
+	default:
+		return "DELETE FROM " + table, nil
+	}
+}
+
+// withReader runs fn on a dedicated reader connection
+func (db *DB) withReader(ctx context.Context, fn func(*sql.Conn) error) error {
+	conn, err := db.readers.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	return fn(conn)
+}
@@ -0,0 +1,552 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+// TableSchema describes the structure of a table
+type TableSchema struct {
+	// Name is the table name
+	Name string `json:"table_name"`
+	// Schema is the database the table belongs to, e.g. "main"
+	Schema string `json:"schema"`
+	// Type is "table", "view", "virtual" or "shadow"
+	Type string `json:"type"`
+	// Strict is true for STRICT tables
+	Strict bool `json:"strict"`
+	// WithoutRowid is true for WITHOUT ROWID tables
+	WithoutRowid bool `json:"without_rowid"`
+	// SQL is the original CREATE statement
+	SQL string `json:"sql"`
+	// Columns holds the columns in declaration order, including hidden and generated columns
+	Columns []TableColumn `json:"columns"`
+	// PrimaryKey lists the primary key columns in key order
+	PrimaryKey []string `json:"primary_key"`
+	// Indexes holds the indexes on the table
+	Indexes []Index `json:"indexes"`
+	// ForeignKeys holds the foreign key constraints of the table
+	ForeignKeys []ForeignKey `json:"foreign_keys"`
+	// Checks holds the CHECK constraints declared on the table and its columns
+	Checks []CheckConstraint `json:"check_constraints"`
+
+	// refs are the references of a view
+	refs references
+}
+
+// TableColumn describes a table column. The JSON names follow PRAGMA table_info.
+type TableColumn struct {
+	CID     int64       `json:"cid"`
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	NotNull bool        `json:"notnull"`
+	Default interface{} `json:"dflt_value"`
+	// PK is the column's position in the primary key, or 0 when it is not part of it
+	PK int64 `json:"pk"`
+	// Hidden is true for hidden columns of virtual tables
+	Hidden bool `json:"hidden,omitempty"`
+	// Generated is "virtual" or "stored" for generated columns
+	Generated string `json:"generated,omitempty"`
+	// Collation is the declared collating sequence, or "" for the default BINARY
+	Collation string `json:"collation,omitempty"`
+
+	// source is the table column a column of a view is read from
+	source columnSource
+}
+
+// Index describes an index on a table
+type Index struct {
+	Name   string `json:"name"`
+	Unique bool   `json:"unique"`
+	// Origin is "c" for CREATE INDEX, "u" for a UNIQUE constraint and "pk" for a PRIMARY KEY
+	Origin  string        `json:"origin"`
+	Partial bool          `json:"partial"`
+	Columns []IndexColumn `json:"columns"`
+	// SQL is the CREATE INDEX statement, or "" for indexes created by constraints
+	SQL string `json:"sql,omitempty"`
+}
+
+// IndexColumn describes a key column of an index
+type IndexColumn struct {
+	// Name is the indexed column, or "" for an expression
+	Name       string `json:"name,omitempty"`
+	Expression bool   `json:"expression,omitempty"`
+	Desc       bool   `json:"desc"`
+	Collation  string `json:"collation"`
+}
+
+// ForeignKey describes a foreign key constraint
+type ForeignKey struct {
+	Columns []string `json:"columns"`
+	Table   string   `json:"references_table"`
+	// References lists the referenced columns; empty entries refer to the primary key
+	References []string `json:"references_columns"`
+	OnUpdate   string   `json:"on_update"`
+	OnDelete   string   `json:"on_delete"`
+	Match      string   `json:"match"`
+}
+
+// CheckConstraint describes a CHECK constraint
+type CheckConstraint struct {
+	// Column is the column the constraint is declared on, or "" for a table constraint
+	Column     string `json:"column,omitempty"`
+	Expression string `json:"expression"`
+}
+
+// GetTableSchema returns the structure of a table or view. The returned error wraps
+// ErrTableNotFound when no table or view has that name. The access rules and masks ctx
+// carries do not apply; use Access.FilterTableSchema on the result.
+func (db *DB) GetTableSchema(ctx context.Context, tableName string) (*TableSchema, error) {
+	ctx = catalogContext(ctx)
+	ref, err := lookupTable(ctx, db.readers, "", tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := &TableSchema{
+		Name:         ref.Name,
+		Schema:       ref.Schema,
+		Type:         ref.Type,
+		WithoutRowid: ref.WithoutRowid,
+		Strict:       ref.Strict,
+	}
+
+	sqlText, err := db.QueryLimitContext(ctx, 1,
+		fmt.Sprintf("SELECT sql FROM %s WHERE name = ? AND type IN ('table', 'view')", ts.master()), ts.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table definition: %w", err)
+	}
+	if len(sqlText.Rows) > 0 {
+		ts.SQL = asString(sqlText.Rows[0][0])
+	}
+
+	if err := db.loadColumns(ctx, ts); err != nil {
+		return nil, err
+	}
+	if err := db.loadIndexes(ctx, ts); err != nil {
+		return nil, err
+	}
+	if err := db.loadForeignKeys(ctx, ts); err != nil {
+		return nil, err
+	}
+	ts.loadDefinition()
+	if ts.Type == "view" {
+		if err := db.loadViewReferences(ctx, ts); err != nil {
+			return nil, err
+		}
+	}
+
+	return ts, nil
+}
+
+// loadViewReferences fills in the references of a view and the sources of its columns
+func (db *DB) loadViewReferences(ctx context.Context, ts *TableSchema) error {
+	refs, sources, err := db.viewReferences(ctx, []View{{Schema: ts.Schema, Name: ts.Name}})
+	if err != nil {
+		return err
+	}
+	ts.refs = refs[0]
+	if len(sources[0]) == len(ts.Columns) {
+		for i := range ts.Columns {
+			ts.Columns[i].source = sources[0][i]
+		}
+	}
+	return nil
+}
+
+// catalogContext returns ctx without its access rules and masks, for reading the schema
+// catalog, which callers filter afterwards
+func catalogContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, accessKey{}, (*Access)(nil))
+	return context.WithValue(ctx, masksKey{}, (*Masks)(nil))
+}
+
+// master returns the schema table that holds the definitions of the table's database
+func (ts *TableSchema) master() string {
+	return schemaTable(ts.Schema)
+}
+
+// loadColumns fills in the columns and primary key from PRAGMA table_xinfo
+func (db *DB) loadColumns(ctx context.Context, ts *TableSchema) error {
+	cols, err := db.QueryLimitContext(ctx, 0,
+		"SELECT cid, name, type, \"notnull\", dflt_value, pk, hidden FROM pragma_table_xinfo(?, ?)", ts.Name, ts.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	ts.Columns = make([]TableColumn, 0, len(cols.Rows))
+	for _, row := range cols.Rows {
+		col := TableColumn{
+			CID:     asInt(row[0]),
+			Name:    asString(row[1]),
+			Type:    asString(row[2]),
+			NotNull: asInt(row[3]) != 0,
+			Default: row[4],
+			PK:      asInt(row[5]),
+		}
+		switch asInt(row[6]) {
+		case 1:
+			col.Hidden = true
+		case 2:
+			col.Generated = "virtual"
+		case 3:
+			col.Generated = "stored"
+		}
+		ts.Columns = append(ts.Columns, col)
+	}
+
+	pk := make([]TableColumn, 0)
+	for _, col := range ts.Columns {
+		if col.PK > 0 {
+			pk = append(pk, col)
+		}
+	}
+	sort.Slice(pk, func(i, j int) bool { return pk[i].PK < pk[j].PK })
+	ts.PrimaryKey = make([]string, len(pk))
+	for i, col := range pk {
+		ts.PrimaryKey[i] = col.Name
+	}
+	return nil
+}
+
+// loadIndexes fills in the indexes from PRAGMA index_list and index_xinfo
+func (db *DB) loadIndexes(ctx context.Context, ts *TableSchema) error {
+	list, err := db.QueryLimitContext(ctx, 0, fmt.Sprintf(
+		`SELECT il.name, il."unique", il.origin, il.partial, m.sql
+		FROM pragma_index_list(?, ?) AS il
+		LEFT JOIN %s AS m ON m.type = 'index' AND m.name = il.name
+		ORDER BY il.seq`, ts.master()),
+		ts.Name, ts.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	ts.Indexes = make([]Index, 0, len(list.Rows))
+	for _, row := range list.Rows {
+		idx := Index{
+			Name:    asString(row[0]),
+			Unique:  asInt(row[1]) != 0,
+			Origin:  asString(row[2]),
+			Partial: asInt(row[3]) != 0,
+			SQL:     asString(row[4]),
+			Columns: []IndexColumn{},
+		}
+
+		cols, err := db.QueryLimitContext(ctx, 0,
+			`SELECT cid, name, "desc", coll FROM pragma_index_xinfo(?, ?) WHERE "key" ORDER BY seqno`, idx.Name, ts.Schema)
+		if err != nil {
+			return fmt.Errorf("failed to read columns of index %q: %w", idx.Name, err)
+		}
+		for _, col := range cols.Rows {
+			idx.Columns = append(idx.Columns, IndexColumn{
+				Name:       asString(col[1]),
+				Expression: asInt(col[0]) == -2,
+				Desc:       asInt(col[2]) != 0,
+				Collation:  asString(col[3]),
+			})
+		}
+		ts.Indexes = append(ts.Indexes, idx)
+	}
+	return nil
+}
+
+// loadForeignKeys fills in the foreign keys from PRAGMA foreign_key_list
+func (db *DB) loadForeignKeys(ctx context.Context, ts *TableSchema) error {
+	list, err := db.QueryLimitContext(ctx, 0,
+		`SELECT id, "table", "from", "to", on_update, on_delete, "match"
+		FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, ts.Name, ts.Schema)
+	if err != nil {
+		return fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	ts.ForeignKeys = make([]ForeignKey, 0)
+	lastID := int64(-1)
+	for _, row := range list.Rows {
+		if id := asInt(row[0]); id != lastID {
+			lastID = id
+			ts.ForeignKeys = append(ts.ForeignKeys, ForeignKey{
+				Table:    asString(row[1]),
+				OnUpdate: asString(row[4]),
+				OnDelete: asString(row[5]),
+				Match:    asString(row[6]),
+			})
+		}
+		fk := &ts.ForeignKeys[len(ts.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, asString(row[2]))
+		fk.References = append(fk.References, asString(row[3]))
+	}
+	return nil
+}
+
+// loadDefinition fills in collations and CHECK constraints, which SQLite only records in the CREATE statement
+func (ts *TableSchema) loadDefinition() {
+	ts.Checks = make([]CheckConstraint, 0)
+
+	def, ok := parseOne(ts.SQL).TableDef()
+	if !ok {
+		return
+	}
+
+	collations := make(map[string]string, len(def.Columns))
+	for _, col := range def.Columns {
+		collations[col.Name] = col.Collation
+		for _, expr := range col.Checks {
+			ts.Checks = append(ts.Checks, CheckConstraint{Column: col.Name, Expression: expr})
+		}
+	}
+	for _, expr := range def.Checks {
+		ts.Checks = append(ts.Checks, CheckConstraint{Expression: expr})
+	}
+
+	for i := range ts.Columns {
+		ts.Columns[i].Collation = collations[ts.Columns[i].Name]
+	}
+}
+
+// asString returns a TEXT value scanned from the driver, or "" for NULL
+func asString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	default:
+		return ""
+	}
+}
+
+// asInt returns an INTEGER value scanned from the driver, or 0 for NULL
+func asInt(v interface{}) int64 {
+	n, _ := v.(int64)
+	return n
+}
+
+// TableInfo describes a table in a table listing
+type TableInfo struct {
+	// Schema is "main" or the alias of an attached database
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	// Virtual is true for virtual tables such as FTS5 or R*Tree tables
+	Virtual bool `json:"virtual,omitempty"`
+	// Module names the module that implements a virtual table, e.g. "fts5"
+	Module string `json:"module,omitempty"`
+	// Shadow is true for the tables in which a virtual table stores its data
+	Shadow bool `json:"shadow,omitempty"`
+}
+
+// View describes a view
+type View struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	SQL    string `json:"sql"`
+
+	// refs are the references of the view
+	refs references
+}
+
+// TableIndex is an index together with the table it belongs to
+type TableIndex struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Index
+}
+
+// Trigger describes a trigger
+type Trigger struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+	Table  string `json:"table"`
+	// Timing is BEFORE, AFTER or INSTEAD OF
+	Timing string `json:"timing"`
+	// Event is DELETE, INSERT or UPDATE
+	Event string `json:"event"`
+	SQL   string `json:"sql"`
+
+	// refs are the references of the trigger
+	refs references
+}
+
+// Schemas returns the names of the main database and of the databases attached to it, as
+// reported by PRAGMA database_list
+func (db *DB) Schemas(ctx context.Context) ([]string, error) {
+	result, err := db.QueryLimitContext(catalogContext(ctx), 0,
+		"SELECT name FROM pragma_database_list WHERE name <> 'temp' ORDER BY seq")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	schemas := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		schemas = append(schemas, asString(row[0]))
+	}
+	return schemas, nil
+}
+
+// GetTables returns the tables of the main and attached databases, flagging virtual tables
+// and their shadow tables. The access rules and masks ctx carries do not apply.
+func (db *DB) GetTables(ctx context.Context) ([]TableInfo, error) {
+	ctx = catalogContext(ctx)
+	schemas, err := db.Schemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]TableInfo, 0)
+	for _, schema := range schemas {
+		result, err := db.QueryLimitContext(ctx, 0, fmt.Sprintf(
+			`SELECT m.name, m.sql, tl.type FROM %s AS m
+			JOIN pragma_table_list AS tl ON tl.schema = ? AND tl.name = m.name
+			WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%%' ORDER BY m.name`, schemaTable(schema)),
+			schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+
+		for _, row := range result.Rows {
+			table := TableInfo{Schema: schema, Name: asString(row[0])}
+			switch asString(row[2]) {
+			case "virtual":
+				table.Virtual = true
+				table.Module, _ = parseOne(asString(row[1])).VirtualTableModule()
+			case "shadow":
+				table.Shadow = true
+			}
+			tables = append(tables, table)
+		}
+	}
+	return tables, nil
+}
+
+// GetViews returns the views of the main and attached databases. The access rules and masks
+// ctx carries do not apply.
+func (db *DB) GetViews(ctx context.Context) ([]View, error) {
+	ctx = catalogContext(ctx)
+	schemas, err := db.Schemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]View, 0)
+	for _, schema := range schemas {
+		result, err := db.QueryLimitContext(ctx, 0,
+			fmt.Sprintf("SELECT name, sql FROM %s WHERE type = 'view' ORDER BY name", schemaTable(schema)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list views: %w", err)
+		}
+
+		for _, row := range result.Rows {
+			views = append(views, View{Schema: schema, Name: asString(row[0]), SQL: asString(row[1])})
+		}
+	}
+
+	refs, _, err := db.viewReferences(ctx, views)
+	if err != nil {
+		return nil, err
+	}
+	for i := range views {
+		views[i].refs = refs[i]
+	}
+	return views, nil
+}
+
+// GetIndexes returns the indexes of one table, or of every table in the main and attached
+// databases when tableName is "". Indexes that SQLite creates for PRIMARY KEY and UNIQUE
+// constraints are included. The access rules and masks ctx carries do not apply.
+func (db *DB) GetIndexes(ctx context.Context, tableName string) ([]TableIndex, error) {
+	ctx = catalogContext(ctx)
+
+	var tables []*TableSchema
+	if tableName != "" {
+		ref, err := lookupTable(ctx, db.readers, "", tableName)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, &TableSchema{Name: ref.Name, Schema: ref.Schema})
+	} else {
+		schemas, err := db.Schemas(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, schema := range schemas {
+			result, err := db.QueryLimitContext(ctx, 0,
+				fmt.Sprintf("SELECT name FROM %s WHERE type = 'table' ORDER BY name", schemaTable(schema)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list indexes: %w", err)
+			}
+			for _, row := range result.Rows {
+				tables = append(tables, &TableSchema{Name: asString(row[0]), Schema: schema})
+			}
+		}
+	}
+
+	indexes := make([]TableIndex, 0)
+	for _, ts := range tables {
+		if err := db.loadIndexes(ctx, ts); err != nil {
+			return nil, err
+		}
+		for _, idx := range ts.Indexes {
+			indexes = append(indexes, TableIndex{Schema: ts.Schema, Table: ts.Name, Index: idx})
+		}
+	}
+	return indexes, nil
+}
+
+// GetTriggers returns the triggers of one table or view, or all triggers of the main and
+// attached databases when tableName is "". The access rules and masks ctx carries do not apply.
+func (db *DB) GetTriggers(ctx context.Context, tableName string) ([]Trigger, error) {
+	ctx = catalogContext(ctx)
+
+	var schemas []string
+	table := ""
+	if tableName != "" {
+		ref, err := lookupTable(ctx, db.readers, "", tableName)
+		if err != nil {
+			return nil, err
+		}
+		schemas, table = []string{ref.Schema}, ref.Name
+	} else {
+		var err error
+		if schemas, err = db.Schemas(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	triggers := make([]Trigger, 0)
+	for _, schema := range schemas {
+		result, err := db.QueryLimitContext(ctx, 0, fmt.Sprintf(
+			"SELECT name, tbl_name, sql FROM %s WHERE type = 'trigger' AND (? = '' OR tbl_name = ?) ORDER BY name",
+			schemaTable(schema)), table, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list triggers: %w", err)
+		}
+
+		for _, row := range result.Rows {
+			trigger := Trigger{Schema: schema, Name: asString(row[0]), Table: asString(row[1]), SQL: asString(row[2])}
+			if def, ok := parseOne(trigger.SQL).Trigger(); ok {
+				trigger.Timing, trigger.Event = def.Timing, def.Event
+			}
+			triggers = append(triggers, trigger)
+		}
+	}
+
+	refs, err := db.triggerReferences(ctx, triggers)
+	if err != nil {
+		return nil, err
+	}
+	for i := range triggers {
+		triggers[i].refs = refs[i]
+	}
+	return triggers, nil
+}
+
+// parseOne parses a single statement from sqlite_schema, returning an empty statement when it cannot
+func parseOne(sql string) sqlparse.Statement {
+	statements, err := sqlparse.Split(sql)
+	if err != nil || len(statements) != 1 {
+		return sqlparse.Statement{}
+	}
+	return statements[0]
+}
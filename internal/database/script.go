@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WithInitScripts runs SQL scripts on the writer connection after connecting, in order and
+// before any query can run. Each path is a script file or a directory whose .sql files run
+// in name order.
+func WithInitScripts(paths ...string) Option {
+	return func(o *options) {
+		o.initScripts = append(o.initScripts, paths...)
+	}
+}
+
+// ScriptFiles returns the script at path, or the .sql files in the directory at path in name order
+func ScriptFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read script: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql scripts found in %s", path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// runScripts runs the scripts at paths on q, see WithInitScripts
+func runScripts(ctx context.Context, q queryer, paths []string) error {
+	for _, path := range paths {
+		files, err := ScriptFiles(path)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			log.Printf("Running script %s", file)
+			script, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read script: %w", err)
+			}
+			if _, err := q.ExecContext(ctx, string(script)); err != nil {
+				return fmt.Errorf("failed to run %s: %w", file, err)
+			}
+		}
+	}
+	return nil
+}
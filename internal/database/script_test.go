@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"02_data.sql", "01_schema.SQL", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+	}
+
+	files, err := ScriptFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "01_schema.SQL"), filepath.Join(dir, "02_data.sql")}, files)
+
+	files, err = ScriptFiles(filepath.Join(dir, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "README.md")}, files)
+
+	_, err = ScriptFiles(filepath.Join(dir, "missing.sql"))
+	assert.ErrorContains(t, err, "cannot read script")
+
+	_, err = ScriptFiles(t.TempDir())
+	assert.ErrorContains(t, err, "no .sql scripts found")
+}
+
+func TestInitScripts(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.sql")
+	require.NoError(t, os.WriteFile(schema, []byte(`
+CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+CREATE INDEX idx_items_name ON items (name);
+`), 0o600))
+	seed := filepath.Join(dir, "seed")
+	require.NoError(t, os.Mkdir(seed, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(seed, "01_items.sql"),
+		[]byte("INSERT INTO items (name) VALUES ('first'), ('second');"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(seed, "02_cleanup.sql"),
+		[]byte("DELETE FROM items WHERE name = 'second';"), 0o600))
+
+	t.Run("in order", func(t *testing.T) {
+		db, err := New(InMemoryDB, false, WithInitScripts(schema, seed))
+		require.NoError(t, err)
+		defer db.Close()
+
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT name FROM items")
+		require.NoError(t, err)
+		assert.Equal(t, [][]interface{}{{"first"}}, result.Rows)
+	})
+
+	t.Run("read-only memory database", func(t *testing.T) {
+		db, err := New(InMemoryDB, true, WithInitScripts(schema, seed))
+		require.NoError(t, err)
+		defer db.Close()
+
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT count(*) FROM items")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.Rows[0][0])
+
+		_, err = db.ExecuteContext(ctx, "DELETE FROM items")
+		assert.ErrorContains(t, err, "readonly")
+	})
+
+	t.Run("file database", func(t *testing.T) {
+		db, err := New(createTestDB(t), false, WithInitScripts(schema))
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.QueryLimitContext(ctx, 0, "SELECT * FROM items")
+		assert.NoError(t, err)
+	})
+
+	t.Run("read-only file database", func(t *testing.T) {
+		_, err := New(createTestDB(t), true, WithInitScripts(schema))
+		assert.ErrorContains(t, err, "cannot run scripts on read-only database")
+	})
+
+	t.Run("failing script", func(t *testing.T) {
+		broken := filepath.Join(dir, "broken.sql")
+		require.NoError(t, os.WriteFile(broken, []byte("CREATE TABLE items (id INTEGER);"), 0o600))
+
+		_, err := New(InMemoryDB, false, WithInitScripts(schema, broken))
+		assert.ErrorContains(t, err, "failed to run "+broken)
+		assert.ErrorContains(t, err, "already exists")
+	})
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Transaction modes accepted by BeginTx
+const (
+	TxDeferred  = "DEFERRED"
+	TxImmediate = "IMMEDIATE"
+	TxExclusive = "EXCLUSIVE"
+)
+
+// savepointName restricts savepoint names to plain identifiers
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Tx is a transaction held open on the writer connection across several calls.
+// Unlike *sql.Tx it is not bound to the context it was started with, so it can
+// outlive a single MCP request. Tx is not safe for concurrent use.
+type Tx struct {
+	conn *sql.Conn
+	// indexes caches the indexes the authorizer checks index pragmas against
+	indexes *indexCache
+	// done returns the connection and hands the writer to the next write
+	done func()
+}
+
+// BeginTx takes the writer connection once the writes queued before are done and starts a
+// transaction on it. Other writes fail with ErrWriterBusy until the transaction ends. ctx
+// bounds the wait and the BEGIN statement only. mode is one of TxDeferred, TxImmediate or TxExclusive; ""
+// means TxDeferred.
+func (db *DB) BeginTx(ctx context.Context, mode string) (*Tx, error) {
+	mode = strings.ToUpper(mode)
+	switch mode {
+	case "":
+		mode = TxDeferred
+	case TxDeferred, TxImmediate, TxExclusive:
+	default:
+		return nil, fmt.Errorf("invalid transaction mode %q: must be deferred, immediate or exclusive", mode)
+	}
+
+	conn, done, err := db.writerConn(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN "+mode); err != nil {
+		done()
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	return &Tx{conn: conn, indexes: &db.indexes, done: done}, nil
+}
+
+// QueryLimitContext executes a query inside the transaction, see DB.QueryLimitContext
+func (tx *Tx) QueryLimitContext(ctx context.Context, limit int, query string, args ...interface{}) (*Result, error) {
+	var result *Result
+	err := withAuthorizer(ctx, tx.indexes, tx.conn, func() (err error) {
+		result, err = queryMasked(ctx, tx.conn, limit, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// ExecuteContext runs a statement inside the transaction, see DB.ExecuteContext
+func (tx *Tx) ExecuteContext(ctx context.Context, statement string, args ...interface{}) (int64, error) {
+	var n int64
+	err := withAuthorizer(ctx, tx.indexes, tx.conn, func() (err error) {
+		n, err = execute(ctx, tx.conn, statement, args...)
+		return err
+	})
+	return n, err
+}
+
+// ExecuteLimitContext runs a statement inside the transaction, see DB.ExecuteLimitContext.
+// Only the statement's own changes are rolled back when the limit is exceeded.
+func (tx *Tx) ExecuteLimitContext(ctx context.Context, limit int64, statement string, args ...interface{}) (int64, error) {
+	if limit <= 0 {
+		return tx.ExecuteContext(ctx, statement, args...)
+	}
+	var n int64
+	err := withAuthorizer(ctx, tx.indexes, tx.conn, func() (err error) {
+		n, err = executeLimit(ctx, tx.conn, limit, statement, args...)
+		return err
+	})
+	return n, err
+}
+
+// IsReadOnly reports whether a statement is read-only, see DB.IsReadOnly. The statement is
+// compiled on the transaction's connection so that uncommitted schema changes are visible.
+func (tx *Tx) IsReadOnly(ctx context.Context, statement string, args ...interface{}) (bool, error) {
+	return isReadOnly(ctx, tx.conn, statement, args...)
+}
+
+// Savepoint opens a named savepoint inside the transaction
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	return tx.savepointOp(ctx, "SAVEPOINT", name)
+}
+
+// ReleaseSavepoint releases a savepoint, keeping its changes in the enclosing transaction
+func (tx *Tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.savepointOp(ctx, "RELEASE SAVEPOINT", name)
+}
+
+// RollbackToSavepoint undoes the changes made since a savepoint; the savepoint stays open
+func (tx *Tx) RollbackToSavepoint(ctx context.Context, name string) error {
+	return tx.savepointOp(ctx, "ROLLBACK TO SAVEPOINT", name)
+}
+
+// savepointOp runs a savepoint statement after validating the savepoint name
+func (tx *Tx) savepointOp(ctx context.Context, op, name string) error {
+	if !savepointName.MatchString(name) {
+		return fmt.Errorf("invalid savepoint name %q: use letters, digits and underscores", name)
+	}
+	if _, err := tx.conn.ExecContext(ctx, op+" "+name); err != nil {
+		return fmt.Errorf("%s failed: %w", strings.ToLower(op), err)
+	}
+	return nil
+}
+
+// Commit commits the transaction and hands the writer to the next write
+func (tx *Tx) Commit(ctx context.Context) error {
+	return tx.finish(ctx, "COMMIT")
+}
+
+// Rollback rolls the transaction back and hands the writer to the next write
+func (tx *Tx) Rollback(ctx context.Context) error {
+	return tx.finish(ctx, "ROLLBACK")
+}
+
+// finish ends the transaction with COMMIT or ROLLBACK and releases the connection.
+// The connection is released even if the statement fails; a failed COMMIT is rolled back
+// so that no transaction is left open on a pooled connection.
+func (tx *Tx) finish(ctx context.Context, statement string) error {
+	_, err := tx.conn.ExecContext(ctx, statement)
+	if err != nil && statement == "COMMIT" {
+		// Ignore the error: SQLite may already have rolled the transaction back
+		_, _ = tx.conn.ExecContext(context.Background(), "ROLLBACK")
+	}
+
+	tx.done()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", strings.ToLower(statement), err)
+	}
+	return nil
+}
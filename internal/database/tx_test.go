@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTx(t *testing.T) {
+	dbPath := createTestDB(t)
+	db, err := New(dbPath, false)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	countUsers := func(t *testing.T) int64 {
+		t.Helper()
+		result, err := db.QueryLimitContext(ctx, 0, "SELECT count(*) FROM users")
+		require.NoError(t, err)
+		return result.Rows[0][0].(int64)
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, "")
+		require.NoError(t, err)
+
+		n, err := tx.ExecuteContext(ctx, "INSERT INTO users (name, email, age) VALUES (?, ?, ?)", "Carol", "carol@example.com", 41)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		result, err := tx.QueryLimitContext(ctx, 0, "SELECT name FROM users WHERE email = ?", "carol@example.com")
+		require.NoError(t, err)
+		require.Len(t, result.Rows, 1)
+
+		require.NoError(t, tx.Commit(ctx))
+		assert.Equal(t, int64(3), countUsers(t))
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, "immediate")
+		require.NoError(t, err)
+
+		_, err = tx.ExecuteContext(ctx, "DELETE FROM users")
+		require.NoError(t, err)
+
+		require.NoError(t, tx.Rollback(ctx))
+		assert.Equal(t, int64(3), countUsers(t))
+	})
+
+	t.Run("savepoints", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, TxDeferred)
+		require.NoError(t, err)
+
+		require.NoError(t, tx.Savepoint(ctx, "before_delete"))
+		_, err = tx.ExecuteContext(ctx, "DELETE FROM users")
+		require.NoError(t, err)
+		require.NoError(t, tx.RollbackToSavepoint(ctx, "before_delete"))
+		require.NoError(t, tx.ReleaseSavepoint(ctx, "before_delete"))
+
+		require.NoError(t, tx.Commit(ctx))
+		assert.Equal(t, int64(3), countUsers(t))
+	})
+
+	t.Run("invalid savepoint name", func(t *testing.T) {
+		tx, err := db.BeginTx(ctx, "")
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		err = tx.Savepoint(ctx, "x; DROP TABLE users")
+		assert.ErrorContains(t, err, "invalid savepoint name")
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := db.BeginTx(ctx, "sometimes")
+		assert.ErrorContains(t, err, "invalid transaction mode")
+	})
+}
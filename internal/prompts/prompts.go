@@ -0,0 +1,166 @@
+// Package prompts provides MCP prompts for common database workflows, filled in with the
+// schema of the database they are for
+package prompts
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// builtinFiles holds the templates of the built-in prompts
+//
+//go:embed builtin/*.md
+var builtinFiles embed.FS
+
+// Prompts provides MCP prompts built from templates
+type Prompts struct {
+	dbs        *database.Databases
+	templates  []*Template
+	authorizer *authz.Authorizer
+	access     *database.Access
+}
+
+// Option configures a Prompts instance
+type Option func(*Prompts)
+
+// WithTemplates adds prompts. A template replaces the built-in prompt of the same name.
+func WithTemplates(templates ...*Template) Option {
+	return func(p *Prompts) {
+		for _, t := range templates {
+			p.add(t)
+		}
+	}
+}
+
+// WithAuthorizer limits authenticated callers to the databases they have a policy for, and
+// leaves the tables and columns their policies do not allow out of the schema
+func WithAuthorizer(authorizer *authz.Authorizer) Option {
+	return func(p *Prompts) {
+		p.authorizer = authorizer
+	}
+}
+
+// WithAccess leaves tables and columns out of the schema for every caller
+func WithAccess(access *database.Access) Option {
+	return func(p *Prompts) {
+		p.access = access
+	}
+}
+
+// New creates a new Prompts instance serving the built-in prompts for dbs
+func New(dbs *database.Databases, opts ...Option) *Prompts {
+	p := &Prompts{dbs: dbs, templates: Builtin()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Builtin returns the templates of the built-in prompts
+func Builtin() []*Template {
+	files, err := fs.Glob(builtinFiles, "builtin/*.md")
+	if err != nil {
+		panic(err)
+	}
+
+	templates := make([]*Template, len(files))
+	for i, file := range files {
+		data, err := builtinFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		if templates[i], err = ParseTemplate(strings.TrimSuffix(path.Base(file), ".md"), data); err != nil {
+			panic(err)
+		}
+	}
+	return templates
+}
+
+// add adds a template, replacing the one of the same name
+func (p *Prompts) add(t *Template) {
+	for i, existing := range p.templates {
+		if existing.Name == t.Name {
+			p.templates[i] = t
+			return
+		}
+	}
+	p.templates = append(p.templates, t)
+}
+
+// GetPrompts returns all available MCP prompts
+func (p *Prompts) GetPrompts() []mcp.Prompt {
+	list := make([]mcp.Prompt, len(p.templates))
+	for i, t := range p.templates {
+		list[i] = t.prompt()
+	}
+	return list
+}
+
+// HandlePrompt handles MCP prompt requests. The schema placeholders are filled in with the
+// tables and columns the caller may see.
+func (p *Prompts) HandlePrompt(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	t := p.template(request.Params.Name)
+	if t == nil {
+		return nil, fmt.Errorf("unknown prompt: %s", request.Params.Name)
+	}
+
+	args := request.Params.Arguments
+	for _, arg := range t.Arguments {
+		if arg.Required && strings.TrimSpace(args[arg.Name]) == "" {
+			return nil, fmt.Errorf("prompt %s requires the %s argument", t.Name, arg.Name)
+		}
+	}
+
+	name, err := p.dbs.Name(args[DatabaseArgument])
+	if err != nil {
+		return nil, err
+	}
+	db, err := p.dbs.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	grant, err := p.authorizer.GrantContext(ctx, "", name)
+	if err != nil {
+		return nil, err
+	}
+	access := p.access.Intersect(grant.Visibility())
+
+	values := make(map[string]string, len(args)+3)
+	for key, value := range args {
+		values[key] = value
+	}
+	values[DatabaseArgument] = name
+	if t.uses(schemaPlaceholder) {
+		if values[schemaPlaceholder], err = describeDatabase(ctx, db, access); err != nil {
+			return nil, err
+		}
+	}
+	if t.uses(tableSchemaPlaceholder) {
+		if values[tableSchemaPlaceholder], err = describeTable(ctx, db, access, args[TableArgument]); err != nil {
+			return nil, err
+		}
+	}
+
+	return mcp.NewGetPromptResult(t.Description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(t.render(values))),
+	}), nil
+}
+
+// template returns the template named name, or nil
+func (p *Prompts) template(name string) *Template {
+	for _, t := range p.templates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package prompts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/testutil"
+)
+
+// getPrompt renders a prompt and returns its text
+func getPrompt(ctx context.Context, t *testing.T, p *Prompts, name string, args map[string]string) (string, error) {
+	t.Helper()
+	result, err := p.HandlePrompt(ctx, mcp.GetPromptRequest{Params: mcp.GetPromptParams{Name: name, Arguments: args}})
+	if err != nil {
+		return "", err
+	}
+	require.Len(t, result.Messages, 1)
+	assert.Equal(t, mcp.RoleUser, result.Messages[0].Role)
+	return testutil.GetTextContent(t, result.Messages[0].Content), nil
+}
+
+func TestGetPrompts(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	custom, err := ParseTemplate("write_query", []byte("Custom {{database}}"))
+	require.NoError(t, err)
+	extra, err := ParseTemplate("extra", []byte("Extra"))
+	require.NoError(t, err)
+	p := New(testutil.Databases(t, db), WithTemplates(custom, extra))
+
+	prompts := p.GetPrompts()
+	names := make([]string, len(prompts))
+	for i, prompt := range prompts {
+		names[i] = prompt.Name
+		assert.NotEmpty(t, prompt.Arguments, prompt.Name)
+	}
+	assert.Equal(t, []string{"analyze_table", "explore_database", "review_migration", "write_query", "extra"}, names)
+
+	// A template replaces the built-in prompt of the same name
+	text, err := getPrompt(context.Background(), t, p, "write_query", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Custom test", text)
+}
+
+func TestHandlePrompt(t *testing.T) {
+	ctx := context.Background()
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+	_, err := db.ExecuteContext(ctx, "CREATE INDEX idx_users_age ON users (age)")
+	require.NoError(t, err)
+	_, err = db.ExecuteContext(ctx, "CREATE VIEW adults AS SELECT name FROM users WHERE age >= 18")
+	require.NoError(t, err)
+	p := New(testutil.Databases(t, db))
+
+	t.Run("explore database", func(t *testing.T) {
+		text, err := getPrompt(ctx, t, p, "explore_database", nil)
+		require.NoError(t, err)
+		assert.Contains(t, text, "SQLite database `test`")
+		assert.Contains(t, text, "-- users (table)\nCREATE TABLE users (")
+		assert.Contains(t, text, "-- products (table)")
+		assert.Contains(t, text, "-- adults (view)\nCREATE VIEW adults AS SELECT name FROM users WHERE age >= 18;")
+		assert.NotContains(t, text, "{{")
+	})
+
+	t.Run("analyze table", func(t *testing.T) {
+		text, err := getPrompt(ctx, t, p, "analyze_table", map[string]string{"table": "users"})
+		require.NoError(t, err)
+		assert.Contains(t, text, "the table `users`")
+		assert.Contains(t, text, "CREATE INDEX idx_users_age ON users (age);")
+		assert.NotContains(t, text, "products")
+
+		_, err = getPrompt(ctx, t, p, "analyze_table", map[string]string{"table": "missing"})
+		assert.ErrorIs(t, err, database.ErrTableNotFound)
+		_, err = getPrompt(ctx, t, p, "analyze_table", nil)
+		assert.EqualError(t, err, "prompt analyze_table requires the table argument")
+	})
+
+	t.Run("write query", func(t *testing.T) {
+		text, err := getPrompt(ctx, t, p, "write_query", map[string]string{"question": "Who is older than {{schema}}?"})
+		require.NoError(t, err)
+		assert.Contains(t, text, "Who is older than {{schema}}?")
+		assert.Contains(t, text, "CREATE TABLE products")
+	})
+
+	t.Run("review migration", func(t *testing.T) {
+		text, err := getPrompt(ctx, t, p, "review_migration", map[string]string{
+			"sql": "ALTER TABLE users DROP COLUMN age", "database": "TEST",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, text, "ALTER TABLE users DROP COLUMN age")
+		assert.Contains(t, text, "SQLite database `test`")
+		assert.Contains(t, text, "CREATE TABLE users")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := getPrompt(ctx, t, p, "missing", nil)
+		assert.EqualError(t, err, "unknown prompt: missing")
+		_, err = getPrompt(ctx, t, p, "explore_database", map[string]string{"database": "missing"})
+		assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+	})
+}
+
+func TestPromptAccess(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	authorizer, err := authz.New([]authz.Policy{
+		{Principals: []string{"alice"}, Databases: []string{testutil.TestDBName}, Read: []string{"users.name", "users.age"}},
+	})
+	require.NoError(t, err)
+	p := New(testutil.Databases(t, db), WithAuthorizer(authorizer))
+
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice"})
+	text, err := getPrompt(alice, t, p, "explore_database", nil)
+	require.NoError(t, err)
+	assert.Contains(t, text, "-- Some columns or constraints are not shown\nCREATE TABLE users (\n  name TEXT NOT NULL,\n  age INTEGER\n);")
+	assert.NotContains(t, text, "email")
+	assert.NotContains(t, text, "products")
+
+	_, err = getPrompt(alice, t, p, "analyze_table", map[string]string{"table": "products"})
+	assert.ErrorIs(t, err, database.ErrTableNotFound)
+
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob"})
+	_, err = getPrompt(bob, t, p, "explore_database", nil)
+	assert.ErrorIs(t, err, authz.ErrNotAllowed)
+}
@@ -0,0 +1,113 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// describeDatabase returns the definitions of the tables and views of db that access lets
+// the caller see, as SQL. Shadow tables of virtual tables are left out.
+func describeDatabase(ctx context.Context, db *database.DB, access *database.Access) (string, error) {
+	tables, err := db.GetTables(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tables: %w", err)
+	}
+
+	var b strings.Builder
+	for _, table := range access.FilterTables(tables) {
+		if table.Shadow {
+			continue
+		}
+		ts, err := db.GetTableSchema(ctx, qualifiedName(table.Schema, table.Name))
+		if err != nil {
+			return "", fmt.Errorf("failed to get table schema for '%s': %w", table.Name, err)
+		}
+		if access.FilterTableSchema(ts) {
+			writeTable(&b, ts, false)
+		}
+	}
+
+	views, err := db.GetViews(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get views: %w", err)
+	}
+	for _, view := range access.FilterViews(views) {
+		if view.SQL == "" {
+			fmt.Fprintf(&b, "-- %s (view)\n-- The definition is not shown\n\n", qualifiedName(view.Schema, view.Name))
+			continue
+		}
+		fmt.Fprintf(&b, "-- %s (view)\n%s;\n\n", qualifiedName(view.Schema, view.Name), view.SQL)
+	}
+
+	if b.Len() == 0 {
+		return "-- The database has no tables or views", nil
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// describeTable returns the definition of a table or view with its indexes, as SQL. The
+// returned error wraps database.ErrTableNotFound when access hides it.
+func describeTable(ctx context.Context, db *database.DB, access *database.Access, tableName string) (string, error) {
+	ts, err := db.GetTableSchema(ctx, tableName)
+	if err == nil && !access.FilterTableSchema(ts) {
+		err = fmt.Errorf("%w: %s", database.ErrTableNotFound, tableName)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrTableNotFound) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to get table schema for '%s': %w", tableName, err)
+	}
+
+	var b strings.Builder
+	writeTable(&b, ts, true)
+	return strings.TrimSpace(b.String()), nil
+}
+
+// writeTable writes the CREATE statement of a table, and of its indexes when withIndexes is
+// set. When access removed columns from the schema, the statement of a table is rebuilt from
+// the columns left, and those of a view are listed.
+func writeTable(b *strings.Builder, ts *database.TableSchema, withIndexes bool) {
+	fmt.Fprintf(b, "-- %s (%s)\n", qualifiedName(ts.Schema, ts.Name), ts.Type)
+	switch {
+	case ts.SQL != "":
+		fmt.Fprintf(b, "%s;\n", ts.SQL)
+	case ts.Type == "view":
+		columns := make([]string, len(ts.Columns))
+		for i, c := range ts.Columns {
+			columns[i] = c.Name
+		}
+		fmt.Fprintf(b, "-- The definition is not shown; columns: %s\n", strings.Join(columns, ", "))
+	default:
+		columns := make([]string, len(ts.Columns))
+		for i, c := range ts.Columns {
+			columns[i] = strings.TrimSpace(c.Name + " " + c.Type)
+			if c.NotNull {
+				columns[i] += " NOT NULL"
+			}
+		}
+		b.WriteString("-- Some columns or constraints are not shown\n")
+		fmt.Fprintf(b, "CREATE TABLE %s (\n  %s\n);\n", ts.Name, strings.Join(columns, ",\n  "))
+	}
+
+	if withIndexes {
+		for _, idx := range ts.Indexes {
+			if idx.SQL != "" {
+				fmt.Fprintf(b, "%s;\n", idx.SQL)
+			}
+		}
+	}
+	b.WriteString("\n")
+}
+
+// qualifiedName returns name prefixed with its schema unless the schema is main
+func qualifiedName(schema, name string) string {
+	if schema == "" || schema == "main" {
+		return name
+	}
+	return schema + "." + name
+}
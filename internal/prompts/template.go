@@ -0,0 +1,199 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DatabaseArgument names the database a prompt is for. Every prompt accepts it; it
+	// defaults to the default database.
+	DatabaseArgument = "database"
+	// TableArgument names the table the {{table_schema}} placeholder describes
+	TableArgument = "table"
+
+	// schemaPlaceholder is replaced with the definitions of the database's tables and views
+	schemaPlaceholder = "schema"
+	// tableSchemaPlaceholder is replaced with the definition of the table named by TableArgument
+	tableSchemaPlaceholder = "table_schema"
+)
+
+var (
+	// placeholder matches {{name}} in template text
+	placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	// promptName restricts prompt names to the characters allowed in database names
+	promptName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]*$`)
+	// argumentName restricts argument names to ones that can be used as placeholders
+	argumentName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Template is a prompt whose text has {{name}} placeholders. Besides its arguments, the text
+// may use {{database}}, the name of the database the prompt is for, {{schema}}, the
+// definitions of that database's tables and views, and {{table_schema}}, the definition of
+// the table named by the table argument.
+type Template struct {
+	Name        string
+	Description string
+	Arguments   []Argument
+	Text        string
+}
+
+// Argument is an argument of a prompt template
+type Argument struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+}
+
+// frontMatter is the YAML header of a template file
+type frontMatter struct {
+	Description string     `yaml:"description"`
+	Arguments   []Argument `yaml:"arguments"`
+}
+
+// ParseTemplate parses the Markdown text of a template. The text may start with a YAML front
+// matter between two --- lines that sets the description and arguments.
+func ParseTemplate(name string, data []byte) (*Template, error) {
+	t := &Template{Name: name, Text: string(data)}
+
+	data = append(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), '\n')
+	if rest, ok := bytes.CutPrefix(data, []byte("---\n")); ok {
+		// Keep the newline before the closing line, so that an empty header is found too
+		header, body, found := bytes.Cut(append([]byte("\n"), rest...), []byte("\n---\n"))
+		if !found {
+			return nil, fmt.Errorf("prompt %s: front matter is not closed with ---", name)
+		}
+
+		var fm frontMatter
+		decoder := yaml.NewDecoder(bytes.NewReader(header))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&fm); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("prompt %s: invalid front matter: %w", name, err)
+		}
+		t.Description, t.Arguments, t.Text = fm.Description, fm.Arguments, string(body)
+	}
+	t.Text = strings.TrimSpace(t.Text)
+
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("prompt %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// LoadTemplates loads every .md file in dir as a template named after the file without its
+// extension, in name order
+func LoadTemplates(dir string) ([]*Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompts directory: %w", err)
+	}
+
+	var templates []*Template
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read prompt: %w", err))
+			continue
+		}
+		t, err := ParseTemplate(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		templates = append(templates, t)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// validate checks the name and arguments, and that every placeholder has a value
+func (t *Template) validate() error {
+	if !promptName.MatchString(t.Name) {
+		return errors.New("invalid name: use letters, digits, underscores and hyphens")
+	}
+
+	seen := make(map[string]bool)
+	for _, arg := range t.Arguments {
+		switch {
+		case !argumentName.MatchString(arg.Name):
+			return fmt.Errorf("invalid argument name %q: use letters, digits and underscores", arg.Name)
+		case arg.Name == schemaPlaceholder || arg.Name == tableSchemaPlaceholder:
+			return fmt.Errorf("argument name %q is reserved", arg.Name)
+		case seen[arg.Name]:
+			return fmt.Errorf("argument %q is declared twice", arg.Name)
+		}
+		seen[arg.Name] = true
+	}
+
+	for _, name := range t.placeholders() {
+		switch {
+		case name == tableSchemaPlaceholder && !seen[TableArgument]:
+			return fmt.Errorf("{{%s}} requires a %q argument", name, TableArgument)
+		case !seen[name] && name != DatabaseArgument && name != schemaPlaceholder && name != tableSchemaPlaceholder:
+			return fmt.Errorf("unknown placeholder {{%s}}: declare it as an argument", name)
+		}
+	}
+	return nil
+}
+
+// placeholders returns the names of the placeholders in the text
+func (t *Template) placeholders() []string {
+	var names []string
+	for _, m := range placeholder.FindAllStringSubmatch(t.Text, -1) {
+		if !slices.Contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// uses reports whether the text has the placeholder name
+func (t *Template) uses(name string) bool {
+	return slices.Contains(t.placeholders(), name)
+}
+
+// prompt returns the MCP prompt for the template, with the database argument added
+func (t *Template) prompt() mcp.Prompt {
+	opts := []mcp.PromptOption{mcp.WithPromptDescription(t.Description)}
+	hasDatabase := false
+	for _, arg := range t.Arguments {
+		argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Description)}
+		if arg.Required {
+			argOpts = append(argOpts, mcp.RequiredArgument())
+		}
+		opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+		hasDatabase = hasDatabase || arg.Name == DatabaseArgument
+	}
+	if !hasDatabase {
+		opts = append(opts, mcp.WithArgument(DatabaseArgument,
+			mcp.ArgumentDescription("Name of the database, as reported by list_databases; defaults to the default database")))
+	}
+	return mcp.NewPrompt(t.Name, opts...)
+}
+
+// render replaces the placeholders with values. Values are not searched for placeholders.
+func (t *Template) render(values map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(t.Text, func(m string) string {
+		return values[placeholder.FindStringSubmatch(m)[1]]
+	})
+}
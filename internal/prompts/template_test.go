@@ -0,0 +1,88 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplate(t *testing.T) {
+	t.Run("front matter", func(t *testing.T) {
+		tmpl, err := ParseTemplate("summarize", []byte(`---
+description: Summarize a region
+arguments:
+  - name: region
+    description: Region to summarize
+    required: true
+---
+
+Summarize {{ region }} in {{database}}.
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "Summarize a region", tmpl.Description)
+		assert.Equal(t, []Argument{{Name: "region", Description: "Region to summarize", Required: true}}, tmpl.Arguments)
+		assert.Equal(t, "Summarize {{ region }} in {{database}}.", tmpl.Text)
+		assert.Equal(t, "Summarize {{x}} in sales.", tmpl.render(map[string]string{"region": "{{x}}", "database": "sales"}))
+	})
+
+	t.Run("no front matter", func(t *testing.T) {
+		tmpl, err := ParseTemplate("plain", []byte("\r\nList the tables of {{database}}.\r\n"))
+		require.NoError(t, err)
+		assert.Empty(t, tmpl.Description)
+		assert.Equal(t, "List the tables of {{database}}.", tmpl.Text)
+	})
+
+	t.Run("prompt", func(t *testing.T) {
+		tmpl, err := ParseTemplate("plain", []byte("---\n---\nText"))
+		require.NoError(t, err)
+		prompt := tmpl.prompt()
+		assert.Equal(t, "plain", prompt.Name)
+		require.Len(t, prompt.Arguments, 1)
+		assert.Equal(t, DatabaseArgument, prompt.Arguments[0].Name)
+		assert.False(t, prompt.Arguments[0].Required)
+	})
+
+	for _, tc := range []struct {
+		name, text, err string
+	}{
+		{"bad name", "text", "invalid name"},
+		{"unclosed", "---\ndescription: x\n", "front matter is not closed"},
+		{"unknown key", "---\ntitle: x\n---\n", "field title not found"},
+		{"unknown placeholder", "Use {{region}}", "unknown placeholder {{region}}"},
+		{"table schema", "{{table_schema}}", `{{table_schema}} requires a "table" argument`},
+		{"reserved", "---\narguments: [{name: schema}]\n---\n", `argument name "schema" is reserved`},
+		{"twice", "---\narguments: [{name: a}, {name: a}]\n---\n", `argument "a" is declared twice`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			name := "valid"
+			if tc.name == "bad name" {
+				name = "no spaces"
+			}
+			_, err := ParseTemplate(name, []byte(tc.text))
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.md"), []byte("Second"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.MD"), []byte("First"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("{{ignored}}"), 0o600))
+
+	templates, err := LoadTemplates(dir)
+	require.NoError(t, err)
+	require.Len(t, templates, 2)
+	assert.Equal(t, "a", templates[0].Name)
+	assert.Equal(t, "b", templates[1].Name)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.md"), []byte("{{missing}}"), 0o600))
+	_, err = LoadTemplates(dir)
+	assert.ErrorContains(t, err, filepath.Join(dir, "c.md")+": prompt c: unknown placeholder {{missing}}")
+
+	_, err = LoadTemplates(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "failed to read prompts directory")
+}
@@ -4,43 +4,99 @@ package resources
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
 	"github.com/StacklokLabs/sqlite-mcp/internal/database"
 )
 
 // SchemaResources provides MCP resources for SQLite database schema information
 type SchemaResources struct {
-	db *database.DB
+	dbs        *database.Databases
+	authorizer *authz.Authorizer
+	access     *database.Access
 }
 
-// New creates a new SchemaResources instance
-func New(db *database.DB) *SchemaResources {
-	return &SchemaResources{db: db}
+// Option configures a SchemaResources instance
+type Option func(*SchemaResources)
+
+// WithAuthorizer limits authenticated callers to the databases they have a policy for, and
+// hides the tables and columns their policies do not allow
+func WithAuthorizer(authorizer *authz.Authorizer) Option {
+	return func(sr *SchemaResources) {
+		sr.authorizer = authorizer
+	}
 }
 
-// GetResources returns all available MCP resources
-func (*SchemaResources) GetResources() []mcp.Resource {
-	return []mcp.Resource{
-		mcp.NewResource(
-			"schema://tables",
-			"Database Tables",
-			mcp.WithResourceDescription("List of all tables in the SQLite database"),
-			mcp.WithMIMEType("application/json"),
-		),
+// WithAccess hides tables and columns from every caller
+func WithAccess(access *database.Access) Option {
+	return func(sr *SchemaResources) {
+		sr.access = access
+	}
+}
+
+// New creates a new SchemaResources instance serving the schemas of dbs
+func New(dbs *database.Databases, opts ...Option) *SchemaResources {
+	sr := &SchemaResources{dbs: dbs}
+	for _, opt := range opts {
+		opt(sr)
 	}
+	return sr
+}
+
+// GetResources returns all available MCP resources, one set per database
+func (sr *SchemaResources) GetResources() []mcp.Resource {
+	var list []mcp.Resource
+	for _, name := range sr.dbs.Names() {
+		list = append(list,
+			mcp.NewResource(
+				fmt.Sprintf("schema://%s/tables", name),
+				fmt.Sprintf("Database Tables (%s)", name),
+				mcp.WithResourceDescription(fmt.Sprintf(
+					"List of all tables in the %s database, with virtual tables flagged", name)),
+				mcp.WithMIMEType("application/json"),
+			),
+			mcp.NewResource(
+				fmt.Sprintf("schema://%s/views", name),
+				fmt.Sprintf("Database Views (%s)", name),
+				mcp.WithResourceDescription(fmt.Sprintf("List of all views in the %s database with their definitions", name)),
+				mcp.WithMIMEType("application/json"),
+			),
+			mcp.NewResource(
+				fmt.Sprintf("schema://%s/indexes", name),
+				fmt.Sprintf("Database Indexes (%s)", name),
+				mcp.WithResourceDescription(fmt.Sprintf("List of all indexes in the %s database", name)),
+				mcp.WithMIMEType("application/json"),
+			),
+			mcp.NewResource(
+				fmt.Sprintf("schema://%s/triggers", name),
+				fmt.Sprintf("Database Triggers (%s)", name),
+				mcp.WithResourceDescription(fmt.Sprintf("List of all triggers in the %s database", name)),
+				mcp.WithMIMEType("application/json"),
+			),
+		)
+	}
+	return list
 }
 
 // GetResourceTemplates returns all available MCP resource templates
 func (*SchemaResources) GetResourceTemplates() []mcp.ResourceTemplate {
 	return []mcp.ResourceTemplate{
 		mcp.NewResourceTemplate(
-			"schema://table/{name}",
+			"schema://{db}/table/{name}",
 			"Table Schema",
-			mcp.WithTemplateDescription("Schema information for a specific table"),
+			mcp.WithTemplateDescription("Columns, keys, indexes, foreign keys and constraints of a specific table"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		mcp.NewResourceTemplate(
+			"schema://{db}/view/{name}",
+			"View Schema",
+			mcp.WithTemplateDescription("Columns and definition of a specific view"),
 			mcp.WithTemplateMIMEType("application/json"),
 		),
 	}
@@ -50,69 +106,153 @@ func (*SchemaResources) GetResourceTemplates() []mcp.ResourceTemplate {
 func (sr *SchemaResources) HandleResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 	uri := request.Params.URI
 
+	// Every URI has the form schema://{db}/{resource}
+	path, ok := strings.CutPrefix(uri, "schema://")
+	if !ok {
+		return nil, fmt.Errorf("unknown resource URI: %s", uri)
+	}
+	dbName, resource, ok := strings.Cut(path, "/")
+	if !ok {
+		return nil, fmt.Errorf("unknown resource URI: %s", uri)
+	}
+	db, err := sr.dbs.Get(dbName)
+	if err != nil {
+		return nil, err
+	}
+	grant, err := sr.authorizer.GrantContext(ctx, "", dbName)
+	if err != nil {
+		return nil, err
+	}
+	access := sr.access.Intersect(grant.Visibility())
+
 	switch {
-	case uri == "schema://tables":
-		return sr.handleTablesList(ctx)
-	case strings.HasPrefix(uri, "schema://table/"):
-		tableName := strings.TrimPrefix(uri, "schema://table/")
-		return sr.handleTableSchema(ctx, tableName)
+	case resource == "tables":
+		return sr.handleTablesList(ctx, db, access, uri)
+	case resource == "views":
+		return sr.handleViewsList(ctx, db, access, uri)
+	case resource == "indexes":
+		return sr.handleIndexesList(ctx, db, access, uri)
+	case resource == "triggers":
+		return sr.handleTriggersList(ctx, db, access, uri)
+	case strings.HasPrefix(resource, "table/"):
+		tableName, err := url.PathUnescape(strings.TrimPrefix(resource, "table/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid table name in %s: %w", uri, err)
+		}
+		return sr.handleTableSchema(ctx, db, access, uri, tableName)
+	case strings.HasPrefix(resource, "view/"):
+		viewName, err := url.PathUnescape(strings.TrimPrefix(resource, "view/"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid view name in %s: %w", uri, err)
+		}
+		return sr.handleViewSchema(ctx, db, access, uri, viewName)
 	default:
 		return nil, fmt.Errorf("unknown resource URI: %s", uri)
 	}
 }
 
 // handleTablesList returns a list of all tables
-func (sr *SchemaResources) handleTablesList(_ context.Context) ([]mcp.ResourceContents, error) {
-	tables, err := sr.db.GetTables()
+func (*SchemaResources) handleTablesList(
+	ctx context.Context, db *database.DB, access *database.Access, uri string,
+) ([]mcp.ResourceContents, error) {
+	tables, err := db.GetTables(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tables: %w", err)
 	}
 
-	jsonData, err := json.MarshalIndent(tables, "", "  ")
+	return jsonContents(uri, access.FilterTables(tables))
+}
+
+// handleViewsList returns a list of all views
+func (*SchemaResources) handleViewsList(
+	ctx context.Context, db *database.DB, access *database.Access, uri string,
+) ([]mcp.ResourceContents, error) {
+	views, err := db.GetViews(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal tables: %w", err)
+		return nil, fmt.Errorf("failed to get views: %w", err)
 	}
 
-	return []mcp.ResourceContents{
-		mcp.TextResourceContents{
-			URI:      "schema://tables",
-			MIMEType: "application/json",
-			Text:     string(jsonData),
-		},
-	}, nil
+	return jsonContents(uri, access.FilterViews(views))
 }
 
-// handleTableSchema returns schema information for a specific table
-func (sr *SchemaResources) handleTableSchema(_ context.Context, tableName string) ([]mcp.ResourceContents, error) {
-	if tableName == "" {
-		return nil, fmt.Errorf("table name is required")
+// handleIndexesList returns a list of all indexes
+func (*SchemaResources) handleIndexesList(
+	ctx context.Context, db *database.DB, access *database.Access, uri string,
+) ([]mcp.ResourceContents, error) {
+	indexes, err := db.GetIndexes(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indexes: %w", err)
 	}
 
-	schema, err := sr.db.GetTableSchema(tableName)
+	return jsonContents(uri, access.FilterIndexes(indexes))
+}
+
+// handleTriggersList returns a list of all triggers
+func (*SchemaResources) handleTriggersList(
+	ctx context.Context, db *database.DB, access *database.Access, uri string,
+) ([]mcp.ResourceContents, error) {
+	triggers, err := db.GetTriggers(ctx, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get table schema for '%s': %w", tableName, err)
+		return nil, fmt.Errorf("failed to get triggers: %w", err)
 	}
 
-	if len(schema) == 0 {
-		return nil, fmt.Errorf("table '%s' not found", tableName)
+	return jsonContents(uri, access.FilterTriggers(triggers))
+}
+
+// handleViewSchema returns the columns and definition of a specific view
+func (*SchemaResources) handleViewSchema(
+	ctx context.Context, db *database.DB, access *database.Access, uri, viewName string,
+) ([]mcp.ResourceContents, error) {
+	if viewName == "" {
+		return nil, fmt.Errorf("view name is required")
 	}
 
-	// Create a more structured response
-	tableInfo := map[string]interface{}{
-		"table_name": tableName,
-		"columns":    schema,
+	schema, err := db.GetTableSchema(ctx, viewName)
+	if err != nil && !errors.Is(err, database.ErrTableNotFound) {
+		return nil, fmt.Errorf("failed to get view schema for '%s': %w", viewName, err)
 	}
 
-	jsonData, err := json.MarshalIndent(tableInfo, "", "  ")
+	if err != nil || schema.Type != "view" || !access.FilterTableSchema(schema) {
+		return nil, fmt.Errorf("view '%s' not found", viewName)
+	}
+
+	return jsonContents(uri, schema)
+}
+
+// jsonContents returns v as the JSON contents of the resource at uri
+func jsonContents(uri string, v interface{}) ([]mcp.ResourceContents, error) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal table schema: %w", err)
+		return nil, fmt.Errorf("failed to marshal %s: %w", uri, err)
 	}
 
 	return []mcp.ResourceContents{
 		mcp.TextResourceContents{
-			URI:      fmt.Sprintf("schema://table/%s", tableName),
+			URI:      uri,
 			MIMEType: "application/json",
 			Text:     string(jsonData),
 		},
 	}, nil
 }
+
+// handleTableSchema returns schema information for a specific table
+func (*SchemaResources) handleTableSchema(
+	ctx context.Context, db *database.DB, access *database.Access, uri, tableName string,
+) ([]mcp.ResourceContents, error) {
+	if tableName == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+
+	schema, err := db.GetTableSchema(ctx, tableName)
+	if err == nil && !access.FilterTableSchema(schema) {
+		err = database.ErrTableNotFound
+	}
+	if errors.Is(err, database.ErrTableNotFound) {
+		return nil, fmt.Errorf("table '%s' not found", tableName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table schema for '%s': %w", tableName, err)
+	}
+
+	return jsonContents(uri, schema)
+}
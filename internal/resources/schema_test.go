@@ -8,6 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
 	"github.com/StacklokLabs/sqlite-mcp/internal/testutil"
 )
 
@@ -15,7 +18,7 @@ func TestNew(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	sr := New(db)
+	sr := New(testutil.Databases(t, db))
 	assert.NotNil(t, sr)
 }
 
@@ -23,23 +26,32 @@ func TestGetResources(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	sr := New(db)
+	sr := New(testutil.Databases(t, db))
 	resources := sr.GetResources()
 
-	assert.Len(t, resources, 1)
-	assert.Equal(t, "schema://tables", resources[0].URI)
-	assert.Equal(t, "Database Tables", resources[0].Name)
+	assert.Len(t, resources, 4)
+	assert.Equal(t, "schema://test/tables", resources[0].URI)
+	assert.Equal(t, "Database Tables (test)", resources[0].Name)
+
+	uris := make([]string, len(resources))
+	for i, resource := range resources {
+		uris[i] = resource.URI
+	}
+	assert.Contains(t, uris, "schema://test/views")
+	assert.Contains(t, uris, "schema://test/indexes")
+	assert.Contains(t, uris, "schema://test/triggers")
 }
 
 func TestGetResourceTemplates(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	sr := New(db)
+	sr := New(testutil.Databases(t, db))
 	templates := sr.GetResourceTemplates()
 
-	assert.Len(t, templates, 1)
+	assert.Len(t, templates, 2)
 	assert.Equal(t, "Table Schema", templates[0].Name)
+	assert.Equal(t, "View Schema", templates[1].Name)
 	// URITemplate is a complex type, so we'll just check it's not nil
 	assert.NotNil(t, templates[0].URITemplate)
 }
@@ -48,12 +60,12 @@ func TestHandleTablesList(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	sr := New(db)
+	sr := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	request := mcp.ReadResourceRequest{
 		Params: mcp.ReadResourceParams{
-			URI: "schema://tables",
+			URI: "schema://test/tables",
 		},
 	}
 
@@ -70,13 +82,13 @@ func TestHandleTableSchema(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	sr := New(db)
+	sr := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	t.Run("existing table", func(t *testing.T) {
 		request := mcp.ReadResourceRequest{
 			Params: mcp.ReadResourceParams{
-				URI: "schema://table/users",
+				URI: "schema://test/table/users",
 			},
 		}
 
@@ -97,7 +109,7 @@ func TestHandleTableSchema(t *testing.T) {
 	t.Run("non-existent table", func(t *testing.T) {
 		request := mcp.ReadResourceRequest{
 			Params: mcp.ReadResourceParams{
-				URI: "schema://table/non_existent",
+				URI: "schema://test/table/non_existent",
 			},
 		}
 
@@ -109,7 +121,7 @@ func TestHandleTableSchema(t *testing.T) {
 	t.Run("empty table name", func(t *testing.T) {
 		request := mcp.ReadResourceRequest{
 			Params: mcp.ReadResourceParams{
-				URI: "schema://table/",
+				URI: "schema://test/table/",
 			},
 		}
 
@@ -119,11 +131,194 @@ func TestHandleTableSchema(t *testing.T) {
 	})
 }
 
+func TestHandleSchemaObjects(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	sr := New(testutil.Databases(t, db))
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		"CREATE VIEW adults AS SELECT name, age FROM users WHERE age >= 18",
+		"CREATE INDEX idx_products_name ON products (name)",
+		"CREATE TRIGGER users_audit AFTER DELETE ON users BEGIN SELECT 1; END",
+	} {
+		_, err := db.ExecuteContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+
+	read := func(uri string) (string, error) {
+		contents, err := sr.HandleResource(ctx, mcp.ReadResourceRequest{
+			Params: mcp.ReadResourceParams{URI: uri},
+		})
+		if err != nil {
+			return "", err
+		}
+		require.Len(t, contents, 1)
+		return testutil.GetTextResourceContents(t, contents[0]), nil
+	}
+
+	t.Run("views", func(t *testing.T) {
+		text, err := read("schema://test/views")
+		require.NoError(t, err)
+		assert.Contains(t, text, "adults")
+	})
+
+	t.Run("view schema", func(t *testing.T) {
+		text, err := read("schema://test/view/adults")
+		require.NoError(t, err)
+		assert.Contains(t, text, `"type": "view"`)
+		assert.Contains(t, text, "age")
+
+		_, err = read("schema://test/view/users")
+		assert.ErrorContains(t, err, "view 'users' not found")
+	})
+
+	t.Run("indexes", func(t *testing.T) {
+		text, err := read("schema://test/indexes")
+		require.NoError(t, err)
+		assert.Contains(t, text, "idx_products_name")
+	})
+
+	t.Run("triggers", func(t *testing.T) {
+		text, err := read("schema://test/triggers")
+		require.NoError(t, err)
+		assert.Contains(t, text, "users_audit")
+		assert.Contains(t, text, "DELETE")
+	})
+}
+
+func TestMultipleDatabases(t *testing.T) {
+	first := testutil.CreateTestDB(t)
+	defer first.Close()
+	second := testutil.CreateTestDB(t)
+	defer second.Close()
+
+	ctx := context.Background()
+	_, err := second.ExecuteContext(ctx, "CREATE TABLE orders (id INTEGER PRIMARY KEY, total REAL)")
+	require.NoError(t, err)
+
+	dbs := database.NewDatabases()
+	require.NoError(t, dbs.Add("first", first))
+	require.NoError(t, dbs.Add("second", second))
+	sr := New(dbs)
+
+	assert.Len(t, sr.GetResources(), 8)
+
+	read := func(uri string) (string, error) {
+		contents, err := sr.HandleResource(ctx, mcp.ReadResourceRequest{
+			Params: mcp.ReadResourceParams{URI: uri},
+		})
+		if err != nil {
+			return "", err
+		}
+		require.Len(t, contents, 1)
+		assert.Equal(t, uri, contents[0].(mcp.TextResourceContents).URI)
+		return testutil.GetTextResourceContents(t, contents[0]), nil
+	}
+
+	text, err := read("schema://second/tables")
+	require.NoError(t, err)
+	assert.Contains(t, text, "orders")
+
+	text, err = read("schema://first/tables")
+	require.NoError(t, err)
+	assert.NotContains(t, text, "orders")
+
+	text, err = read("schema://second/table/orders")
+	require.NoError(t, err)
+	assert.Contains(t, text, "total")
+
+	_, err = read("schema://first/table/orders")
+	assert.ErrorContains(t, err, "table 'orders' not found")
+
+	_, err = read("schema://third/tables")
+	assert.ErrorIs(t, err, database.ErrDatabaseNotFound)
+
+	_, err = read("schema://tables")
+	assert.ErrorContains(t, err, "unknown resource URI")
+}
+
+func TestAuthorization(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	authorizer, err := authz.New([]authz.Policy{
+		{Principals: []string{"alice"}, Databases: []string{testutil.TestDBName}, Read: []string{"users.name"}},
+	})
+	require.NoError(t, err)
+	sr := New(testutil.Databases(t, db), WithAuthorizer(authorizer))
+
+	read := func(ctx context.Context, uri string) (string, error) {
+		t.Helper()
+		contents, err := sr.HandleResource(ctx, mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: uri}})
+		if err != nil {
+			return "", err
+		}
+		return testutil.GetTextResourceContents(t, contents[0]), nil
+	}
+
+	alice := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "alice"})
+	text, err := read(alice, "schema://test/tables")
+	require.NoError(t, err)
+	assert.Contains(t, text, "users")
+	assert.NotContains(t, text, "products")
+
+	text, err = read(alice, "schema://test/table/users")
+	require.NoError(t, err)
+	assert.Contains(t, text, `"name": "name"`)
+	assert.NotContains(t, text, "email")
+
+	_, err = read(alice, "schema://test/table/products")
+	assert.EqualError(t, err, "table 'products' not found")
+
+	bob := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "bob"})
+	_, err = read(bob, "schema://test/tables")
+	assert.ErrorIs(t, err, authz.ErrNotAllowed)
+}
+
+func TestAccess(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	_, err := db.ExecuteContext(context.Background(), "CREATE VIEW contacts AS SELECT name, email FROM users")
+	require.NoError(t, err)
+
+	access, err := database.NewAccess([]string{"*"}, []string{"*"}, []string{"products", "users.email"})
+	require.NoError(t, err)
+	sr := New(testutil.Databases(t, db), WithAccess(access))
+
+	contents, err := sr.HandleResource(context.Background(),
+		mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "schema://test/tables"}})
+	require.NoError(t, err)
+	text := testutil.GetTextResourceContents(t, contents[0])
+	assert.Contains(t, text, "users")
+	assert.NotContains(t, text, "products")
+
+	contents, err = sr.HandleResource(context.Background(),
+		mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "schema://test/table/users"}})
+	require.NoError(t, err)
+	assert.NotContains(t, testutil.GetTextResourceContents(t, contents[0]), "email")
+
+	_, err = sr.HandleResource(context.Background(),
+		mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: "schema://test/table/products"}})
+	assert.EqualError(t, err, "table 'products' not found")
+
+	// The view reads a denied column: its definition and that column are hidden
+	for _, uri := range []string{"schema://test/views", "schema://test/view/contacts"} {
+		contents, err = sr.HandleResource(context.Background(), mcp.ReadResourceRequest{Params: mcp.ReadResourceParams{URI: uri}})
+		require.NoError(t, err, uri)
+		text = testutil.GetTextResourceContents(t, contents[0])
+		assert.Contains(t, text, "contacts", uri)
+		assert.NotContains(t, text, "email", uri)
+	}
+}
+
 func TestHandleUnknownResource(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	sr := New(db)
+	sr := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	request := mcp.ReadResourceRequest{
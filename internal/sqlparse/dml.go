@@ -0,0 +1,110 @@
+package sqlparse
+
+import "strings"
+
+// DML describes the target of an INSERT, REPLACE, UPDATE or DELETE statement
+type DML struct {
+	// Verb is INSERT, UPDATE or DELETE; REPLACE statements report INSERT
+	Verb string
+	// Schema is the optional schema qualifier of the target table
+	Schema string
+	// Table is the target table name
+	Table string
+	// Where is true when the statement has a WHERE clause outside any subquery
+	Where bool
+	// Returning is true when the statement has its own RETURNING clause
+	Returning bool
+}
+
+// DML parses a data modification statement, including one prefixed by a WITH clause;
+// ok is false for any other statement
+func (s Statement) DML() (d DML, ok bool) {
+	tokens := s.Tokens
+	if len(tokens) > 0 && tokens[0].Is("WITH") {
+		tokens = skipWith(tokens)
+	}
+	if len(tokens) == 0 {
+		return DML{}, false
+	}
+
+	rest := tokens[1:]
+	switch {
+	case tokens[0].Is("INSERT"):
+		d.Verb = "INSERT"
+		rest = skipConflictClause(rest)
+		if len(rest) == 0 || !rest[0].Is("INTO") {
+			return DML{}, false
+		}
+		rest = rest[1:]
+	case tokens[0].Is("REPLACE"):
+		d.Verb = "INSERT"
+		if len(rest) == 0 || !rest[0].Is("INTO") {
+			return DML{}, false
+		}
+		rest = rest[1:]
+	case tokens[0].Is("UPDATE"):
+		d.Verb = "UPDATE"
+		rest = skipConflictClause(rest)
+	case tokens[0].Is("DELETE"):
+		d.Verb = "DELETE"
+		if len(rest) == 0 || !rest[0].Is("FROM") {
+			return DML{}, false
+		}
+		rest = rest[1:]
+	default:
+		return DML{}, false
+	}
+
+	if len(rest) == 0 || (rest[0].Kind != TokenWord && rest[0].Kind != TokenQuoted) {
+		return DML{}, false
+	}
+	d.Table = unquote(rest[0])
+	rest = rest[1:]
+	if len(rest) >= 2 && rest[0].Kind == TokenPunct && rest[0].Text == "." {
+		d.Schema, d.Table = d.Table, unquote(rest[1])
+		rest = rest[2:]
+	}
+
+	depth := 0
+	for _, t := range rest {
+		switch {
+		case t.Kind == TokenPunct && t.Text == "(":
+			depth++
+		case t.Kind == TokenPunct && t.Text == ")":
+			depth--
+		case depth == 0 && t.Is("WHERE"):
+			d.Where = true
+		case depth == 0 && t.Is("RETURNING"):
+			d.Returning = true
+		}
+	}
+
+	return d, true
+}
+
+// skipWith returns the tokens following the common table expressions of a WITH clause
+func skipWith(tokens []Token) []Token {
+	depth := 0
+	for i, t := range tokens[1:] {
+		switch {
+		case t.Kind == TokenPunct && t.Text == "(":
+			depth++
+		case t.Kind == TokenPunct && t.Text == ")":
+			depth--
+		case depth == 0 && t.Kind == TokenWord:
+			switch strings.ToUpper(t.Text) {
+			case "SELECT", "VALUES", "INSERT", "REPLACE", "UPDATE", "DELETE":
+				return tokens[i+1:]
+			}
+		}
+	}
+	return nil
+}
+
+// skipConflictClause skips an OR ROLLBACK/ABORT/REPLACE/FAIL/IGNORE clause
+func skipConflictClause(tokens []Token) []Token {
+	if len(tokens) >= 2 && tokens[0].Is("OR") {
+		return tokens[2:]
+	}
+	return tokens
+}
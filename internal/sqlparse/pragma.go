@@ -0,0 +1,133 @@
+package sqlparse
+
+import "strings"
+
+// queryPragmas only report information, whether or not they are given an argument
+var queryPragmas = map[string]bool{
+	"collation_list":    true,
+	"compile_options":   true,
+	"data_version":      true,
+	"database_list":     true,
+	"foreign_key_check": true,
+	"foreign_key_list":  true,
+	"freelist_count":    true,
+	"function_list":     true,
+	"index_info":        true,
+	"index_list":        true,
+	"index_xinfo":       true,
+	"integrity_check":   true,
+	"module_list":       true,
+	"page_count":        true,
+	"pragma_list":       true,
+	"quick_check":       true,
+	"table_info":        true,
+	"table_list":        true,
+	"table_xinfo":       true,
+}
+
+// settingPragmas report a setting when called without an argument and change it when given one
+var settingPragmas = map[string]bool{
+	"application_id":            true,
+	"auto_vacuum":               true,
+	"automatic_index":           true,
+	"busy_timeout":              true,
+	"cache_size":                true,
+	"cache_spill":               true,
+	"cell_size_check":           true,
+	"defer_foreign_keys":        true,
+	"encoding":                  true,
+	"foreign_keys":              true,
+	"ignore_check_constraints":  true,
+	"journal_mode":              true,
+	"journal_size_limit":        true,
+	"legacy_alter_table":        true,
+	"locking_mode":              true,
+	"max_page_count":            true,
+	"mmap_size":                 true,
+	"page_size":                 true,
+	"query_only":                true,
+	"read_uncommitted":          true,
+	"recursive_triggers":        true,
+	"reverse_unordered_selects": true,
+	"schema_version":            true,
+	"secure_delete":             true,
+	"synchronous":               true,
+	"temp_store":                true,
+	"trusted_schema":            true,
+	"user_version":              true,
+	"wal_autocheckpoint":        true,
+}
+
+// databasePragmas change the database file, not the settings of the connection they run on
+var databasePragmas = map[string]bool{
+	"application_id":     true,
+	"incremental_vacuum": true,
+	"optimize":           true,
+	"user_version":       true,
+	"wal_checkpoint":     true,
+}
+
+// KnownPragma reports whether name, in lower case, is a pragma this package knows to be
+// read-only when called without an argument
+func KnownPragma(name string) bool {
+	return queryPragmas[name] || settingPragmas[name]
+}
+
+// Pragma describes the parts of a PRAGMA statement
+type Pragma struct {
+	// Schema is the optional schema qualifier, e.g. "main" in PRAGMA main.table_info(t)
+	Schema string
+	// Name is the lower-cased pragma name
+	Name string
+	// HasArgument is true for the PRAGMA name = value and PRAGMA name(value) forms
+	HasArgument bool
+}
+
+// Pragma parses a PRAGMA statement; ok is false when the statement is not a PRAGMA
+func (s Statement) Pragma() (p Pragma, ok bool) {
+	if s.Keyword() != "PRAGMA" || len(s.Tokens) < 2 {
+		return Pragma{}, false
+	}
+
+	rest := s.Tokens[1:]
+	if len(rest) >= 3 && rest[1].Kind == TokenPunct && rest[1].Text == "." {
+		p.Schema = unquote(rest[0])
+		rest = rest[2:]
+	}
+
+	p.Name = strings.ToLower(unquote(rest[0]))
+	p.HasArgument = len(rest) > 1
+	return p, true
+}
+
+// ReadOnly reports whether executing the pragma cannot change the database or connection state.
+// Unknown pragmas are never considered read-only.
+func (p Pragma) ReadOnly() bool {
+	if queryPragmas[p.Name] {
+		return true
+	}
+	return settingPragmas[p.Name] && !p.HasArgument
+}
+
+// ConnectionSafe reports whether executing the pragma leaves the settings of the connection
+// it runs on unchanged, so that it may run on a connection other callers share. Unknown
+// pragmas are never considered connection-safe.
+func (p Pragma) ConnectionSafe() bool {
+	return p.ReadOnly() || databasePragmas[p.Name]
+}
+
+// unquote returns the identifier named by a word or quoted token
+func unquote(t Token) string {
+	if t.Kind != TokenQuoted || len(t.Text) < 2 {
+		return t.Text
+	}
+	inner := t.Text[1 : len(t.Text)-1]
+	switch t.Text[0] {
+	case '"':
+		return strings.ReplaceAll(inner, `""`, `"`)
+	case '`':
+		return strings.ReplaceAll(inner, "``", "`")
+	default:
+		return inner
+	}
+}
@@ -0,0 +1,305 @@
+// Package sqlparse provides a lightweight SQLite tokenizer used to split and inspect SQL statements
+package sqlparse
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+const (
+	// TokenWord is a keyword or bare identifier
+	TokenWord TokenKind = iota
+	// TokenQuoted is a quoted identifier ("name", `name` or [name])
+	TokenQuoted
+	// TokenString is a string or blob literal
+	TokenString
+	// TokenNumber is a numeric literal
+	TokenNumber
+	// TokenParam is a bound parameter placeholder (?, ?NNN, :name, @name or $name)
+	TokenParam
+	// TokenPunct is an operator or punctuation character
+	TokenPunct
+)
+
+// Token is a single lexical token of a SQL statement
+type Token struct {
+	Kind TokenKind
+	Text string
+	// Pos is the byte offset of the token in its statement's Text
+	Pos int
+}
+
+// Is reports whether the token is the given keyword, compared case-insensitively
+func (t Token) Is(keyword string) bool {
+	return t.Kind == TokenWord && strings.EqualFold(t.Text, keyword)
+}
+
+// Statement is a single SQL statement with its comments stripped
+type Statement struct {
+	// Text is the statement source without leading or trailing comments and without the terminating semicolon
+	Text string
+	// Tokens holds the statement tokens, excluding comments and whitespace
+	Tokens []Token
+}
+
+// Keyword returns the upper-cased leading keyword of the statement, or "" for an empty statement
+func (s Statement) Keyword() string {
+	if len(s.Tokens) == 0 || s.Tokens[0].Kind != TokenWord {
+		return ""
+	}
+	return strings.ToUpper(s.Tokens[0].Text)
+}
+
+// Compound reports whether the statement combines SELECTs with UNION, INTERSECT or EXCEPT,
+// at the top level or in a subquery
+func (s Statement) Compound() bool {
+	for _, t := range s.Tokens {
+		if t.Is("UNION") || t.Is("INTERSECT") || t.Is("EXCEPT") {
+			return true
+		}
+	}
+	return false
+}
+
+// VacuumInto reports whether the statement is a VACUUM INTO, which writes a copy of the
+// database to a file
+func (s Statement) VacuumInto() bool {
+	if s.Keyword() != "VACUUM" {
+		return false
+	}
+	for _, t := range s.Tokens[1:] {
+		if t.Is("INTO") {
+			return true
+		}
+	}
+	return false
+}
+
+// Split splits SQL source into statements, dropping comments and empty statements.
+// Semicolons inside string literals, quoted identifiers and CREATE TRIGGER bodies
+// do not terminate a statement.
+func Split(sql string) ([]Statement, error) {
+	l := &lexer{src: sql}
+
+	var statements []Statement
+	var current []Token
+	start, end := -1, -1
+	depth := 0
+
+	flush := func() {
+		for i := range current {
+			current[i].Pos -= start
+		}
+		if len(current) > 0 {
+			statements = append(statements, Statement{
+				Text:   sql[start:end],
+				Tokens: current,
+			})
+		}
+		current = nil
+		start, end = -1, -1
+		depth = 0
+	}
+
+	for {
+		tok, pos, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if pos < 0 {
+			break
+		}
+
+		if tok.Kind == TokenPunct && tok.Text == ";" && depth == 0 {
+			flush()
+			continue
+		}
+
+		if start < 0 {
+			start = pos
+		}
+		end = l.pos
+		tok.Pos = pos
+		current = append(current, tok)
+
+		// Trigger bodies contain semicolons between BEGIN and the matching END
+		if tok.Kind == TokenWord && isCreateTrigger(current) {
+			switch {
+			case tok.Is("BEGIN"), tok.Is("CASE"):
+				depth++
+			case tok.Is("END") && depth > 0:
+				depth--
+			}
+		}
+	}
+	flush()
+
+	return statements, nil
+}
+
+// isCreateTrigger reports whether tokens start a CREATE [TEMP|TEMPORARY] TRIGGER statement
+func isCreateTrigger(tokens []Token) bool {
+	if len(tokens) < 2 || !tokens[0].Is("CREATE") {
+		return false
+	}
+	if tokens[1].Is("TEMP") || tokens[1].Is("TEMPORARY") {
+		return len(tokens) >= 3 && tokens[2].Is("TRIGGER")
+	}
+	return tokens[1].Is("TRIGGER")
+}
+
+// lexer produces tokens from SQL source, skipping whitespace and comments
+type lexer struct {
+	src string
+	pos int
+}
+
+// next returns the next token and its start offset, or a negative offset at end of input
+func (l *lexer) next() (Token, int, error) {
+	l.skipSpaceAndComments()
+	if l.pos >= len(l.src) {
+		return Token{}, -1, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+
+	switch {
+	case c == '\'':
+		if err := l.skipQuoted('\'', '\''); err != nil {
+			return Token{}, start, err
+		}
+		return Token{Kind: TokenString, Text: l.src[start:l.pos]}, start, nil
+	case c == '"' || c == '`':
+		if err := l.skipQuoted(c, c); err != nil {
+			return Token{}, start, err
+		}
+		return Token{Kind: TokenQuoted, Text: l.src[start:l.pos]}, start, nil
+	case c == '[':
+		if err := l.skipQuoted('[', ']'); err != nil {
+			return Token{}, start, err
+		}
+		return Token{Kind: TokenQuoted, Text: l.src[start:l.pos]}, start, nil
+	case (c == 'x' || c == 'X') && l.pos+1 < len(l.src) && l.src[l.pos+1] == '\'':
+		l.pos++
+		if err := l.skipQuoted('\'', '\''); err != nil {
+			return Token{}, start, err
+		}
+		return Token{Kind: TokenString, Text: l.src[start:l.pos]}, start, nil
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		l.skipNumber()
+		return Token{Kind: TokenNumber, Text: l.src[start:l.pos]}, start, nil
+	case c == '?':
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return Token{Kind: TokenParam, Text: l.src[start:l.pos]}, start, nil
+	case (c == ':' || c == '@' || c == '$') && l.pos+1 < len(l.src) && l.isIdentAt(l.pos+1):
+		l.pos++
+		l.skipIdent()
+		return Token{Kind: TokenParam, Text: l.src[start:l.pos]}, start, nil
+	case l.isIdentAt(l.pos):
+		l.skipIdent()
+		return Token{Kind: TokenWord, Text: l.src[start:l.pos]}, start, nil
+	default:
+		_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		l.pos += size
+		return Token{Kind: TokenPunct, Text: l.src[start:l.pos]}, start, nil
+	}
+}
+
+// skipSpaceAndComments advances past whitespace, -- line comments and /* block comments */
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			if idx := strings.IndexByte(l.src[l.pos:], '\n'); idx >= 0 {
+				l.pos += idx + 1
+			} else {
+				l.pos = len(l.src)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			// An unterminated block comment runs to the end of input, as in SQLite
+			if idx := strings.Index(l.src[l.pos+2:], "*/"); idx >= 0 {
+				l.pos += idx + 4
+			} else {
+				l.pos = len(l.src)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			if !unicode.IsSpace(r) {
+				return
+			}
+			l.pos += size
+		}
+	}
+}
+
+// skipQuoted advances past a quoted token; a doubled closing quote is an escaped quote
+func (l *lexer) skipQuoted(open, closing byte) error {
+	start := l.pos
+	l.pos++ // opening quote
+	for l.pos < len(l.src) {
+		if l.src[l.pos] == closing {
+			if open != '[' && l.pos+1 < len(l.src) && l.src[l.pos+1] == closing {
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			return nil
+		}
+		l.pos++
+	}
+	return fmt.Errorf("unterminated quoted token starting at offset %d", start)
+}
+
+// skipNumber advances past a decimal, real or hexadecimal numeric literal
+func (l *lexer) skipNumber() {
+	if strings.HasPrefix(l.src[l.pos:], "0x") || strings.HasPrefix(l.src[l.pos:], "0X") {
+		l.pos += 2
+		for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || strings.IndexByte("abcdefABCDEF", l.src[l.pos]) >= 0) {
+			l.pos++
+		}
+		return
+	}
+	for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+		l.pos++
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+}
+
+// skipIdent advances past identifier characters
+func (l *lexer) skipIdent() {
+	for l.pos < len(l.src) && (l.isIdentAt(l.pos) || isDigit(l.src[l.pos]) || l.src[l.pos] == '$') {
+		_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		l.pos += size
+	}
+}
+
+// isIdentAt reports whether an identifier can start at offset i
+func (l *lexer) isIdentAt(i int) bool {
+	c := l.src[i]
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return c >= utf8.RuneSelf
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
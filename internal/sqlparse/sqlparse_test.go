@@ -0,0 +1,261 @@
+package sqlparse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	t.Run("strips comments", func(t *testing.T) {
+		statements, err := Split("  -- leading comment\n/* block */ SELECT 1 -- trailing\n")
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		assert.Equal(t, "SELECT 1", statements[0].Text)
+		assert.Equal(t, "SELECT", statements[0].Keyword())
+	})
+
+	t.Run("multiple statements", func(t *testing.T) {
+		statements, err := Split("SELECT 1; DELETE FROM users;;")
+		require.NoError(t, err)
+		require.Len(t, statements, 2)
+		assert.Equal(t, "SELECT", statements[0].Keyword())
+		assert.Equal(t, "DELETE", statements[1].Keyword())
+	})
+
+	t.Run("semicolons in literals and identifiers", func(t *testing.T) {
+		statements, err := Split(`SELECT 'a;b', "c;d", [e;f], ` + "`g;h`" + ` FROM t`)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+	})
+
+	t.Run("escaped quotes", func(t *testing.T) {
+		statements, err := Split("SELECT 'it''s; fine'")
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		assert.Equal(t, TokenString, statements[0].Tokens[1].Kind)
+	})
+
+	t.Run("trigger body", func(t *testing.T) {
+		statements, err := Split(`
+			CREATE TRIGGER t AFTER INSERT ON users BEGIN
+				UPDATE users SET age = CASE WHEN age IS NULL THEN 0 ELSE age END;
+				DELETE FROM log;
+			END;
+			SELECT 1`)
+		require.NoError(t, err)
+		require.Len(t, statements, 2)
+		assert.Equal(t, "CREATE", statements[0].Keyword())
+		assert.Equal(t, "SELECT", statements[1].Keyword())
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		statements, err := Split("SELECT * FROM t WHERE a = ? AND b = ?2 AND c = :c AND d = @d AND e = $e")
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+
+		var params []string
+		for _, tok := range statements[0].Tokens {
+			if tok.Kind == TokenParam {
+				params = append(params, tok.Text)
+			}
+		}
+		assert.Equal(t, []string{"?", "?2", ":c", "@d", "$e"}, params)
+	})
+
+	t.Run("unterminated string", func(t *testing.T) {
+		_, err := Split("SELECT 'oops")
+		assert.Error(t, err)
+	})
+
+	t.Run("only comments", func(t *testing.T) {
+		statements, err := Split("-- nothing here\n/* or here */")
+		require.NoError(t, err)
+		assert.Empty(t, statements)
+	})
+}
+
+func TestPragma(t *testing.T) {
+	tests := []struct {
+		sql            string
+		name           string
+		schema         string
+		readOnly       bool
+		connectionSafe bool
+	}{
+		{"PRAGMA table_info(users)", "table_info", "", true, true},
+		{"PRAGMA main.index_list('users')", "index_list", "main", true, true},
+		{"PRAGMA user_version", "user_version", "", true, true},
+		{"PRAGMA user_version = 5", "user_version", "", false, true},
+		{"PRAGMA user_version(5)", "user_version", "", false, true},
+		{"PRAGMA \"foreign_keys\" = OFF", "foreign_keys", "", false, false},
+		{"PRAGMA busy_timeout = 0", "busy_timeout", "", false, false},
+		{"PRAGMA main.journal_mode = DELETE", "journal_mode", "main", false, false},
+		{"PRAGMA wal_checkpoint", "wal_checkpoint", "", false, true},
+		{"PRAGMA optimize", "optimize", "", false, true},
+		{"PRAGMA vdbe_trace = ON", "vdbe_trace", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			statements, err := Split(tt.sql)
+			require.NoError(t, err)
+			require.Len(t, statements, 1)
+
+			pragma, ok := statements[0].Pragma()
+			require.True(t, ok)
+			assert.Equal(t, tt.name, pragma.Name)
+			assert.Equal(t, tt.schema, pragma.Schema)
+			assert.Equal(t, tt.readOnly, pragma.ReadOnly())
+			assert.Equal(t, tt.connectionSafe, pragma.ConnectionSafe())
+		})
+	}
+
+	t.Run("not a pragma", func(t *testing.T) {
+		statements, err := Split("SELECT 1")
+		require.NoError(t, err)
+		_, ok := statements[0].Pragma()
+		assert.False(t, ok)
+	})
+}
+
+func TestDML(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want DML
+	}{
+		{"INSERT INTO users (name) VALUES ('a')", DML{Verb: "INSERT", Table: "users"}},
+		{"INSERT OR IGNORE INTO main.users VALUES (1)", DML{Verb: "INSERT", Schema: "main", Table: "users"}},
+		{"REPLACE INTO \"my table\" VALUES (1)", DML{Verb: "INSERT", Table: "my table"}},
+		{"UPDATE OR FAIL [users] SET age = 1 WHERE id = 2", DML{Verb: "UPDATE", Table: "users", Where: true}},
+		{"DELETE FROM users WHERE id IN (SELECT id FROM old) RETURNING id",
+			DML{Verb: "DELETE", Table: "users", Where: true, Returning: true}},
+		{"WITH old(id) AS (SELECT id FROM users WHERE age > 60) DELETE FROM users WHERE id IN old",
+			DML{Verb: "DELETE", Table: "users", Where: true}},
+		{"WITH old(id) AS (SELECT id FROM users WHERE age > 60) DELETE FROM users",
+			DML{Verb: "DELETE", Table: "users"}},
+		{"UPDATE users SET name = (SELECT 'x' FROM t WHERE t.id = 1)", DML{Verb: "UPDATE", Table: "users"}},
+		{"UPDATE users SET name = (SELECT 'returning' FROM t) WHERE id = 1", DML{Verb: "UPDATE", Table: "users", Where: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			statements, err := Split(tt.sql)
+			require.NoError(t, err)
+			require.Len(t, statements, 1)
+
+			dml, ok := statements[0].DML()
+			require.True(t, ok)
+			assert.Equal(t, tt.want, dml)
+		})
+	}
+
+	for _, sql := range []string{"SELECT 1", "WITH x AS (SELECT 1) SELECT * FROM x", "CREATE TABLE t (a)"} {
+		t.Run(sql, func(t *testing.T) {
+			statements, err := Split(sql)
+			require.NoError(t, err)
+			_, ok := statements[0].DML()
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestTableDef(t *testing.T) {
+	statements, err := Split(`CREATE TABLE IF NOT EXISTS "order items" (
+		id INTEGER PRIMARY KEY,
+		sku TEXT NOT NULL COLLATE NOCASE CHECK (length(sku) > 0),
+		qty INTEGER DEFAULT (1) CHECK(qty >= 0),
+		price REAL,
+		CONSTRAINT positive_total CHECK (qty * price >= 0),
+		FOREIGN KEY (sku) REFERENCES products(sku)
+	) STRICT`)
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	def, ok := statements[0].TableDef()
+	require.True(t, ok)
+	assert.Equal(t, []ColumnDef{
+		{Name: "id"},
+		{Name: "sku", Collation: "NOCASE", Checks: []string{"length(sku) > 0"}},
+		{Name: "qty", Checks: []string{"qty >= 0"}},
+		{Name: "price"},
+	}, def.Columns)
+	assert.Equal(t, []string{"qty * price >= 0"}, def.Checks)
+
+	for _, sql := range []string{"CREATE TABLE t AS SELECT 1", "CREATE INDEX i ON t (a)", "SELECT (1)"} {
+		statements, err := Split(sql)
+		require.NoError(t, err)
+		_, ok := statements[0].TableDef()
+		assert.False(t, ok, sql)
+	}
+}
+
+func TestVirtualTableModule(t *testing.T) {
+	tests := map[string]string{
+		"CREATE VIRTUAL TABLE docs USING fts5(title, body)":             "fts5",
+		"CREATE VIRTUAL TABLE IF NOT EXISTS main.boxes USING RTREE(id)": "rtree",
+		"CREATE TABLE t (a)": "",
+	}
+	for sql, want := range tests {
+		statements, err := Split(sql)
+		require.NoError(t, err)
+		module, ok := statements[0].VirtualTableModule()
+		assert.Equal(t, want != "", ok, sql)
+		assert.Equal(t, want, module, sql)
+	}
+}
+
+func TestCompound(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT a FROM t UNION ALL SELECT b FROM t":                             true,
+		"SELECT a FROM (SELECT a FROM t except SELECT a FROM u)":                true,
+		"WITH c AS (SELECT a FROM t INTERSECT SELECT a FROM u) SELECT * FROM c": true,
+		"SELECT 'union' AS \"union\" FROM t":                                    false,
+		"SELECT a FROM t WHERE a IN (SELECT a FROM u)":                          false,
+	}
+	for sql, want := range tests {
+		statements, err := Split(sql)
+		require.NoError(t, err)
+		assert.Equal(t, want, statements[0].Compound(), sql)
+	}
+}
+
+func TestVacuumInto(t *testing.T) {
+	tests := map[string]bool{
+		"VACUUM":                          false,
+		"VACUUM main":                     false,
+		"VACUUM INTO '/tmp/copy.db'":      true,
+		"vacuum main into '/tmp/copy.db'": true,
+		"SELECT 1 AS into_col":            false,
+	}
+	for sql, want := range tests {
+		statements, err := Split(sql)
+		require.NoError(t, err)
+		assert.Equal(t, want, statements[0].VacuumInto(), sql)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want TriggerDef
+	}{
+		{"CREATE TRIGGER t AFTER INSERT ON users BEGIN SELECT 1; END", TriggerDef{Timing: "AFTER", Event: "INSERT"}},
+		{"CREATE TEMP TRIGGER IF NOT EXISTS t UPDATE OF name ON users BEGIN SELECT 1; END",
+			TriggerDef{Timing: "BEFORE", Event: "UPDATE"}},
+		{"CREATE TRIGGER t INSTEAD OF DELETE ON v BEGIN SELECT 1; END", TriggerDef{Timing: "INSTEAD OF", Event: "DELETE"}},
+	}
+	for _, tt := range tests {
+		statements, err := Split(tt.sql)
+		require.NoError(t, err)
+		def, ok := statements[0].Trigger()
+		require.True(t, ok, tt.sql)
+		assert.Equal(t, tt.want, def, tt.sql)
+	}
+
+	statements, err := Split("CREATE TABLE t (a)")
+	require.NoError(t, err)
+	_, ok := statements[0].Trigger()
+	assert.False(t, ok)
+}
@@ -0,0 +1,188 @@
+package sqlparse
+
+import "strings"
+
+// TableDef holds the parts of a CREATE TABLE statement that SQLite does not report through pragmas
+type TableDef struct {
+	// Columns holds the column definitions in declaration order
+	Columns []ColumnDef
+	// Checks holds the expressions of table-level CHECK constraints
+	Checks []string
+}
+
+// ColumnDef holds the parts of a column definition that SQLite does not report through pragmas
+type ColumnDef struct {
+	// Name is the column name
+	Name string
+	// Collation is the declared COLLATE sequence, or "" for the default
+	Collation string
+	// Checks holds the expressions of column CHECK constraints
+	Checks []string
+}
+
+// TableDef parses the column list of a CREATE TABLE statement; ok is false for any other
+// statement and for CREATE TABLE ... AS SELECT
+func (s Statement) TableDef() (def TableDef, ok bool) {
+	if s.Keyword() != "CREATE" {
+		return TableDef{}, false
+	}
+
+	// Find the opening parenthesis of the column list after CREATE [TEMP] TABLE [IF NOT EXISTS] name
+	open := -1
+	for i, t := range s.Tokens {
+		if t.Is("AS") {
+			return TableDef{}, false
+		}
+		if t.Kind == TokenPunct && t.Text == "(" {
+			open = i
+			break
+		}
+	}
+	if open < 0 || len(s.Tokens) < 2 || !isCreateTable(s.Tokens) {
+		return TableDef{}, false
+	}
+
+	for _, item := range splitList(s.Tokens[open+1:]) {
+		if len(item) == 0 {
+			continue
+		}
+		switch {
+		case item[0].Is("CONSTRAINT"), item[0].Is("PRIMARY"), item[0].Is("UNIQUE"),
+			item[0].Is("CHECK"), item[0].Is("FOREIGN"):
+			def.Checks = append(def.Checks, s.checks(item)...)
+		default:
+			col := ColumnDef{Name: unquote(item[0]), Checks: s.checks(item)}
+			for i := 1; i+1 < len(item); i++ {
+				if item[i].Is("COLLATE") {
+					col.Collation = unquote(item[i+1])
+				}
+			}
+			def.Columns = append(def.Columns, col)
+		}
+	}
+
+	return def, true
+}
+
+// checks returns the expressions of the CHECK constraints in a column or table constraint definition
+func (s Statement) checks(item []Token) []string {
+	var exprs []string
+	depth := 0
+	for i, t := range item {
+		switch {
+		case t.Kind == TokenPunct && t.Text == "(":
+			depth++
+		case t.Kind == TokenPunct && t.Text == ")":
+			depth--
+		case depth == 0 && t.Is("CHECK") && i+1 < len(item):
+			if expr, ok := s.parenthesized(item[i+1:]); ok {
+				exprs = append(exprs, expr)
+			}
+		}
+	}
+	return exprs
+}
+
+// parenthesized returns the source text inside the parenthesized group that tokens start with
+func (s Statement) parenthesized(tokens []Token) (string, bool) {
+	if tokens[0].Kind != TokenPunct || tokens[0].Text != "(" {
+		return "", false
+	}
+
+	depth := 0
+	for i, t := range tokens {
+		if t.Kind != TokenPunct {
+			continue
+		}
+		switch t.Text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				if i == 1 {
+					return "", true
+				}
+				return s.Text[tokens[1].Pos:t.Pos], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitList splits the tokens following an opening parenthesis into the comma-separated
+// items of the list, stopping at the matching closing parenthesis
+func splitList(tokens []Token) [][]Token {
+	var items [][]Token
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.Kind != TokenPunct {
+			continue
+		}
+		switch {
+		case t.Text == "(":
+			depth++
+		case t.Text == ")" && depth > 0:
+			depth--
+		case t.Text == ")":
+			return append(items, tokens[start:i])
+		case t.Text == "," && depth == 0:
+			items = append(items, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, tokens[start:])
+}
+
+// isCreateTable reports whether tokens start a CREATE [TEMP|TEMPORARY] TABLE statement
+func isCreateTable(tokens []Token) bool {
+	if tokens[1].Is("TEMP") || tokens[1].Is("TEMPORARY") {
+		return len(tokens) >= 3 && tokens[2].Is("TABLE")
+	}
+	return tokens[1].Is("TABLE")
+}
+
+// VirtualTableModule returns the lower-cased module name of a CREATE VIRTUAL TABLE statement,
+// e.g. "fts5"; ok is false for any other statement
+func (s Statement) VirtualTableModule() (module string, ok bool) {
+	if s.Keyword() != "CREATE" || len(s.Tokens) < 3 || !s.Tokens[1].Is("VIRTUAL") || !s.Tokens[2].Is("TABLE") {
+		return "", false
+	}
+	for i, t := range s.Tokens[3:] {
+		if t.Is("USING") && 3+i+1 < len(s.Tokens) {
+			return strings.ToLower(unquote(s.Tokens[3+i+1])), true
+		}
+	}
+	return "", false
+}
+
+// TriggerDef describes when a trigger fires
+type TriggerDef struct {
+	// Timing is BEFORE, AFTER or INSTEAD OF
+	Timing string
+	// Event is DELETE, INSERT or UPDATE
+	Event string
+}
+
+// Trigger parses a CREATE TRIGGER statement; ok is false for any other statement
+func (s Statement) Trigger() (def TriggerDef, ok bool) {
+	if !isCreateTrigger(s.Tokens) {
+		return TriggerDef{}, false
+	}
+
+	// SQLite fires triggers BEFORE the event unless told otherwise
+	def.Timing = "BEFORE"
+	for i, t := range s.Tokens {
+		switch {
+		case t.Is("ON"):
+			return def, def.Event != ""
+		case t.Is("BEFORE"), t.Is("AFTER"):
+			def.Timing = strings.ToUpper(t.Text)
+		case t.Is("INSTEAD") && i+1 < len(s.Tokens) && s.Tokens[i+1].Is("OF"):
+			def.Timing = "INSTEAD OF"
+		case def.Event == "" && (t.Is("DELETE") || t.Is("INSERT") || t.Is("UPDATE")):
+			def.Event = strings.ToUpper(t.Text)
+		}
+	}
+	return TriggerDef{}, false
+}
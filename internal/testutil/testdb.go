@@ -66,6 +66,17 @@ func CreateTestDB(t *testing.T) *database.DB {
 	return db
 }
 
+// TestDBName is the name Databases registers the test database under
+const TestDBName = "test"
+
+// Databases returns a database set serving db as the default database named TestDBName
+func Databases(t *testing.T, db *database.DB) *database.Databases {
+	t.Helper()
+	dbs := database.NewDatabases()
+	require.NoError(t, dbs.Add(TestDBName, db))
+	return dbs
+}
+
 // GetTextContent is a helper function to extract text from MCP Content
 func GetTextContent(t *testing.T, content mcp.Content) string {
 	t.Helper()
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+// WithAuthorizer restricts authenticated callers to the tools, databases, statements and
+// tables their policies allow. Callers without a principal, such as stdio clients, are not
+// restricted.
+func WithAuthorizer(authorizer *authz.Authorizer) Option {
+	return func(qt *QueryTools) {
+		qt.authorizer = authorizer
+	}
+}
+
+// WithAccess hides tables and columns from every caller. Statements that use them are
+// rejected by SQLite as they are compiled, and the schema tools leave them out.
+func WithAccess(access *database.Access) Option {
+	return func(qt *QueryTools) {
+		qt.access = access
+	}
+}
+
+// WithMasks masks the values of query results and dry run samples for every caller
+func WithMasks(masks *database.Masks) Option {
+	return func(qt *QueryTools) {
+		qt.masks = masks
+	}
+}
+
+// FilterTools hides the tools the caller may not use. It is a server.ToolFilterFunc.
+func (qt *QueryTools) FilterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	allowed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if qt.authorizer.AllowsToolContext(ctx, tool.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+// grant returns what the caller may do with the tool called by request on a database
+func (qt *QueryTools) grant(ctx context.Context, request mcp.CallToolRequest, name string) (*authz.Grant, error) {
+	return qt.authorizer.GrantContext(ctx, request.Params.Name, name)
+}
+
+// authorizeStatement checks that grant allows a classified statement and returns a context
+// carrying the tables and columns the statement may use
+func authorizeStatement(
+	ctx context.Context, grant *authz.Grant, stmt sqlparse.Statement, kind statementKind,
+) (context.Context, error) {
+	class := statementClass(stmt, kind)
+	if !grant.Allows(class) {
+		return nil, fmt.Errorf("%w: %s statements", authz.ErrNotAllowed, class)
+	}
+	return database.WithAccess(ctx, grant.Access(class)), nil
+}
+
+// statementClass returns the authz statement kind of a classified statement
+func statementClass(stmt sqlparse.Statement, kind statementKind) string {
+	if kind == statementRead {
+		return authz.StatementRead
+	}
+	if dml, ok := stmt.DML(); ok {
+		switch dml.Verb {
+		case "INSERT":
+			return authz.StatementInsert
+		case "UPDATE":
+			return authz.StatementUpdate
+		case "DELETE":
+			return authz.StatementDelete
+		}
+	}
+	switch stmt.Keyword() {
+	case "CREATE", "DROP", "ALTER":
+		return authz.StatementDDL
+	}
+	return authz.StatementOther
+}
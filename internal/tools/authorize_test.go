@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/testutil"
+)
+
+func TestAuthorization(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	authorizer, err := authz.New([]authz.Policy{
+		{
+			Name:       "catalog",
+			Scopes:     []string{"catalog"},
+			Tools:      []string{"execute_query", "execute_statement", "list_tables", "describe_table", "list_databases"},
+			Statements: []string{authz.StatementRead, authz.StatementUpdate},
+			Read:       []string{"products", "users.name"},
+			Write:      []string{"products.price"},
+		},
+	})
+	require.NoError(t, err)
+	qt := New(testutil.Databases(t, db), WithAuthorizer(authorizer))
+
+	catalog := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "carol", Scopes: []string{"catalog"}})
+	stranger := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "dave"})
+
+	call := func(ctx context.Context, name string, args map[string]interface{}) (string, bool) {
+		t.Helper()
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		require.NoError(t, err)
+		return testutil.GetTextContent(t, result.Content[0]), result.IsError
+	}
+
+	t.Run("tools", func(t *testing.T) {
+		text, isError := call(catalog, "list_views", nil)
+		assert.True(t, isError)
+		assert.Contains(t, text, "not allowed: tool list_views")
+
+		_, isError = call(stranger, "list_tables", nil)
+		assert.True(t, isError)
+
+		var names []string
+		for _, tool := range qt.FilterTools(catalog, qt.GetTools()) {
+			names = append(names, tool.Name)
+		}
+		assert.ElementsMatch(t,
+			[]string{"execute_query", "execute_statement", "list_tables", "describe_table", "list_databases"}, names)
+		assert.Empty(t, qt.FilterTools(stranger, qt.GetTools()))
+		assert.Len(t, qt.FilterTools(context.Background(), qt.GetTools()), len(qt.GetTools()))
+	})
+
+	t.Run("tables and columns", func(t *testing.T) {
+		text, isError := call(catalog, "execute_query", map[string]interface{}{"query": "SELECT name FROM users"})
+		assert.False(t, isError, text)
+		assert.Contains(t, text, "Alice")
+
+		text, isError = call(catalog, "execute_query", map[string]interface{}{"query": "SELECT email FROM users"})
+		assert.True(t, isError)
+		assert.Contains(t, text, "access denied: reading users.email is not allowed")
+	})
+
+	t.Run("statement kinds", func(t *testing.T) {
+		text, isError := call(catalog, "execute_statement",
+			map[string]interface{}{"statement": "UPDATE products SET price = price * 2 WHERE id = 1"})
+		assert.False(t, isError, text)
+
+		text, isError = call(catalog, "execute_statement",
+			map[string]interface{}{"statement": "UPDATE products SET name = 'x' WHERE id = 1"})
+		assert.True(t, isError)
+		assert.Contains(t, text, "writing products.name")
+
+		text, isError = call(catalog, "execute_statement", map[string]interface{}{"statement": "DELETE FROM products WHERE id = 2"})
+		assert.True(t, isError)
+		assert.Contains(t, text, "not allowed: delete statements")
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		text, isError := call(catalog, "list_tables", nil)
+		assert.False(t, isError)
+		assert.Contains(t, text, "products")
+		assert.Contains(t, text, "users")
+
+		text, isError = call(catalog, "describe_table", map[string]interface{}{"table_name": "users"})
+		assert.False(t, isError)
+		assert.Contains(t, text, `"name": "name"`)
+		assert.NotContains(t, text, "email")
+	})
+
+	t.Run("databases", func(t *testing.T) {
+		text, isError := call(catalog, "list_databases", nil)
+		assert.False(t, isError)
+		assert.Contains(t, text, testutil.TestDBName)
+
+		text, isError = call(catalog, "execute_query",
+			map[string]interface{}{"query": "SELECT 1", "database": "other"})
+		assert.True(t, isError)
+		assert.Contains(t, text, "other")
+	})
+
+	t.Run("unauthenticated callers", func(t *testing.T) {
+		text, isError := call(context.Background(), "execute_query", map[string]interface{}{"query": "SELECT email FROM users"})
+		assert.False(t, isError)
+		assert.Contains(t, text, "alice@example.com")
+	})
+}
+
+func TestAuthorizationHidesTables(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	authorizer, err := authz.New([]authz.Policy{{Principals: []string{"*"}, Read: []string{"products"}}})
+	require.NoError(t, err)
+	qt := New(testutil.Databases(t, db), WithAuthorizer(authorizer))
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Name: "erin"})
+
+	for name, args := range map[string]map[string]interface{}{
+		"list_tables":    nil,
+		"list_indexes":   nil,
+		"describe_table": {"table_name": "users"},
+	} {
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name, Arguments: args}})
+		require.NoError(t, err)
+		assert.NotContains(t, testutil.GetTextContent(t, result.Content[0]), `"users"`, name)
+	}
+
+	result, err := qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+		Name: "list_indexes", Arguments: map[string]interface{}{"table_name": "users"},
+	}})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Equal(t, "Table 'users' not found", testutil.GetTextContent(t, result.Content[0]))
+}
+
+func TestAccessLists(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+	_, err := db.ExecuteContext(context.Background(), "CREATE VIEW contacts AS SELECT name, email FROM users")
+	require.NoError(t, err)
+
+	access, err := database.NewAccess([]string{"*"}, []string{"*"}, []string{"products", "users.email"})
+	require.NoError(t, err)
+	qt := New(testutil.Databases(t, db), WithAccess(access))
+
+	call := func(name string, args map[string]interface{}) (string, bool) {
+		t.Helper()
+		result, err := qt.HandleTool(context.Background(), mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		require.NoError(t, err)
+		return testutil.GetTextContent(t, result.Content[0]), result.IsError
+	}
+
+	text, isError := call("execute_query", map[string]interface{}{"query": "SELECT name FROM users"})
+	assert.False(t, isError, text)
+
+	for _, query := range []string{
+		"SELECT * FROM users",
+		"SELECT name FROM users WHERE email LIKE '%@example.com'",
+		"SELECT name FROM users WHERE id IN (SELECT id FROM products)",
+		"SELECT u.name, p.name FROM users u JOIN products p ON p.id = u.id",
+		"SELECT email FROM contacts",
+	} {
+		text, isError := call("execute_query", map[string]interface{}{"query": query})
+		assert.True(t, isError, query)
+		assert.Contains(t, text, "access denied", query)
+	}
+
+	text, isError = call("list_tables", nil)
+	assert.False(t, isError)
+	assert.NotContains(t, text, "products")
+
+	text, isError = call("describe_table", map[string]interface{}{"table_name": "users"})
+	assert.False(t, isError)
+	assert.NotContains(t, text, "email")
+
+	text, isError = call("describe_table", map[string]interface{}{"table_name": "products"})
+	assert.True(t, isError)
+	assert.Contains(t, text, "not found")
+
+	// The view reads a denied column: its definition and that column are hidden
+	text, isError = call("list_views", nil)
+	assert.False(t, isError)
+	assert.Contains(t, text, "contacts")
+	assert.NotContains(t, text, "email")
+
+	text, isError = call("describe_table", map[string]interface{}{"table_name": "contacts"})
+	assert.False(t, isError)
+	assert.Contains(t, text, "name")
+	assert.NotContains(t, text, "email")
+}
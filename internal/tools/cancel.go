@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MethodNotificationCancelled is the MCP notification a client sends to cancel an in-flight request
+const MethodNotificationCancelled = "notifications/cancelled"
+
+// requestIDMetaKey is the _meta field used to carry the JSON-RPC request ID from the
+// before-call hook to the tool handler, which mcp-go does not otherwise expose
+const requestIDMetaKey = "sqlite-mcp/requestId"
+
+// inflightCalls tracks running tool calls so they can be cancelled by the client
+type inflightCalls struct {
+	mu    sync.Mutex
+	calls map[string]context.CancelFunc
+}
+
+// newInflightCalls creates an empty in-flight call registry
+func newInflightCalls() *inflightCalls {
+	return &inflightCalls{calls: make(map[string]context.CancelFunc)}
+}
+
+// add registers cancel under key and returns a function that removes it again
+func (ic *inflightCalls) add(key string, cancel context.CancelFunc) func() {
+	ic.mu.Lock()
+	ic.calls[key] = cancel
+	ic.mu.Unlock()
+
+	return func() {
+		ic.mu.Lock()
+		delete(ic.calls, key)
+		ic.mu.Unlock()
+	}
+}
+
+// cancel cancels the call registered under key, reporting whether one was found
+func (ic *inflightCalls) cancel(key string) bool {
+	ic.mu.Lock()
+	cancel, ok := ic.calls[key]
+	ic.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// TrackRequest is an OnBeforeCallTool hook that records the JSON-RPC request ID on the
+// tool call so that a later notifications/cancelled can find and interrupt it
+func (*QueryTools) TrackRequest(_ context.Context, id any, request *mcp.CallToolRequest) {
+	if id == nil {
+		return
+	}
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	if request.Params.Meta.AdditionalFields == nil {
+		request.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	request.Params.Meta.AdditionalFields[requestIDMetaKey] = fmt.Sprint(id)
+}
+
+// HandleCancelled handles notifications/cancelled by interrupting the referenced tool call
+func (qt *QueryTools) HandleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok || id == nil {
+		return
+	}
+	qt.inflight.cancel(callKey(ctx, fmt.Sprint(id)))
+}
+
+// callContext derives the context a tool call runs under. It applies the query timeout,
+// honouring a smaller per-call timeout_ms, and registers the call for client cancellation.
+// The returned function must be called once the call has finished.
+func (qt *QueryTools) callContext(ctx context.Context, request mcp.CallToolRequest) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	release := func() {}
+	if request.Params.Meta != nil {
+		if id, ok := request.Params.Meta.AdditionalFields[requestIDMetaKey].(string); ok {
+			release = qt.inflight.add(callKey(ctx, id), cancel)
+		}
+	}
+
+	timeout := qt.queryTimeout
+	if ms := mcp.ParseInt(request, "timeout_ms", 0); ms > 0 {
+		if perCall := time.Duration(ms) * time.Millisecond; timeout == 0 || perCall < timeout {
+			timeout = perCall
+		}
+	}
+
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		return ctx, func() {
+			cancelTimeout()
+			release()
+			cancel()
+		}
+	}
+
+	return ctx, func() {
+		release()
+		cancel()
+	}
+}
+
+// callKey identifies a request within its MCP session
+func callKey(ctx context.Context, id string) string {
+	return sessionID(ctx) + "/" + id
+}
+
+// contextError explains a failure caused by the call's context ending, or returns err unchanged
+func contextError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("statement timed out and was interrupted: %w", err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("statement was cancelled by the client: %w", err)
+	default:
+		return err
+	}
+}
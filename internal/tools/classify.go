@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+// statementKind describes the effect of a SQL statement
+type statementKind int
+
+const (
+	// statementRead only reads data and may return rows
+	statementRead statementKind = iota
+	// statementWrite may change the database or the connection state
+	statementWrite
+	// statementTransaction begins or ends a transaction or savepoint
+	statementTransaction
+)
+
+// errMultipleStatements is returned when a tool call contains more than one statement
+var errMultipleStatements = errors.New("only a single SQL statement is allowed per call")
+
+// classify checks that sql holds exactly one statement and determines whether it is read-only.
+// PRAGMAs are judged against a list of known read-only pragmas; every other statement is
+// compiled by SQLite and inspected, so the answer does not depend on how the text is written.
+func classify(
+	ctx context.Context, exec database.Executor, sql string, params []interface{},
+) (sqlparse.Statement, statementKind, error) {
+	statements, err := sqlparse.Split(sql)
+	if err != nil {
+		return sqlparse.Statement{}, statementWrite, fmt.Errorf("failed to parse SQL: %w", err)
+	}
+
+	switch len(statements) {
+	case 0:
+		return sqlparse.Statement{}, statementWrite, errors.New("no SQL statement found")
+	case 1:
+	default:
+		return sqlparse.Statement{}, statementWrite, errMultipleStatements
+	}
+
+	stmt := statements[0]
+	switch stmt.Keyword() {
+	case "ATTACH", "DETACH":
+		return stmt, statementWrite, fmt.Errorf("%s statements are not allowed", stmt.Keyword())
+	case "PRAGMA":
+		if pragma, ok := stmt.Pragma(); ok && pragma.ReadOnly() {
+			return stmt, statementRead, nil
+		}
+		return stmt, statementWrite, nil
+	case "EXPLAIN":
+		// EXPLAIN never runs the statement, but a PRAGMA still takes effect while it is prepared
+		if inner := explainTarget(stmt); inner.Keyword() == "PRAGMA" {
+			if pragma, ok := inner.Pragma(); !ok || !pragma.ReadOnly() {
+				return stmt, statementWrite, nil
+			}
+		}
+		return stmt, statementRead, nil
+	case "BEGIN", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE":
+		// Transaction control is read-only to SQLite but changes the connection state
+		return stmt, statementTransaction, nil
+	}
+
+	readOnly, err := exec.IsReadOnly(ctx, stmt.Text, params...)
+	if err != nil {
+		return stmt, statementWrite, err
+	}
+	if readOnly {
+		return stmt, statementRead, nil
+	}
+	return stmt, statementWrite, nil
+}
+
+// explainTarget returns the statement wrapped by EXPLAIN or EXPLAIN QUERY PLAN
+func explainTarget(stmt sqlparse.Statement) sqlparse.Statement {
+	tokens := stmt.Tokens[1:]
+	if len(tokens) >= 2 && tokens[0].Is("QUERY") && tokens[1].Is("PLAN") {
+		tokens = tokens[2:]
+	}
+	return sqlparse.Statement{Tokens: tokens}
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+const (
+	// cursorTTL is how long an unused cursor is kept before it expires
+	cursorTTL = 10 * time.Minute
+	// maxOpenCursors bounds the number of buffered results kept across all sessions
+	maxOpenCursors = 64
+)
+
+// errCursorNotFound is returned for unknown, expired or foreign cursors
+var errCursorNotFound = errors.New("cursor not found or expired; rerun the query")
+
+// cursor holds the remaining rows of a query result between fetch_more calls
+type cursor struct {
+	owner    owner
+	result   *database.Result
+	offset   int
+	pageSize int
+	expires  time.Time
+}
+
+// resultPage is one page of a query result as returned to the client
+type resultPage struct {
+	Columns    []database.Column `json:"columns" jsonschema_description:"Result columns in statement order"`
+	Rows       [][]interface{}   `json:"rows" jsonschema_description:"Row values, ordered as in columns"`
+	RowCount   int               `json:"row_count" jsonschema_description:"Number of rows in this page"`
+	Truncated  bool              `json:"truncated" jsonschema_description:"Whether the query produced more rows than max_rows"`
+	NextCursor string            `json:"next_cursor,omitempty" jsonschema_description:"Pass to fetch_more to get the next page"`
+}
+
+// cursorStore keeps buffered query results so later pages can be served without rerunning the query
+type cursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]*cursor
+}
+
+// newCursorStore creates an empty cursor store
+func newCursorStore() *cursorStore {
+	return &cursorStore{cursors: make(map[string]*cursor)}
+}
+
+// firstPage returns the first page of a result, keeping the remainder under a new cursor if needed
+func (cs *cursorStore) firstPage(ctx context.Context, result *database.Result, pageSize int) (resultPage, error) {
+	c := &cursor{
+		owner:    ownerOf(ctx),
+		result:   result,
+		pageSize: pageSize,
+	}
+	return cs.page(c, "")
+}
+
+// next returns the following page of the cursor identified by token
+func (cs *cursorStore) next(ctx context.Context, token string) (resultPage, error) {
+	cs.mu.Lock()
+	c, ok := cs.cursors[token]
+	if ok && (c.owner != ownerOf(ctx) || time.Now().After(c.expires)) {
+		ok = false
+	}
+	if ok {
+		delete(cs.cursors, token)
+	}
+	cs.mu.Unlock()
+
+	if !ok {
+		return resultPage{}, errCursorNotFound
+	}
+	return cs.page(c, token)
+}
+
+// page slices the next page off c and stores c again under token when rows remain
+func (cs *cursorStore) page(c *cursor, token string) (resultPage, error) {
+	end := min(c.offset+c.pageSize, len(c.result.Rows))
+	page := resultPage{
+		Columns:   c.result.Columns,
+		Rows:      c.result.Rows[c.offset:end],
+		RowCount:  end - c.offset,
+		Truncated: c.result.Truncated,
+	}
+	c.offset = end
+
+	if c.offset < len(c.result.Rows) {
+		if token == "" {
+			var err error
+			if token, err = newCursorToken(); err != nil {
+				return resultPage{}, err
+			}
+		}
+		c.expires = time.Now().Add(cursorTTL)
+		cs.put(token, c)
+		page.NextCursor = token
+	}
+
+	if page.Rows == nil {
+		page.Rows = [][]interface{}{}
+	}
+	return page, nil
+}
+
+// put stores a cursor, dropping expired cursors and evicting the oldest when the store is full
+func (cs *cursorStore) put(token string, c *cursor) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	now := time.Now()
+	var oldest string
+	for t, existing := range cs.cursors {
+		if now.After(existing.expires) {
+			delete(cs.cursors, t)
+			continue
+		}
+		if oldest == "" || existing.expires.Before(cs.cursors[oldest].expires) {
+			oldest = t
+		}
+	}
+	if len(cs.cursors) >= maxOpenCursors && oldest != "" {
+		delete(cs.cursors, oldest)
+	}
+
+	cs.cursors[token] = c
+}
+
+// newCursorToken returns a random opaque cursor token
+func newCursorToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// owner identifies who a session transaction or cursor belongs to: the MCP session, and the
+// principal the request was authenticated as when the server requires authentication
+type owner struct {
+	session   string
+	principal string
+}
+
+// ownerOf returns the owner of the request ctx belongs to
+func ownerOf(ctx context.Context) owner {
+	o := owner{session: sessionID(ctx)}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		o.principal = principal.Name
+	}
+	return o
+}
+
+// sessionID returns the MCP session ID of the current request, or "" outside a session
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
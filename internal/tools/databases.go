@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// databaseInfo describes a served database in the list_databases output
+type databaseInfo struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"read_only"`
+	Default  bool   `json:"default,omitempty"`
+}
+
+// diagnosticsInfo describes a database in the get_diagnostics output
+type diagnosticsInfo struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"read_only"`
+	*database.Diagnostics
+}
+
+// databaseCreation is where and how create_database creates databases
+type databaseCreation struct {
+	dir       string
+	readWrite bool
+	opts      []database.Option
+}
+
+// WithDatabaseCreation lets create_database create database files in dir, named after the
+// database with a .db extension. They are opened with opts, and for writing when readWrite is
+// set; init scripts in opts run once, when a database is created.
+func WithDatabaseCreation(dir string, readWrite bool, opts ...database.Option) Option {
+	return func(qt *QueryTools) {
+		qt.creation = &databaseCreation{dir: dir, readWrite: readWrite, opts: opts}
+	}
+}
+
+// withDatabase adds the database argument accepted by every tool that reads or writes a database
+func withDatabase() mcp.ToolOption {
+	return mcp.WithString("database",
+		mcp.Description("Name of the database to use, as reported by list_databases. Defaults to the database of "+
+			"the session's open transaction, or else to the default database."))
+}
+
+// listDatabasesTool creates the list_databases tool
+func (*QueryTools) listDatabasesTool() mcp.Tool {
+	return mcp.NewTool(
+		"list_databases",
+		mcp.WithDescription("List the databases served by this server with their paths and whether they are read-only"),
+	)
+}
+
+// handleListDatabases handles listing the served databases
+func (qt *QueryTools) handleListDatabases(ctx context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	names := qt.dbs.Names()
+	list := make([]databaseInfo, 0, len(names))
+	for i, name := range names {
+		// Only list the databases the caller has a policy for
+		if _, err := qt.authorizer.GrantContext(ctx, "", name); err != nil {
+			continue
+		}
+		db, err := qt.dbs.Get(name)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to list databases", err), nil
+		}
+		list = append(list, databaseInfo{Name: name, Path: db.Path(), ReadOnly: !db.Writable(), Default: i == 0})
+	}
+
+	return formatList("Databases", list)
+}
+
+// getDiagnosticsTool creates the get_diagnostics tool
+func (*QueryTools) getDiagnosticsTool() mcp.Tool {
+	return mcp.NewTool(
+		"get_diagnostics",
+		mcp.WithDescription("Report the SQLite version and the connection settings in effect on a database, such as "+
+			"its durability profile, journal mode, synchronous setting, busy timeout, cache size and page counts"),
+		withDatabase(),
+	)
+}
+
+// handleGetDiagnostics handles reporting the settings in effect on a database
+func (qt *QueryTools) handleGetDiagnostics(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, db, _, err := qt.target(ctx, request)
+	if err == nil {
+		_, err = qt.grant(ctx, request, name)
+	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to read diagnostics", err), nil
+	}
+
+	diagnostics, err := db.Diagnostics(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to read diagnostics", err), nil
+	}
+
+	return formatList("Diagnostics", diagnosticsInfo{
+		Name: name, Path: db.Path(), ReadOnly: !db.Writable(), Diagnostics: diagnostics,
+	})
+}
+
+// createDatabaseTool creates the create_database tool
+func (*QueryTools) createDatabaseTool() mcp.Tool {
+	return mcp.NewTool(
+		"create_database",
+		mcp.WithDescription("Create a new database file in the server's database directory and serve it under the "+
+			"given name. It starts empty unless the server applies a schema to new databases"),
+		mcp.WithString("name", mcp.Required(),
+			mcp.Description("Name of the new database; letters, digits, underscores and hyphens")),
+	)
+}
+
+// handleCreateDatabase handles creating and serving a new database
+func (qt *QueryTools) handleCreateDatabase(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if qt.creation == nil {
+		return mcp.NewToolResultError("database creation is not enabled on this server"), nil
+	}
+	name := mcp.ParseString(request, "name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name parameter is required"), nil
+	}
+	if err := database.ValidateDatabaseName(name); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create database", err), nil
+	}
+	if _, err := qt.grant(ctx, request, name); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create database", err), nil
+	}
+	if existing, err := qt.dbs.Name(name); err == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("database '%s' already exists", existing)), nil
+	}
+
+	// The name cannot contain path separators, so the file stays inside the directory
+	path := filepath.Join(qt.creation.dir, name+".db")
+	db, err := database.Create(path, !qt.creation.readWrite, qt.creation.opts...)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create database", err), nil
+	}
+	if err := qt.dbs.Add(name, db); err != nil {
+		// Another call registered the name first; remove the file so the name can be used again
+		_ = db.Close()
+		database.Remove(path)
+		return mcp.NewToolResultErrorFromErr("Failed to create database", err), nil
+	}
+
+	return formatList("Database created", databaseInfo{Name: name, Path: db.Path(), ReadOnly: !db.Writable()})
+}
+
+// target resolves the database a tool call addresses. It returns the caller's open transaction
+// when the call addresses the transaction's database, and nil otherwise.
+func (qt *QueryTools) target(ctx context.Context, request mcp.CallToolRequest) (string, *database.DB, *sessionTx, error) {
+	requested := mcp.ParseString(request, "database", "")
+
+	stx := qt.txs.get(ownerOf(ctx))
+	if stx != nil && requested == "" {
+		requested = stx.database
+	}
+
+	name, err := qt.dbs.Name(requested)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	db, err := qt.dbs.Get(name)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if stx != nil && stx.database != name {
+		stx = nil
+	}
+	return name, db, stx, nil
+}
+
+// schemaDB resolves the database a schema tool call addresses, along with the tables and
+// columns the caller may see in it
+func (qt *QueryTools) schemaDB(ctx context.Context, request mcp.CallToolRequest) (*database.DB, *database.Access, error) {
+	name, db, _, err := qt.target(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	grant, err := qt.grant(ctx, request, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, qt.access.Intersect(grant.Visibility()), nil
+}
+
+// visibleTable checks that tableName, when given, names a table or view the caller may see.
+// The returned error wraps database.ErrTableNotFound when it does not, so that hidden tables
+// cannot be told apart from missing ones.
+func visibleTable(ctx context.Context, db *database.DB, access *database.Access, tableName string) error {
+	if access == nil || tableName == "" {
+		return nil
+	}
+	name, err := db.ResolveTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if !access.Visible(name) {
+		return fmt.Errorf("%w: %s", database.ErrTableNotFound, tableName)
+	}
+	return nil
+}
+
+// errReadOnly returns the error for a write to a read-only database
+func errReadOnly(name string) error {
+	return fmt.Errorf("database '%s' is read-only", name)
+}
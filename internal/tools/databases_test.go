@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+	"github.com/StacklokLabs/sqlite-mcp/internal/testutil"
+)
+
+func TestMultipleDatabases(t *testing.T) {
+	sales := testutil.CreateTestDB(t)
+	defer sales.Close()
+	archive := testutil.CreateTestDB(t)
+	defer archive.Close()
+
+	ctx := context.Background()
+	_, err := archive.ExecuteContext(ctx, "CREATE TABLE orders (id INTEGER PRIMARY KEY, total REAL)")
+	require.NoError(t, err)
+
+	// Serve the archive through a read-only connection
+	archiveRO, err := database.New(archive.Path(), true)
+	require.NoError(t, err)
+	defer archiveRO.Close()
+
+	dbs := database.NewDatabases()
+	require.NoError(t, dbs.Add("sales", sales))
+	require.NoError(t, dbs.Add("archive", archiveRO))
+	qt := New(dbs)
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	call := func(ctx context.Context, name string, args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("list databases", func(t *testing.T) {
+		result := call(ctx, "list_databases", nil)
+		require.False(t, result.IsError)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, `"name": "sales"`)
+		assert.Contains(t, text, `"name": "archive"`)
+		assert.Contains(t, text, `"read_only": true`)
+		assert.Contains(t, text, `"default": true`)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		result := call(ctx, "get_diagnostics", map[string]interface{}{"database": "archive"})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, `"name": "archive"`)
+		assert.Contains(t, text, `"read_only": true`)
+		assert.Contains(t, text, `"durability": "wal"`)
+		assert.Contains(t, text, `"journal_mode": "wal"`)
+		assert.Contains(t, text, `"synchronous": "normal"`)
+		assert.Contains(t, text, `"sqlite_version": "3.`)
+	})
+
+	t.Run("database argument", func(t *testing.T) {
+		result := call(ctx, "list_tables", map[string]interface{}{"database": "archive"})
+		require.False(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "orders")
+
+		// The first database is the default
+		result = call(ctx, "list_tables", nil)
+		require.False(t, result.IsError)
+		assert.NotContains(t, testutil.GetTextContent(t, result.Content[0]), "orders")
+
+		result = call(ctx, "execute_query", map[string]interface{}{
+			"query": "SELECT count(*) FROM orders", "database": "ARCHIVE",
+		})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		result = call(ctx, "describe_table", map[string]interface{}{"table_name": "orders", "database": "sales"})
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("unknown database", func(t *testing.T) {
+		result := call(ctx, "execute_query", map[string]interface{}{"query": "SELECT 1", "database": "missing"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "database not found: missing")
+	})
+
+	t.Run("read-only database", func(t *testing.T) {
+		result := call(ctx, "execute_statement", map[string]interface{}{
+			"statement": "INSERT INTO orders (total) VALUES (1)", "database": "archive",
+		})
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "database 'archive' is read-only")
+
+		result = call(ctx, "begin_transaction", map[string]interface{}{"database": "archive"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "read-only")
+	})
+
+	t.Run("transaction database is the default", func(t *testing.T) {
+		writable := testutil.CreateTestDB(t)
+		defer writable.Close()
+		dbs := database.NewDatabases()
+		require.NoError(t, dbs.Add("sales", sales))
+		require.NoError(t, dbs.Add("scratch", writable))
+		qt := New(dbs)
+		ctx := srv.WithContext(context.Background(), &testSession{id: "multi"})
+
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+			Name: "begin_transaction", Arguments: map[string]interface{}{"database": "scratch"},
+		}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		result, err = qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+			Name: "execute_statement", Arguments: map[string]interface{}{"statement": "DELETE FROM users WHERE age > 0"},
+		}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		// Neither the committed data of scratch nor sales has changed yet
+		for _, db := range []*database.DB{writable, sales} {
+			rows, err := db.QueryContext(context.Background(), "SELECT count(*) AS n FROM users")
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, rows[0]["n"])
+		}
+
+		result, err = qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+			Name: "begin_transaction", Arguments: map[string]interface{}{"database": "sales", "savepoint": "sp"},
+		}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "already open in this session on database 'scratch'")
+
+		result, err = qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: "commit_transaction"}})
+		require.NoError(t, err)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		rows, err := writable.QueryContext(context.Background(), "SELECT count(*) AS n FROM users")
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, rows[0]["n"])
+	})
+}
+
+func TestCreateDatabase(t *testing.T) {
+	ctx := context.Background()
+	// Closing dbs closes the databases it serves, including the created one
+	dbs := testutil.Databases(t, testutil.CreateTestDB(t))
+	defer dbs.Close()
+
+	dir := filepath.Join(t.TempDir(), "databases")
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	require.NoError(t, os.WriteFile(schema, []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);"), 0o600))
+	qt := New(dbs, WithDatabaseCreation(dir, true, database.WithInitScripts(schema)))
+
+	call := func(name string, args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: name, Arguments: args}})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("creates and serves the database", func(t *testing.T) {
+		result := call("create_database", map[string]interface{}{"name": "scratch"})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, `"name": "scratch"`)
+		assert.Contains(t, text, `"read_only": false`)
+		assert.FileExists(t, filepath.Join(dir, "scratch.db"))
+
+		result = call("execute_statement", map[string]interface{}{
+			"statement": "INSERT INTO notes (body) VALUES ('hello')", "database": "scratch",
+		})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, []string{"test", "scratch"}, dbs.Names())
+	})
+
+	t.Run("existing database", func(t *testing.T) {
+		for _, name := range []string{"SCRATCH", "test"} {
+			result := call("create_database", map[string]interface{}{"name": name})
+			assert.True(t, result.IsError)
+			assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "already exists")
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		result := call("create_database", map[string]interface{}{"name": "../outside"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "invalid database name")
+	})
+
+	t.Run("not enabled", func(t *testing.T) {
+		result, err := New(dbs).HandleTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+			Name: "create_database", Arguments: map[string]interface{}{"name": "other"},
+		}})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "not enabled")
+	})
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// dryRunSampleRows is how many changed rows a dry run shows before and after the change
+const dryRunSampleRows = 10
+
+// statementPreview is the result of an execute_statement dry run as returned to the client
+type statementPreview struct {
+	DryRun       bool       `json:"dry_run"`
+	RowsAffected int64      `json:"rows_affected"`
+	Before       *rowSample `json:"before,omitempty"`
+	After        *rowSample `json:"after,omitempty"`
+}
+
+// rowSample is a sample of the rows changed by a statement
+type rowSample struct {
+	Columns   []database.Column `json:"columns"`
+	Rows      [][]interface{}   `json:"rows"`
+	Truncated bool              `json:"truncated"`
+}
+
+// newRowSample encodes a sampled result for output, or returns nil when there is none
+func (qt *QueryTools) newRowSample(result *database.Result) *rowSample {
+	if result == nil {
+		return nil
+	}
+	qt.encoding.EncodeResult(result)
+
+	sample := &rowSample{Columns: result.Columns, Rows: result.Rows, Truncated: result.Truncated}
+	if sample.Rows == nil {
+		sample.Rows = [][]interface{}{}
+	}
+	return sample
+}
+
+// formatPreview returns a dry run preview as structured content with a JSON text block
+func (qt *QueryTools) formatPreview(preview *database.Preview) (*mcp.CallToolResult, error) {
+	out := statementPreview{
+		DryRun:       true,
+		RowsAffected: preview.RowsAffected,
+		Before:       qt.newRowSample(preview.Before),
+		After:        qt.newRowSample(preview.After),
+	}
+
+	jsonData, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format preview", err), nil
+	}
+
+	summary := fmt.Sprintf("Dry run: the statement would affect %d rows and has been rolled back.", preview.RowsAffected)
+	if qt.policy.exceedsLimit(preview.RowsAffected) {
+		summary += fmt.Sprintf(" Executing it would fail because it exceeds the limit of %d affected rows.",
+			qt.policy.MaxAffectedRows)
+	}
+
+	text := fmt.Sprintf("%s Preview:\n```json\n%s\n```", summary, string(jsonData))
+	return mcp.NewToolResultStructured(out, text), nil
+}
@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// parametersDescription documents the accepted forms of the parameters argument
+const parametersDescription = "Optional parameters: an array of values bound to ? or ?NNN placeholders in order, " +
+	"or an object mapping names to values for :name, @name and $name placeholders. " +
+	"Values may be null, numbers, strings or booleans; " +
+	`use {"type": "blob", "base64": "..."} for BLOBs and {"type": "integer", "value": "..."} for integers beyond 2^53`
+
+// withParameters declares the parameters argument, which accepts a positional array or a named object
+func withParameters() mcp.ToolOption {
+	return mcp.WithAny("parameters", func(schema map[string]any) {
+		schema["type"] = []string{"array", "object"}
+		schema["description"] = parametersDescription
+	})
+}
+
+// parseParameters converts the parameters argument into driver arguments
+func parseParameters(request mcp.CallToolRequest) ([]interface{}, error) {
+	switch raw := mcp.ParseArgument(request, "parameters", nil).(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		params := make([]interface{}, len(raw))
+		for i, v := range raw {
+			param, err := parameterValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+			params[i] = param
+		}
+		return params, nil
+	case map[string]interface{}:
+		params := make([]interface{}, 0, len(raw))
+		for name, v := range raw {
+			param, err := parameterValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %q: %w", name, err)
+			}
+			// The placeholder prefix is optional; the driver matches on the bare name
+			bare := strings.TrimLeft(name, ":@$")
+			if bare == "" {
+				return nil, fmt.Errorf("parameter %q: name is empty", name)
+			}
+			params = append(params, sql.Named(bare, param))
+		}
+		return params, nil
+	default:
+		return nil, fmt.Errorf("parameters must be an array or an object, got %T", raw)
+	}
+}
+
+// parameterValue converts a single JSON parameter value into a driver value
+func parameterValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case nil, string:
+		return val, nil
+	case bool:
+		if val {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case float64:
+		// JSON numbers arrive as float64; bind whole numbers as INTEGER
+		if val == math.Trunc(val) && math.Abs(val) < 1<<63 {
+			return int64(val), nil
+		}
+		return val, nil
+	case map[string]interface{}:
+		return typedParameterValue(val)
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", v)
+	}
+}
+
+// typedParameterValue converts an explicitly typed parameter such as {"type": "blob", "base64": "..."}
+func typedParameterValue(obj map[string]interface{}) (interface{}, error) {
+	typ, _ := obj["type"].(string)
+	value := obj["value"]
+
+	switch strings.ToLower(typ) {
+	case "null":
+		return nil, nil
+	case "blob":
+		encoded, ok := obj["base64"].(string)
+		if !ok {
+			return nil, fmt.Errorf("blob parameter requires a base64 string")
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 in blob parameter: %w", err)
+		}
+		return data, nil
+	case "integer":
+		switch n := value.(type) {
+		case string:
+			i, err := strconv.ParseInt(n, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer parameter: %w", err)
+			}
+			return i, nil
+		case float64:
+			if n != math.Trunc(n) {
+				return nil, fmt.Errorf("integer parameter has a fractional part: %v", n)
+			}
+			// float64(math.MaxInt64) rounds up to 2^63, which is out of range
+			if n < math.MinInt64 || n >= math.MaxInt64 {
+				return nil, fmt.Errorf("integer parameter is out of range: %v", n)
+			}
+			return int64(n), nil
+		}
+		return nil, fmt.Errorf("integer parameter requires a number or string value")
+	case "real":
+		switch n := value.(type) {
+		case string:
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid real parameter: %w", err)
+			}
+			return f, nil
+		case float64:
+			return n, nil
+		}
+		return nil, fmt.Errorf("real parameter requires a number or string value")
+	case "text":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("text parameter requires a string value")
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown parameter type %q; expected null, integer, real, text or blob", typ)
+	}
+}
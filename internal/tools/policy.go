@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/sqlparse"
+)
+
+// WritePolicy restricts what execute_statement may change
+type WritePolicy struct {
+	// RequireWhere rejects UPDATE and DELETE statements without a WHERE clause
+	RequireWhere bool
+	// AllowDDL permits DROP and ALTER statements
+	AllowDDL bool
+	// AllowVacuumInto permits VACUUM INTO statements, which write a copy of the database to any
+	// path the server can write to
+	AllowVacuumInto bool
+	// MaxAffectedRows rolls back statements that change more rows than this; zero disables the limit
+	MaxAffectedRows int64
+}
+
+// DefaultWritePolicy returns the policy used unless WithWritePolicy is given
+func DefaultWritePolicy() WritePolicy {
+	return WritePolicy{RequireWhere: true}
+}
+
+// check returns an error describing why the policy rejects stmt, or nil. PRAGMAs that change
+// the settings of the connection are always rejected, as the connection is shared with other
+// calls and sessions.
+func (p WritePolicy) check(stmt sqlparse.Statement) error {
+	if stmt.Keyword() == "EXPLAIN" {
+		// Only EXPLAIN PRAGMA gets here; the pragma takes effect while it is prepared
+		stmt = explainTarget(stmt)
+	}
+
+	switch keyword := stmt.Keyword(); keyword {
+	case "DROP", "ALTER":
+		if !p.AllowDDL {
+			return fmt.Errorf("%s statements are disabled on this server", keyword)
+		}
+	case "VACUUM":
+		if stmt.VacuumInto() && !p.AllowVacuumInto {
+			return errors.New("VACUUM INTO statements are disabled on this server")
+		}
+	case "PRAGMA":
+		if pragma, ok := stmt.Pragma(); !ok || !pragma.ConnectionSafe() {
+			return fmt.Errorf("PRAGMA %s cannot be set: it changes the settings of the shared connection", pragma.Name)
+		}
+	}
+
+	if dml, ok := stmt.DML(); ok && p.RequireWhere && !dml.Where {
+		switch dml.Verb {
+		case "UPDATE", "DELETE":
+			return fmt.Errorf("%s statements must have a WHERE clause that selects the rows to change", dml.Verb)
+		}
+	}
+
+	return nil
+}
+
+// exceedsLimit reports whether changing n rows goes over the affected-row limit
+func (p WritePolicy) exceedsLimit(n int64) bool {
+	return p.MaxAffectedRows > 0 && n > p.MaxAffectedRows
+}
@@ -4,22 +4,101 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
 	"github.com/StacklokLabs/sqlite-mcp/internal/database"
 )
 
+const (
+	// DefaultMaxRows is the default number of rows a query may buffer before its result is truncated
+	DefaultMaxRows = 1000
+	// DefaultPageSize is the default number of rows returned per page
+	DefaultPageSize = 100
+)
+
 // QueryTools provides MCP tools for SQLite database operations
 type QueryTools struct {
-	db *database.DB
+	dbs          *database.Databases
+	queryTimeout time.Duration
+	maxRows      int
+	pageSize     int
+	encoding     database.Encoding
+	inflight     *inflightCalls
+	cursors      *cursorStore
+	txs          *sessionTxs
+	policy       WritePolicy
+	authorizer   *authz.Authorizer
+	access       *database.Access
+	masks        *database.Masks
+	creation     *databaseCreation
+}
+
+// Option configures a QueryTools instance
+type Option func(*QueryTools)
+
+// WithQueryTimeout limits how long a single query or statement may run. Zero disables the limit.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(qt *QueryTools) {
+		qt.queryTimeout = timeout
+	}
+}
+
+// WithMaxRows limits how many rows a single query may return across all of its pages
+func WithMaxRows(maxRows int) Option {
+	return func(qt *QueryTools) {
+		qt.maxRows = maxRows
+	}
+}
+
+// WithPageSize sets the default number of rows returned per page
+func WithPageSize(pageSize int) Option {
+	return func(qt *QueryTools) {
+		qt.pageSize = pageSize
+	}
 }
 
-// New creates a new QueryTools instance
-func New(db *database.DB) *QueryTools {
-	return &QueryTools{db: db}
+// WithEncoding sets how query result values are represented in tool output
+func WithEncoding(encoding database.Encoding) Option {
+	return func(qt *QueryTools) {
+		qt.encoding = encoding
+	}
+}
+
+// WithTransactionIdleTimeout sets how long a session transaction may sit unused before it is
+// rolled back. Zero disables the limit.
+func WithTransactionIdleTimeout(timeout time.Duration) Option {
+	return func(qt *QueryTools) {
+		qt.txs.idleTimeout = timeout
+	}
+}
+
+// WithWritePolicy sets the restrictions applied to execute_statement
+func WithWritePolicy(policy WritePolicy) Option {
+	return func(qt *QueryTools) {
+		qt.policy = policy
+	}
+}
+
+// New creates a new QueryTools instance serving dbs
+func New(dbs *database.Databases, opts ...Option) *QueryTools {
+	qt := &QueryTools{
+		dbs:      dbs,
+		maxRows:  DefaultMaxRows,
+		pageSize: DefaultPageSize,
+		inflight: newInflightCalls(),
+		cursors:  newCursorStore(),
+		txs:      newSessionTxs(DefaultTransactionIdleTimeout),
+		policy:   DefaultWritePolicy(),
+	}
+	for _, opt := range opts {
+		opt(qt)
+	}
+	return qt
 }
 
 // GetTools returns all available MCP tools
@@ -27,18 +106,51 @@ func (qt *QueryTools) GetTools() []mcp.Tool {
 	return []mcp.Tool{
 		qt.executeQueryTool(),
 		qt.executeStatementTool(),
+		qt.fetchMoreTool(),
+		qt.listDatabasesTool(),
+		qt.getDiagnosticsTool(),
+		qt.createDatabaseTool(),
 		qt.listTablesTool(),
 		qt.describeTableTool(),
+		qt.listViewsTool(),
+		qt.listIndexesTool(),
+		qt.listTriggersTool(),
+		qt.beginTransactionTool(),
+		qt.commitTransactionTool(),
+		qt.rollbackTransactionTool(),
 	}
 }
 
+// ToolNames returns the names of every tool GetTools returns
+func ToolNames() []string {
+	tools := New(nil).GetTools()
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
 // executeQueryTool creates the execute_query tool for SELECT operations
-func (*QueryTools) executeQueryTool() mcp.Tool {
+func (qt *QueryTools) executeQueryTool() mcp.Tool {
 	return mcp.NewTool(
 		"execute_query",
-		mcp.WithDescription("Execute a SELECT query against the SQLite database"),
-		mcp.WithString("query", mcp.Required(), mcp.Description("The SQL SELECT query to execute")),
-		mcp.WithArray("parameters", mcp.Description("Optional parameters for the query"), mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithDescription("Execute a read-only query (SELECT, WITH, VALUES, EXPLAIN or a read-only PRAGMA) "+
+			"against the SQLite database"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("The single read-only SQL statement to execute")),
+		withParameters(),
+		withDatabase(),
+		mcp.WithNumber("max_rows",
+			mcp.Description(fmt.Sprintf("Maximum number of rows to return across all pages (at most %d)", qt.maxRows)),
+			mcp.Min(1)),
+		mcp.WithNumber("page_size",
+			mcp.Description(fmt.Sprintf("Number of rows per page (default %d); "+
+				"use fetch_more with next_cursor for the rest", qt.pageSize)),
+			mcp.Min(1)),
+		mcp.WithNumber("timeout_ms",
+			mcp.Description("Optional timeout in milliseconds; cannot exceed the server's query timeout"),
+			mcp.Min(1)),
+		mcp.WithOutputSchema[resultPage](),
 	)
 }
 
@@ -48,9 +160,24 @@ func (*QueryTools) executeStatementTool() mcp.Tool {
 		"execute_statement",
 		mcp.WithDescription("Execute an INSERT, UPDATE, or DELETE statement against the SQLite database"),
 		mcp.WithString("statement", mcp.Required(), mcp.Description("The SQL statement to execute")),
-		mcp.WithArray("parameters",
-			mcp.Description("Optional parameters for the statement"),
-			mcp.Items(map[string]any{"type": "string"})),
+		withParameters(),
+		withDatabase(),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Execute the statement and roll it back, returning the affected row count and "+
+				"a sample of the changed rows before and after")),
+		mcp.WithNumber("timeout_ms",
+			mcp.Description("Optional timeout in milliseconds; cannot exceed the server's query timeout"),
+			mcp.Min(1)),
+	)
+}
+
+// fetchMoreTool creates the fetch_more tool for paging through query results
+func (*QueryTools) fetchMoreTool() mcp.Tool {
+	return mcp.NewTool(
+		"fetch_more",
+		mcp.WithDescription("Fetch the next page of an execute_query result without rerunning the query"),
+		mcp.WithString("cursor", mcp.Required(), mcp.Description("The next_cursor value from a previous result page")),
+		mcp.WithOutputSchema[resultPage](),
 	)
 }
 
@@ -58,7 +185,38 @@ func (*QueryTools) executeStatementTool() mcp.Tool {
 func (*QueryTools) listTablesTool() mcp.Tool {
 	return mcp.NewTool(
 		"list_tables",
-		mcp.WithDescription("List all tables in the SQLite database"),
+		mcp.WithDescription("List all tables in the SQLite database and the databases attached to it, flagging virtual "+
+			"tables (with their module, e.g. fts5 or rtree) and the shadow tables that store their data"),
+		withDatabase(),
+	)
+}
+
+// listViewsTool creates the list_views tool
+func (*QueryTools) listViewsTool() mcp.Tool {
+	return mcp.NewTool(
+		"list_views",
+		mcp.WithDescription("List all views in the SQLite database with their definitions"),
+		withDatabase(),
+	)
+}
+
+// listIndexesTool creates the list_indexes tool
+func (*QueryTools) listIndexesTool() mcp.Tool {
+	return mcp.NewTool(
+		"list_indexes",
+		mcp.WithDescription("List the indexes in the SQLite database, including those created for PRIMARY KEY and UNIQUE constraints"),
+		mcp.WithString("table_name", mcp.Description("Optional table to list the indexes of")),
+		withDatabase(),
+	)
+}
+
+// listTriggersTool creates the list_triggers tool
+func (*QueryTools) listTriggersTool() mcp.Tool {
+	return mcp.NewTool(
+		"list_triggers",
+		mcp.WithDescription("List the triggers in the SQLite database with when they fire and their definitions"),
+		mcp.WithString("table_name", mcp.Description("Optional table or view to list the triggers of")),
+		withDatabase(),
 	)
 }
 
@@ -66,125 +224,345 @@ func (*QueryTools) listTablesTool() mcp.Tool {
 func (*QueryTools) describeTableTool() mcp.Tool {
 	return mcp.NewTool(
 		"describe_table",
-		mcp.WithDescription("Get the schema information for a specific table"),
-		mcp.WithString("table_name", mcp.Required(), mcp.Description("The name of the table to describe")),
+		mcp.WithDescription("Get the structure of a table: columns (including hidden and generated ones), "+
+			"primary key, indexes, foreign keys, CHECK constraints, collations, STRICT and WITHOUT ROWID flags "+
+			"and the original CREATE statement"),
+		mcp.WithString("table_name", mcp.Required(),
+			mcp.Description("The name of the table to describe; use schema.table for a table of an attached database")),
+		withDatabase(),
 	)
 }
 
 // HandleTool handles MCP tool calls
 func (qt *QueryTools) HandleTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, cancel := qt.callContext(ctx, request)
+	defer cancel()
+
+	if !qt.authorizer.AllowsToolContext(ctx, request.Params.Name) {
+		return mcp.NewToolResultError(fmt.Sprintf("%v: tool %s", authz.ErrNotAllowed, request.Params.Name)), nil
+	}
+	ctx = database.WithMasks(database.WithAccess(ctx, qt.access), qt.masks)
+
 	switch request.Params.Name {
 	case "execute_query":
 		return qt.handleExecuteQuery(ctx, request)
 	case "execute_statement":
 		return qt.handleExecuteStatement(ctx, request)
+	case "fetch_more":
+		return qt.handleFetchMore(ctx, request)
+	case "list_databases":
+		return qt.handleListDatabases(ctx, request)
+	case "get_diagnostics":
+		return qt.handleGetDiagnostics(ctx, request)
+	case "create_database":
+		return qt.handleCreateDatabase(ctx, request)
 	case "list_tables":
 		return qt.handleListTables(ctx, request)
 	case "describe_table":
 		return qt.handleDescribeTable(ctx, request)
+	case "list_views":
+		return qt.handleListViews(ctx, request)
+	case "list_indexes":
+		return qt.handleListIndexes(ctx, request)
+	case "list_triggers":
+		return qt.handleListTriggers(ctx, request)
+	case "begin_transaction":
+		return qt.handleBeginTransaction(ctx, request)
+	case "commit_transaction":
+		return qt.handleCommitTransaction(ctx, request)
+	case "rollback_transaction":
+		return qt.handleRollbackTransaction(ctx, request)
 	default:
 		return mcp.NewToolResultError(fmt.Sprintf("Unknown tool: %s", request.Params.Name)), nil
 	}
 }
 
 // handleExecuteQuery handles SELECT queries
-func (qt *QueryTools) handleExecuteQuery(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (qt *QueryTools) handleExecuteQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	query := mcp.ParseString(request, "query", "")
 	if query == "" {
 		return mcp.NewToolResultError("query parameter is required"), nil
 	}
 
-	// Validate that it's a SELECT query
-	trimmedQuery := strings.TrimSpace(strings.ToUpper(query))
-	if !strings.HasPrefix(trimmedQuery, "SELECT") {
-		return mcp.NewToolResultError("only SELECT queries are allowed with execute_query"), nil
+	params, err := parseParameters(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid parameters", err), nil
 	}
 
-	// Parse parameters
-	var params []interface{}
-	if paramArray := mcp.ParseArgument(request, "parameters", nil); paramArray != nil {
-		if paramSlice, ok := paramArray.([]interface{}); ok {
-			params = paramSlice
+	maxRows := qt.maxRows
+	if n := mcp.ParseInt(request, "max_rows", 0); n > 0 && (maxRows <= 0 || n < maxRows) {
+		maxRows = n
+	}
+	pageSize := qt.pageSize
+	if n := mcp.ParseInt(request, "page_size", 0); n > 0 {
+		pageSize = n
+	}
+
+	// Run in the session's transaction, if one is open
+	var result *database.Result
+	var toolErr *mcp.CallToolResult
+	err = qt.withExecutor(ctx, request, false, func(grant *authz.Grant, exec database.Executor) error {
+		// Validate that it's a single read-only statement
+		stmt, kind, err := classify(ctx, exec, query, params)
+		if err != nil {
+			toolErr = mcp.NewToolResultErrorFromErr("Invalid query", err)
+			return nil
+		}
+		if kind != statementRead {
+			toolErr = mcp.NewToolResultError("only read-only queries are allowed with execute_query")
+			return nil
+		}
+		queryCtx, err := authorizeStatement(ctx, grant, stmt, kind)
+		if err != nil {
+			toolErr = mcp.NewToolResultErrorFromErr("Query rejected", err)
+			return nil
 		}
+
+		result, err = exec.QueryLimitContext(queryCtx, maxRows, stmt.Text, params...)
+		return err
+	})
+	if toolErr != nil {
+		return toolErr, nil
+	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Query execution failed", contextError(ctx, err)), nil
 	}
+	qt.encoding.EncodeResult(result)
 
-	results, err := qt.db.Query(query, params...)
+	page, err := qt.cursors.firstPage(ctx, result, pageSize)
 	if err != nil {
-		return mcp.NewToolResultErrorFromErr("Query execution failed", err), nil
+		return mcp.NewToolResultErrorFromErr("Failed to store result cursor", err), nil
 	}
 
-	// Format results as JSON
-	jsonData, err := json.MarshalIndent(results, "", "  ")
+	return formatPage("Query executed successfully.", page)
+}
+
+// handleFetchMore returns the next page of a previous query result
+func (qt *QueryTools) handleFetchMore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	token := mcp.ParseString(request, "cursor", "")
+	if token == "" {
+		return mcp.NewToolResultError("cursor parameter is required"), nil
+	}
+
+	page, err := qt.cursors.next(ctx, token)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch more rows", err), nil
+	}
+
+	return formatPage("Fetched more rows.", page)
+}
+
+// formatPage returns a result page as structured content, with a JSON text block for
+// clients that do not support structured tool output
+func formatPage(summary string, page resultPage) (*mcp.CallToolResult, error) {
+	jsonData, err := json.MarshalIndent(page, "", "  ")
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to format results", err), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Query executed successfully. Results:\n```json\n%s\n```", string(jsonData))), nil
+	if page.NextCursor != "" {
+		summary += " More rows are available; call fetch_more with next_cursor."
+	}
+	if page.Truncated {
+		summary += " The result was truncated at the row limit."
+	}
+
+	text := fmt.Sprintf("%s Results:\n```json\n%s\n```", summary, string(jsonData))
+	return mcp.NewToolResultStructured(page, text), nil
 }
 
 // handleExecuteStatement handles INSERT/UPDATE/DELETE statements
-func (qt *QueryTools) handleExecuteStatement(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (qt *QueryTools) handleExecuteStatement(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	statement := mcp.ParseString(request, "statement", "")
 	if statement == "" {
 		return mcp.NewToolResultError("statement parameter is required"), nil
 	}
 
-	// Validate that it's not a SELECT query
-	trimmedStatement := strings.TrimSpace(strings.ToUpper(statement))
-	if strings.HasPrefix(trimmedStatement, "SELECT") {
-		return mcp.NewToolResultError("SELECT queries should use execute_query tool"), nil
+	params, err := parseParameters(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid parameters", err), nil
 	}
 
-	// Parse parameters
-	var params []interface{}
-	if paramArray := mcp.ParseArgument(request, "parameters", nil); paramArray != nil {
-		if paramSlice, ok := paramArray.([]interface{}); ok {
-			params = paramSlice
+	dryRun := mcp.ParseBoolean(request, "dry_run", false)
+
+	// Run in the session's transaction, if one is open
+	var rowsAffected int64
+	var preview *database.Preview
+	var toolErr *mcp.CallToolResult
+	err = qt.withExecutor(ctx, request, true, func(grant *authz.Grant, exec database.Executor) error {
+		// Validate that it's a single statement that is not a read-only query
+		stmt, kind, err := classify(ctx, exec, statement, params)
+		if err != nil {
+			toolErr = mcp.NewToolResultErrorFromErr("Invalid statement", err)
+			return nil
+		}
+		switch kind {
+		case statementRead:
+			toolErr = mcp.NewToolResultError("SELECT queries should use execute_query tool")
+			return nil
+		case statementTransaction:
+			toolErr = mcp.NewToolResultError("transaction statements are not allowed; " +
+				"use begin_transaction, commit_transaction and rollback_transaction")
+			return nil
+		}
+		if err := qt.policy.check(stmt); err != nil {
+			toolErr = mcp.NewToolResultErrorFromErr("Statement rejected", err)
+			return nil
+		}
+		stmtCtx, err := authorizeStatement(ctx, grant, stmt, kind)
+		if err != nil {
+			toolErr = mcp.NewToolResultErrorFromErr("Statement rejected", err)
+			return nil
 		}
-	}
 
-	rowsAffected, err := qt.db.Execute(statement, params...)
+		if dryRun {
+			preview, err = exec.DryRunContext(stmtCtx, dryRunSampleRows, stmt.Text, params...)
+			return err
+		}
+		rowsAffected, err = exec.ExecuteLimitContext(stmtCtx, qt.policy.MaxAffectedRows, stmt.Text, params...)
+		return err
+	})
+	if toolErr != nil {
+		return toolErr, nil
+	}
 	if err != nil {
-		return mcp.NewToolResultErrorFromErr("Statement execution failed", err), nil
+		return mcp.NewToolResultErrorFromErr("Statement execution failed", contextError(ctx, err)), nil
 	}
 
+	if preview != nil {
+		return qt.formatPreview(preview)
+	}
 	return mcp.NewToolResultText(fmt.Sprintf("Statement executed successfully. Rows affected: %d", rowsAffected)), nil
 }
 
 // handleListTables handles listing all tables
-func (qt *QueryTools) handleListTables(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	tables, err := qt.db.GetTables()
+func (qt *QueryTools) handleListTables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	db, access, err := qt.schemaDB(ctx, request)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to list tables", err), nil
 	}
 
+	tables, err := db.GetTables(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list tables", err), nil
+	}
+	tables = access.FilterTables(tables)
+
 	if len(tables) == 0 {
 		return mcp.NewToolResultText("No tables found in the database"), nil
 	}
 
-	jsonData, err := json.MarshalIndent(tables, "", "  ")
+	return formatList("Tables in database", tables)
+}
+
+// handleListViews handles listing all views
+func (qt *QueryTools) handleListViews(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	db, access, err := qt.schemaDB(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list views", err), nil
+	}
+
+	views, err := db.GetViews(ctx)
 	if err != nil {
-		return mcp.NewToolResultErrorFromErr("Failed to format table list", err), nil
+		return mcp.NewToolResultErrorFromErr("Failed to list views", err), nil
+	}
+	views = access.FilterViews(views)
+
+	if len(views) == 0 {
+		return mcp.NewToolResultText("No views found in the database"), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Tables in database:\n```json\n%s\n```", string(jsonData))), nil
+	return formatList("Views in database", views)
+}
+
+// handleListIndexes handles listing indexes, optionally of a single table
+func (qt *QueryTools) handleListIndexes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	db, access, err := qt.schemaDB(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list indexes", err), nil
+	}
+
+	tableName := mcp.ParseString(request, "table_name", "")
+	err = visibleTable(ctx, db, access, tableName)
+	var indexes []database.TableIndex
+	if err == nil {
+		indexes, err = db.GetIndexes(ctx, tableName)
+	}
+	if errors.Is(err, database.ErrTableNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("Table '%s' not found", tableName)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list indexes", err), nil
+	}
+	indexes = access.FilterIndexes(indexes)
+
+	if len(indexes) == 0 {
+		return mcp.NewToolResultText("No indexes found"), nil
+	}
+
+	return formatList("Indexes", indexes)
+}
+
+// handleListTriggers handles listing triggers, optionally of a single table
+func (qt *QueryTools) handleListTriggers(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	db, access, err := qt.schemaDB(ctx, request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list triggers", err), nil
+	}
+
+	tableName := mcp.ParseString(request, "table_name", "")
+	err = visibleTable(ctx, db, access, tableName)
+	var triggers []database.Trigger
+	if err == nil {
+		triggers, err = db.GetTriggers(ctx, tableName)
+	}
+	if errors.Is(err, database.ErrTableNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("Table '%s' not found", tableName)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list triggers", err), nil
+	}
+	triggers = access.FilterTriggers(triggers)
+
+	if len(triggers) == 0 {
+		return mcp.NewToolResultText("No triggers found"), nil
+	}
+
+	return formatList("Triggers", triggers)
+}
+
+// formatList returns a list of schema objects as a JSON text block
+func formatList(title string, list interface{}) (*mcp.CallToolResult, error) {
+	jsonData, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format list", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%s:\n```json\n%s\n```", title, string(jsonData))), nil
 }
 
 // handleDescribeTable handles table schema description
-func (qt *QueryTools) handleDescribeTable(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (qt *QueryTools) handleDescribeTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	tableName := mcp.ParseString(request, "table_name", "")
 	if tableName == "" {
 		return mcp.NewToolResultError("table_name parameter is required"), nil
 	}
 
-	schema, err := qt.db.GetTableSchema(tableName)
+	db, access, err := qt.schemaDB(ctx, request)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to describe table '%s'", tableName), err), nil
 	}
 
-	if len(schema) == 0 {
+	schema, err := db.GetTableSchema(ctx, tableName)
+	if err == nil && !access.FilterTableSchema(schema) {
+		err = database.ErrTableNotFound
+	}
+	if errors.Is(err, database.ErrTableNotFound) {
 		return mcp.NewToolResultError(fmt.Sprintf("Table '%s' not found", tableName)), nil
 	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr(fmt.Sprintf("Failed to describe table '%s'", tableName), err), nil
+	}
 
 	jsonData, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
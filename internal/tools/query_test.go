@@ -2,12 +2,19 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
 	"github.com/StacklokLabs/sqlite-mcp/internal/testutil"
 )
 
@@ -15,7 +22,7 @@ func TestNew(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	assert.NotNil(t, qt)
 }
 
@@ -23,10 +30,10 @@ func TestGetTools(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	tools := qt.GetTools()
 
-	assert.Len(t, tools, 4)
+	assert.Len(t, tools, 14)
 
 	toolNames := make([]string, len(tools))
 	for i, tool := range tools {
@@ -35,15 +42,25 @@ func TestGetTools(t *testing.T) {
 
 	assert.Contains(t, toolNames, "execute_query")
 	assert.Contains(t, toolNames, "execute_statement")
+	assert.Contains(t, toolNames, "fetch_more")
+	assert.Contains(t, toolNames, "list_databases")
+	assert.Contains(t, toolNames, "get_diagnostics")
+	assert.Contains(t, toolNames, "create_database")
 	assert.Contains(t, toolNames, "list_tables")
 	assert.Contains(t, toolNames, "describe_table")
+	assert.Contains(t, toolNames, "list_views")
+	assert.Contains(t, toolNames, "list_indexes")
+	assert.Contains(t, toolNames, "list_triggers")
+	assert.Contains(t, toolNames, "begin_transaction")
+	assert.Contains(t, toolNames, "commit_transaction")
+	assert.Contains(t, toolNames, "rollback_transaction")
 }
 
 func TestHandleExecuteQuery(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	t.Run("successful select query", func(t *testing.T) {
@@ -65,6 +82,53 @@ func TestHandleExecuteQuery(t *testing.T) {
 		assert.Contains(t, text, "Bob")
 	})
 
+	t.Run("structured content", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query": "SELECT name, email, id FROM users ORDER BY id",
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		page, ok := result.StructuredContent.(resultPage)
+		require.True(t, ok)
+		require.Len(t, page.Columns, 3)
+		assert.Equal(t, "name", page.Columns[0].Name)
+		assert.Equal(t, "TEXT", page.Columns[0].Type)
+		assert.Equal(t, "email", page.Columns[1].Name)
+		assert.Equal(t, "id", page.Columns[2].Name)
+		assert.Equal(t, "INTEGER", page.Columns[2].Type)
+		assert.Equal(t, []interface{}{"Alice", "alice@example.com", int64(1)}, page.Rows[0])
+
+		// The text block is kept for clients without structured content support
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "Alice")
+	})
+
+	t.Run("blob and real values", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query": "SELECT x'deadbeef' AS data, 2.0 AS amount",
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, `"$blob": "3q2+7w=="`)
+		assert.Contains(t, text, `"size": 4`)
+		assert.Contains(t, text, "2.0")
+	})
+
 	t.Run("query with parameters", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
@@ -85,6 +149,82 @@ func TestHandleExecuteQuery(t *testing.T) {
 		assert.NotContains(t, text, "Bob")
 	})
 
+	t.Run("typed parameters", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":      "SELECT typeof(?) AS a, typeof(?) AS b, typeof(?) AS c, typeof(?) AS d, hex(?) AS e",
+					"parameters": []interface{}{float64(27), 2.5, nil, true, map[string]interface{}{"type": "blob", "base64": "3q2+7w=="}},
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		page, ok := result.StructuredContent.(resultPage)
+		require.True(t, ok, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, []interface{}{"integer", "real", "null", "integer", "DEADBEEF"}, page.Rows[0])
+	})
+
+	t.Run("named parameters", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":      "SELECT name FROM users WHERE age > :min_age AND name <> @excluded",
+					"parameters": map[string]interface{}{"min_age": float64(20), "@excluded": "Bob"},
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		page, ok := result.StructuredContent.(resultPage)
+		require.True(t, ok, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, [][]interface{}{{"Alice"}}, page.Rows)
+	})
+
+	t.Run("invalid typed parameter", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":      "SELECT ?",
+					"parameters": []interface{}{map[string]interface{}{"type": "blob", "base64": "not base64!"}},
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "invalid base64")
+	})
+
+	t.Run("inexact integer parameters", func(t *testing.T) {
+		for value, want := range map[float64]string{1.5: "fractional part", 1e300: "out of range", -1e19: "out of range"} {
+			request := mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: "execute_query",
+					Arguments: map[string]interface{}{
+						"query":      "SELECT ?",
+						"parameters": []interface{}{map[string]interface{}{"type": "integer", "value": value}},
+					},
+				},
+			}
+
+			result, err := qt.HandleTool(ctx, request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, value)
+			assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), want)
+		}
+	})
+
 	t.Run("non-select query rejected", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
@@ -99,7 +239,93 @@ func TestHandleExecuteQuery(t *testing.T) {
 		require.NoError(t, err)
 
 		text := testutil.GetTextContent(t, result.Content[0])
-		assert.Contains(t, text, "only SELECT queries are allowed")
+		assert.Contains(t, text, "only read-only queries are allowed")
+	})
+
+	t.Run("read-only statements accepted", func(t *testing.T) {
+		queries := []string{
+			"WITH adults AS (SELECT * FROM users WHERE age >= 18) SELECT name FROM adults",
+			"VALUES ('Alice')",
+			"EXPLAIN QUERY PLAN SELECT * FROM users",
+			"PRAGMA table_info(users)",
+			"-- find Alice\nSELECT name FROM users WHERE name = 'Alice';",
+		}
+
+		for _, query := range queries {
+			request := mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: "execute_query",
+					Arguments: map[string]interface{}{
+						"query": query,
+					},
+				},
+			}
+
+			result, err := qt.HandleTool(ctx, request)
+			require.NoError(t, err)
+			assert.False(t, result.IsError, query)
+		}
+	})
+
+	t.Run("writing statements rejected", func(t *testing.T) {
+		queries := []string{
+			"WITH x AS (SELECT 1) DELETE FROM users",
+			"PRAGMA user_version = 5",
+			"EXPLAIN PRAGMA foreign_keys = OFF",
+			"BEGIN",
+		}
+
+		for _, query := range queries {
+			request := mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: "execute_query",
+					Arguments: map[string]interface{}{
+						"query": query,
+					},
+				},
+			}
+
+			result, err := qt.HandleTool(ctx, request)
+			require.NoError(t, err)
+			assert.True(t, result.IsError, query)
+
+			text := testutil.GetTextContent(t, result.Content[0])
+			assert.Contains(t, text, "only read-only queries are allowed", query)
+		}
+	})
+
+	t.Run("attach rejected", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query": "ATTACH DATABASE 'other.db' AS other",
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "ATTACH statements are not allowed")
+	})
+
+	t.Run("multiple statements rejected", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query": "SELECT * FROM users; DELETE FROM users",
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "only a single SQL statement is allowed")
 	})
 
 	t.Run("missing query parameter", func(t *testing.T) {
@@ -122,7 +348,7 @@ func TestHandleExecuteStatement(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	t.Run("successful insert", func(t *testing.T) {
@@ -163,6 +389,34 @@ func TestHandleExecuteStatement(t *testing.T) {
 		assert.Contains(t, text, "Rows affected: 1")
 	})
 
+	t.Run("insert with named and typed parameters", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_statement",
+				Arguments: map[string]interface{}{
+					"statement": "INSERT INTO users (name, email, age) VALUES (:name, :email, :age)",
+					"parameters": map[string]interface{}{
+						"name":  "Dana",
+						"email": nil,
+						"age":   map[string]interface{}{"type": "integer", "value": "9007199254740993"},
+					},
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "Rows affected: 1")
+
+		rows, err := db.Query("SELECT email, age FROM users WHERE name = 'Dana'")
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Nil(t, rows[0]["email"])
+		assert.Equal(t, int64(9007199254740993), rows[0]["age"])
+	})
+
 	t.Run("select query rejected", func(t *testing.T) {
 		request := mcp.CallToolRequest{
 			Params: mcp.CallToolParams{
@@ -179,13 +433,444 @@ func TestHandleExecuteStatement(t *testing.T) {
 		text := testutil.GetTextContent(t, result.Content[0])
 		assert.Contains(t, text, "SELECT queries should use execute_query tool")
 	})
+
+	t.Run("commented select rejected", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_statement",
+				Arguments: map[string]interface{}{
+					"statement": "  -- comment\nSELECT * FROM users",
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "SELECT queries should use execute_query tool")
+	})
+
+	t.Run("multiple statements rejected", func(t *testing.T) {
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_statement",
+				Arguments: map[string]interface{}{
+					"statement": "DELETE FROM products; DROP TABLE users",
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(ctx, request)
+		require.NoError(t, err)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "only a single SQL statement is allowed")
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	qt := New(testutil.Databases(t, db))
+	ctx := context.Background()
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "execute_statement",
+			Arguments: map[string]interface{}{
+				"statement":  "UPDATE users SET age = age + 1 WHERE name = :name",
+				"parameters": map[string]interface{}{"name": "Alice"},
+				"dry_run":    true,
+			},
+		},
+	}
+
+	result, err := qt.HandleTool(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+	assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "would affect 1 rows")
+
+	preview, ok := result.StructuredContent.(statementPreview)
+	require.True(t, ok)
+	assert.True(t, preview.DryRun)
+	assert.Equal(t, int64(1), preview.RowsAffected)
+	require.NotNil(t, preview.Before)
+	require.NotNil(t, preview.After)
+	assert.Equal(t, int64(30), preview.Before.Rows[0][3])
+	assert.Equal(t, int64(31), preview.After.Rows[0][3])
+
+	// Nothing was changed
+	rows, err := db.Query("SELECT age FROM users WHERE name = 'Alice'")
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), rows[0]["age"])
+}
+
+func TestMasking(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	masks, err := database.NewMasks([]database.MaskRule{{Column: "users.email", Method: database.MaskEmailDomain}})
+	require.NoError(t, err)
+	qt := New(testutil.Databases(t, db), WithMasks(masks))
+	ctx := context.Background()
+
+	result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name:      "execute_query",
+			Arguments: map[string]interface{}{"query": "SELECT name, email AS contact FROM users ORDER BY id"},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+	page, ok := result.StructuredContent.(resultPage)
+	require.True(t, ok)
+	assert.Empty(t, page.Columns[0].Masked)
+	assert.Equal(t, database.MaskEmailDomain, page.Columns[1].Masked)
+	assert.Equal(t, "Alice", page.Rows[0][0])
+	assert.Equal(t, "[REDACTED]@example.com", page.Rows[0][1])
+	assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), `"masked": "email_domain"`)
+	assert.NotContains(t, testutil.GetTextContent(t, result.Content[0]), "alice@example.com")
+
+	result, err = qt.HandleTool(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "execute_statement",
+			Arguments: map[string]interface{}{
+				"statement": "UPDATE users SET age = 31 WHERE id = 1",
+				"dry_run":   true,
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+	assert.NotContains(t, testutil.GetTextContent(t, result.Content[0]), "alice@example.com")
+}
+
+func TestWritePolicy(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	execute := func(t *testing.T, qt *QueryTools, statement string) *mcp.CallToolResult {
+		t.Helper()
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "execute_statement",
+				Arguments: map[string]interface{}{"statement": statement},
+			},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("default policy", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+
+		tests := []struct {
+			statement string
+			message   string
+		}{
+			{"DELETE FROM users", "must have a WHERE clause"},
+			{"UPDATE users SET age = 0", "must have a WHERE clause"},
+			{"UPDATE users SET age = (SELECT max(age) FROM users WHERE id = 1)", "must have a WHERE clause"},
+			{"DROP TABLE products", "DROP statements are disabled"},
+			{"ALTER TABLE users ADD COLUMN nickname TEXT", "ALTER statements are disabled"},
+			{"VACUUM INTO '" + filepath.Join(t.TempDir(), "copy.db") + "'", "VACUUM INTO statements are disabled"},
+			{"PRAGMA foreign_keys = OFF", "PRAGMA foreign_keys cannot be set"},
+			{"PRAGMA busy_timeout = 0", "PRAGMA busy_timeout cannot be set"},
+			{"PRAGMA main.journal_mode = DELETE", "PRAGMA journal_mode cannot be set"},
+			{"EXPLAIN PRAGMA query_only = ON", "PRAGMA query_only cannot be set"},
+			{"PRAGMA vdbe_trace = ON", "PRAGMA vdbe_trace cannot be set"},
+		}
+		for _, tt := range tests {
+			result := execute(t, qt, tt.statement)
+			assert.True(t, result.IsError, tt.statement)
+			assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), tt.message, tt.statement)
+		}
+
+		result := execute(t, qt, "CREATE TABLE notes (body TEXT)")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		result = execute(t, qt, "PRAGMA user_version = 7")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		result = execute(t, qt, "VACUUM")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		rows, err := db.Query("PRAGMA user_version")
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), rows[0]["user_version"])
+	})
+
+	t.Run("vacuum into", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db), WithWritePolicy(WritePolicy{AllowVacuumInto: true}))
+
+		copyPath := filepath.Join(t.TempDir(), "copy.db")
+		result := execute(t, qt, "VACUUM INTO '"+copyPath+"'")
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.FileExists(t, copyPath)
+	})
+
+	t.Run("permissive policy", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db), WithWritePolicy(WritePolicy{AllowDDL: true}))
+
+		result := execute(t, qt, "ALTER TABLE notes ADD COLUMN title TEXT")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		result = execute(t, qt, "DELETE FROM notes")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		result = execute(t, qt, "DROP TABLE notes")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+	})
+
+	t.Run("max affected rows", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db), WithWritePolicy(WritePolicy{RequireWhere: true, MaxAffectedRows: 1}))
+
+		result := execute(t, qt, "UPDATE users SET age = age + 1 WHERE age > 0")
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "would affect 2 rows, more than the limit of 1")
+
+		rows, err := db.Query("SELECT age FROM users ORDER BY id")
+		require.NoError(t, err)
+		assert.Equal(t, int64(30), rows[0]["age"])
+		assert.Equal(t, int64(25), rows[1]["age"])
+
+		result = execute(t, qt, "UPDATE users SET age = age + 1 WHERE name = 'Bob'")
+		assert.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "Rows affected: 1")
+	})
+}
+
+func TestPagination(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err := db.Execute("INSERT INTO products (name, price) VALUES (?, ?)", fmt.Sprintf("Item %d", i), i)
+		require.NoError(t, err)
+	}
+
+	qt := New(testutil.Databases(t, db))
+	ctx := context.Background()
+
+	parsePage := func(t *testing.T, result *mcp.CallToolResult) resultPage {
+		t.Helper()
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		start := strings.Index(text, "```json\n")
+		end := strings.LastIndex(text, "\n```")
+		require.True(t, start >= 0 && end > start)
+
+		var page resultPage
+		require.NoError(t, json.Unmarshal([]byte(text[start+len("```json\n"):end]), &page))
+		return page
+	}
+
+	t.Run("pages through result", func(t *testing.T) {
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":     "SELECT * FROM products ORDER BY id",
+					"page_size": 5,
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		page := parsePage(t, result)
+		assert.Equal(t, 5, page.RowCount)
+		assert.False(t, page.Truncated)
+		require.NotEmpty(t, page.NextCursor)
+
+		require.Len(t, page.Columns, 3)
+		assert.Equal(t, "name", page.Columns[1].Name)
+
+		var names []interface{}
+		for cursor := page.NextCursor; cursor != ""; cursor = page.NextCursor {
+			result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name:      "fetch_more",
+					Arguments: map[string]interface{}{"cursor": cursor},
+				},
+			})
+			require.NoError(t, err)
+			page = parsePage(t, result)
+			for _, row := range page.Rows {
+				names = append(names, row[1])
+			}
+		}
+
+		assert.Len(t, names, 7)
+		assert.Equal(t, "Item 9", names[len(names)-1])
+	})
+
+	t.Run("max rows truncates", func(t *testing.T) {
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":    "SELECT * FROM products ORDER BY id",
+					"max_rows": 3,
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		page := parsePage(t, result)
+		assert.Equal(t, 3, page.RowCount)
+		assert.True(t, page.Truncated)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("unknown cursor", func(t *testing.T) {
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "fetch_more",
+				Arguments: map[string]interface{}{"cursor": "does-not-exist"},
+			},
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "cursor not found or expired")
+	})
+
+	t.Run("cursors belong to the principal", func(t *testing.T) {
+		alice := auth.WithPrincipal(ctx, &auth.Principal{Name: "alice"})
+		result, err := qt.HandleTool(alice, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":     "SELECT * FROM products ORDER BY id",
+					"page_size": 5,
+				},
+			},
+		})
+		require.NoError(t, err)
+		page := parsePage(t, result)
+		require.NotEmpty(t, page.NextCursor)
+
+		fetch := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name:      "fetch_more",
+				Arguments: map[string]interface{}{"cursor": page.NextCursor},
+			},
+		}
+		for _, other := range []context.Context{ctx, auth.WithPrincipal(ctx, &auth.Principal{Name: "bob"})} {
+			result, err = qt.HandleTool(other, fetch)
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+			assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "cursor not found or expired")
+		}
+
+		result, err = qt.HandleTool(alice, fetch)
+		require.NoError(t, err)
+		assert.Equal(t, 5, parsePage(t, result).RowCount)
+	})
+}
+
+func TestQueryTimeout(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	runaway := "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+
+	t.Run("server timeout", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db), WithQueryTimeout(50*time.Millisecond))
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query": runaway,
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "timed out")
+	})
+
+	t.Run("per-call timeout", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db), WithQueryTimeout(time.Minute))
+		request := mcp.CallToolRequest{
+			Params: mcp.CallToolParams{
+				Name: "execute_query",
+				Arguments: map[string]interface{}{
+					"query":      runaway,
+					"timeout_ms": 50,
+				},
+			},
+		}
+
+		result, err := qt.HandleTool(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+
+		text := testutil.GetTextContent(t, result.Content[0])
+		assert.Contains(t, text, "timed out")
+	})
+}
+
+func TestHandleCancelled(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	qt := New(testutil.Databases(t, db))
+	ctx := context.Background()
+
+	request := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: "execute_query",
+			Arguments: map[string]interface{}{
+				"query": "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c",
+			},
+		},
+	}
+	qt.TrackRequest(ctx, 7, &request)
+
+	done := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		result, err := qt.HandleTool(ctx, request)
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	// Keep cancelling until the call has registered itself and been interrupted
+	notification := mcp.JSONRPCNotification{
+		Notification: mcp.Notification{
+			Method: MethodNotificationCancelled,
+			Params: mcp.NotificationParams{
+				AdditionalFields: map[string]any{"requestId": float64(7)},
+			},
+		},
+	}
+
+	var result *mcp.CallToolResult
+	require.Eventually(t, func() bool {
+		qt.HandleCancelled(ctx, notification)
+		select {
+		case result = <-done:
+			return true
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.True(t, result.IsError)
+	text := testutil.GetTextContent(t, result.Content[0])
+	assert.Contains(t, text, "cancelled by the client")
 }
 
 func TestHandleListTables(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	request := mcp.CallToolRequest{
@@ -204,11 +889,87 @@ func TestHandleListTables(t *testing.T) {
 	assert.Contains(t, text, "products")
 }
 
+func TestHandleListSchemaObjects(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	qt := New(testutil.Databases(t, db))
+	ctx := context.Background()
+
+	call := func(name string, args map[string]interface{}) string {
+		t.Helper()
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		return testutil.GetTextContent(t, result.Content[0])
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Contains(t, call("list_views", nil), "No views found")
+		assert.Contains(t, call("list_triggers", nil), "No triggers found")
+		assert.Contains(t, call("list_indexes", map[string]interface{}{"table_name": "products"}), "No indexes found")
+	})
+
+	for _, stmt := range []string{
+		"CREATE VIEW adults AS SELECT name FROM users WHERE age >= 18",
+		"CREATE INDEX idx_products_name ON products (name)",
+		"CREATE TRIGGER users_audit AFTER UPDATE ON users BEGIN SELECT 1; END",
+		"CREATE VIRTUAL TABLE docs USING fts5(body)",
+	} {
+		_, err := db.ExecuteContext(ctx, stmt)
+		require.NoError(t, err)
+	}
+
+	t.Run("views", func(t *testing.T) {
+		text := call("list_views", nil)
+		assert.Contains(t, text, "adults")
+		assert.Contains(t, text, "FROM users WHERE age")
+	})
+
+	t.Run("indexes", func(t *testing.T) {
+		text := call("list_indexes", nil)
+		assert.Contains(t, text, "idx_products_name")
+		assert.Contains(t, text, "sqlite_autoindex_users_1")
+
+		text = call("list_indexes", map[string]interface{}{"table_name": "products"})
+		assert.Contains(t, text, "idx_products_name")
+		assert.NotContains(t, text, "sqlite_autoindex_users_1")
+	})
+
+	t.Run("triggers", func(t *testing.T) {
+		text := call("list_triggers", map[string]interface{}{"table_name": "users"})
+		assert.Contains(t, text, "users_audit")
+		assert.Contains(t, text, `"timing": "AFTER"`)
+		assert.Contains(t, text, `"event": "UPDATE"`)
+
+		assert.Contains(t, call("list_triggers", map[string]interface{}{"table_name": "products"}), "No triggers found")
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		for _, name := range []string{"list_indexes", "list_triggers"} {
+			result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+				Params: mcp.CallToolParams{Name: name, Arguments: map[string]interface{}{"table_name": "missing"}},
+			})
+			require.NoError(t, err)
+			assert.True(t, result.IsError)
+			assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "Table 'missing' not found")
+		}
+	})
+
+	t.Run("virtual tables", func(t *testing.T) {
+		text := call("list_tables", nil)
+		assert.Contains(t, text, `"module": "fts5"`)
+		assert.Contains(t, text, "docs_content")
+	})
+}
+
 func TestHandleDescribeTable(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	t.Run("existing table", func(t *testing.T) {
@@ -269,7 +1030,7 @@ func TestHandleUnknownTool(t *testing.T) {
 	db := testutil.CreateTestDB(t)
 	defer db.Close()
 
-	qt := New(db)
+	qt := New(testutil.Databases(t, db))
 	ctx := context.Background()
 
 	request := mcp.CallToolRequest{
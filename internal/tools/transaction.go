@@ -0,0 +1,348 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/authz"
+	"github.com/StacklokLabs/sqlite-mcp/internal/database"
+)
+
+// DefaultTransactionIdleTimeout is how long a session transaction may sit unused before it is
+// rolled back. Other sessions cannot write while it is open, so it is kept short.
+const DefaultTransactionIdleTimeout = 30 * time.Second
+
+// errNoTransaction is returned when a session has no open transaction
+var errNoTransaction = errors.New("no transaction is open in this session")
+
+// sessionTx is a transaction pinned to one MCP session and principal
+type sessionTx struct {
+	// mu serializes use of the transaction's connection
+	mu sync.Mutex
+	tx *database.Tx
+	// database is the name of the database the transaction runs on
+	database   string
+	savepoints []string
+	idle       *time.Timer
+	lastUsed   time.Time
+	closed     bool
+}
+
+// sessionTxs holds the open transaction of each MCP session and principal
+type sessionTxs struct {
+	mu          sync.Mutex
+	txs         map[owner]*sessionTx
+	idleTimeout time.Duration
+}
+
+// newSessionTxs creates an empty transaction registry
+func newSessionTxs(idleTimeout time.Duration) *sessionTxs {
+	return &sessionTxs{
+		txs:         make(map[owner]*sessionTx),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// get returns the open transaction of an owner, or nil
+func (st *sessionTxs) get(o owner) *sessionTx {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.txs[o]
+}
+
+// session returns the open transactions of a session, whoever owns them
+func (st *sessionTxs) session(id string) map[owner]*sessionTx {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	txs := make(map[owner]*sessionTx)
+	for o, stx := range st.txs {
+		if o.session == id {
+			txs[o] = stx
+		}
+	}
+	return txs
+}
+
+// add registers a new transaction on the named database for an owner and starts its idle timer
+func (st *sessionTxs) add(o owner, name string, tx *database.Tx) (*sessionTx, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, exists := st.txs[o]; exists {
+		return nil, errors.New("a transaction is already open in this session")
+	}
+
+	stx := &sessionTx{tx: tx, database: name, lastUsed: time.Now()}
+	if st.idleTimeout > 0 {
+		stx.idle = time.AfterFunc(st.idleTimeout, func() {
+			st.expire(o, stx)
+		})
+	}
+	st.txs[o] = stx
+	return stx, nil
+}
+
+// remove unregisters the transaction of an owner if it is still stx
+func (st *sessionTxs) remove(o owner, stx *sessionTx) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.txs[o] == stx {
+		delete(st.txs, o)
+	}
+}
+
+// expire rolls back an owner's transaction once it has been idle for the idle timeout
+func (st *sessionTxs) expire(o owner, stx *sessionTx) {
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+
+	// The timer may fire while a call is using the transaction; that call restarts it
+	if stx.closed || time.Since(stx.lastUsed) < st.idleTimeout {
+		return
+	}
+	log.Printf("Rolling back transaction of session %q after %s idle", o.session, st.idleTimeout)
+	st.rollback(o, stx)
+}
+
+// end rolls back and unregisters an owner's transaction
+func (st *sessionTxs) end(o owner, stx *sessionTx) {
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+
+	if stx.closed {
+		return
+	}
+	st.rollback(o, stx)
+}
+
+// rollback closes, unregisters and rolls back stx; the caller must hold stx.mu
+func (st *sessionTxs) rollback(o owner, stx *sessionTx) {
+	stx.close()
+	st.remove(o, stx)
+
+	if err := stx.tx.Rollback(context.Background()); err != nil {
+		log.Printf("Error rolling back transaction of session %q: %v", o.session, err)
+	}
+}
+
+// touch records a use and restarts the idle timer; the caller must hold stx.mu
+func (stx *sessionTx) touch(timeout time.Duration) {
+	stx.lastUsed = time.Now()
+	if stx.idle != nil {
+		stx.idle.Reset(timeout)
+	}
+}
+
+// close marks the transaction finished and stops its idle timer; the caller must hold stx.mu
+func (stx *sessionTx) close() {
+	stx.closed = true
+	if stx.idle != nil {
+		stx.idle.Stop()
+	}
+}
+
+// EndSession is an OnUnregisterSession hook that rolls back the session's open transactions
+func (qt *QueryTools) EndSession(_ context.Context, session server.ClientSession) {
+	for o, stx := range qt.txs.session(session.SessionID()) {
+		log.Printf("Rolling back transaction of ended session %q", session.SessionID())
+		qt.txs.end(o, stx)
+	}
+}
+
+// withExecutor runs fn with the session's transaction if one is open on the database the call
+// addresses, or with that database's shared pool, and with what the caller may do there.
+// write rejects read-only databases.
+func (qt *QueryTools) withExecutor(
+	ctx context.Context, request mcp.CallToolRequest, write bool, fn func(*authz.Grant, database.Executor) error,
+) error {
+	name, db, stx, err := qt.target(ctx, request)
+	if err != nil {
+		return err
+	}
+	grant, err := qt.grant(ctx, request, name)
+	if err != nil {
+		return err
+	}
+	if write && !db.Writable() {
+		return errReadOnly(name)
+	}
+	if stx == nil {
+		return fn(grant, db)
+	}
+
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+	if stx.closed {
+		return errNoTransaction
+	}
+	stx.touch(qt.txs.idleTimeout)
+	return fn(grant, stx.tx)
+}
+
+// beginTransactionTool creates the begin_transaction tool
+func (*QueryTools) beginTransactionTool() mcp.Tool {
+	return mcp.NewTool(
+		"begin_transaction",
+		mcp.WithDescription("Begin a transaction for this session. Later execute_query and execute_statement calls "+
+			"run inside it until commit_transaction or rollback_transaction. Pass savepoint to open a "+
+			"named savepoint, nested inside the transaction if one is already open."),
+		mcp.WithString("savepoint", mcp.Description("Optional savepoint name (letters, digits and underscores)")),
+		mcp.WithString("mode",
+			mcp.Description("Locking mode for a new transaction (default deferred)"),
+			mcp.Enum("deferred", "immediate", "exclusive")),
+		mcp.WithString("database",
+			mcp.Description("Name of the database to begin the transaction on; defaults to the default database")),
+	)
+}
+
+// commitTransactionTool creates the commit_transaction tool
+func (*QueryTools) commitTransactionTool() mcp.Tool {
+	return mcp.NewTool(
+		"commit_transaction",
+		mcp.WithDescription("Commit this session's transaction, or release a savepoint when savepoint is given"),
+		mcp.WithString("savepoint", mcp.Description("Optional savepoint to release instead of committing")),
+	)
+}
+
+// rollbackTransactionTool creates the rollback_transaction tool
+func (*QueryTools) rollbackTransactionTool() mcp.Tool {
+	return mcp.NewTool(
+		"rollback_transaction",
+		mcp.WithDescription("Roll back this session's transaction, or undo the changes since a savepoint when savepoint is given"),
+		mcp.WithString("savepoint", mcp.Description("Optional savepoint to roll back to; the savepoint stays open")),
+	)
+}
+
+// handleBeginTransaction starts a session transaction or opens a savepoint
+func (qt *QueryTools) handleBeginTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	o := ownerOf(ctx)
+	savepoint := mcp.ParseString(request, "savepoint", "")
+
+	name, db, stx, err := qt.target(ctx, request)
+	if err == nil {
+		_, err = qt.grant(ctx, request, name)
+	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to begin transaction", err), nil
+	}
+	if open := qt.txs.get(o); open != nil && stx == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("a transaction is already open in this session on database '%s'; "+
+			"commit or roll it back first", open.database)), nil
+	}
+
+	if stx == nil {
+		if !db.Writable() {
+			return mcp.NewToolResultErrorFromErr("Failed to begin transaction", errReadOnly(name)), nil
+		}
+
+		// The call's context bounds the wait for the writer; the transaction outlives it
+		tx, err := db.BeginTx(ctx, mcp.ParseString(request, "mode", ""))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to begin transaction", err), nil
+		}
+		if stx, err = qt.txs.add(o, name, tx); err != nil {
+			_ = tx.Rollback(context.Background())
+			return mcp.NewToolResultErrorFromErr("Failed to begin transaction", err), nil
+		}
+		if savepoint == "" {
+			return mcp.NewToolResultText("Transaction started"), nil
+		}
+	} else if savepoint == "" {
+		return mcp.NewToolResultError("a transaction is already open in this session; " +
+			"pass savepoint to nest, or commit or roll back first"), nil
+	}
+
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+	if stx.closed {
+		return mcp.NewToolResultErrorFromErr("Failed to create savepoint", errNoTransaction), nil
+	}
+	stx.touch(qt.txs.idleTimeout)
+
+	if err := stx.tx.Savepoint(ctx, savepoint); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create savepoint", err), nil
+	}
+	stx.savepoints = append(stx.savepoints, savepoint)
+
+	return mcp.NewToolResultText(fmt.Sprintf("Savepoint '%s' created", savepoint)), nil
+}
+
+// handleCommitTransaction commits the session transaction or releases a savepoint
+func (qt *QueryTools) handleCommitTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return qt.finishTransaction(ctx, request, true)
+}
+
+// handleRollbackTransaction rolls back the session transaction or to a savepoint
+func (qt *QueryTools) handleRollbackTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return qt.finishTransaction(ctx, request, false)
+}
+
+// finishTransaction implements commit_transaction and rollback_transaction
+func (qt *QueryTools) finishTransaction(
+	ctx context.Context, request mcp.CallToolRequest, commit bool,
+) (*mcp.CallToolResult, error) {
+	action, savepointAction := "Rolled back", "Rolled back to"
+	if commit {
+		action, savepointAction = "Committed", "Released"
+	}
+
+	o := ownerOf(ctx)
+	stx := qt.txs.get(o)
+	if stx == nil {
+		return mcp.NewToolResultErrorFromErr("Failed to finish transaction", errNoTransaction), nil
+	}
+
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+	if stx.closed {
+		return mcp.NewToolResultErrorFromErr("Failed to finish transaction", errNoTransaction), nil
+	}
+
+	if savepoint := mcp.ParseString(request, "savepoint", ""); savepoint != "" {
+		idx := slices.Index(stx.savepoints, savepoint)
+		if idx < 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("savepoint '%s' is not open", savepoint)), nil
+		}
+
+		var err error
+		if commit {
+			// RELEASE also releases every savepoint opened after this one
+			if err = stx.tx.ReleaseSavepoint(ctx, savepoint); err == nil {
+				stx.savepoints = stx.savepoints[:idx]
+			}
+		} else {
+			// ROLLBACK TO keeps this savepoint open but discards the later ones
+			if err = stx.tx.RollbackToSavepoint(ctx, savepoint); err == nil {
+				stx.savepoints = stx.savepoints[:idx+1]
+			}
+		}
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Savepoint operation failed", err), nil
+		}
+		stx.touch(qt.txs.idleTimeout)
+		return mcp.NewToolResultText(fmt.Sprintf("%s savepoint '%s'", savepointAction, savepoint)), nil
+	}
+
+	stx.close()
+	qt.txs.remove(o, stx)
+
+	var err error
+	if commit {
+		err = stx.tx.Commit(context.WithoutCancel(ctx))
+	} else {
+		err = stx.tx.Rollback(context.WithoutCancel(ctx))
+	}
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to finish transaction", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%s transaction", action)), nil
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/StacklokLabs/sqlite-mcp/internal/auth"
+	"github.com/StacklokLabs/sqlite-mcp/internal/testutil"
+)
+
+// testSession is a minimal client session for exercising session-scoped state
+type testSession struct {
+	id string
+}
+
+func (*testSession) Initialize()                                         {}
+func (*testSession) Initialized() bool                                   { return true }
+func (*testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *testSession) SessionID() string                                 { return s.id }
+
+func TestTransactions(t *testing.T) {
+	db := testutil.CreateTestDB(t)
+	defer db.Close()
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	call := func(t *testing.T, qt *QueryTools, ctx context.Context, name string, args map[string]interface{}) *mcp.CallToolResult {
+		t.Helper()
+		result, err := qt.HandleTool(ctx, mcp.CallToolRequest{
+			Params: mcp.CallToolParams{Name: name, Arguments: args},
+		})
+		require.NoError(t, err)
+		return result
+	}
+	countUsers := func(t *testing.T, qt *QueryTools, ctx context.Context) interface{} {
+		t.Helper()
+		result := call(t, qt, ctx, "execute_query", map[string]interface{}{"query": "SELECT count(*) FROM users"})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		page, ok := result.StructuredContent.(resultPage)
+		require.True(t, ok)
+		return page.Rows[0][0]
+	}
+	insert := map[string]interface{}{
+		"statement":  "INSERT INTO users (name, email, age) VALUES (?, ?, ?)",
+		"parameters": []interface{}{"Carol", "carol@example.com", float64(41)},
+	}
+
+	t.Run("rollback discards changes", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+		ctx := srv.WithContext(context.Background(), &testSession{id: "rollback"})
+
+		result := call(t, qt, ctx, "begin_transaction", nil)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+
+		result = call(t, qt, ctx, "execute_statement", insert)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, int64(3), countUsers(t, qt, ctx))
+
+		result = call(t, qt, ctx, "rollback_transaction", nil)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, int64(2), countUsers(t, qt, ctx))
+	})
+
+	t.Run("savepoints", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+		ctx := srv.WithContext(context.Background(), &testSession{id: "savepoints"})
+
+		require.False(t, call(t, qt, ctx, "begin_transaction", nil).IsError)
+		require.False(t, call(t, qt, ctx, "execute_statement", insert).IsError)
+
+		result := call(t, qt, ctx, "begin_transaction", map[string]interface{}{"savepoint": "cleanup"})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		require.False(t, call(t, qt, ctx, "execute_statement", map[string]interface{}{"statement": "DELETE FROM users WHERE age > 0"}).IsError)
+
+		result = call(t, qt, ctx, "rollback_transaction", map[string]interface{}{"savepoint": "cleanup"})
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, int64(3), countUsers(t, qt, ctx))
+
+		result = call(t, qt, ctx, "commit_transaction", map[string]interface{}{"savepoint": "missing"})
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "not open")
+
+		result = call(t, qt, ctx, "commit_transaction", nil)
+		require.False(t, result.IsError, testutil.GetTextContent(t, result.Content[0]))
+		assert.Equal(t, int64(3), countUsers(t, qt, context.Background()))
+
+		_, err := db.Execute("DELETE FROM users WHERE email = 'carol@example.com'")
+		require.NoError(t, err)
+	})
+
+	t.Run("transactions are per session", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+		ctx := srv.WithContext(context.Background(), &testSession{id: "mine"})
+		other := srv.WithContext(context.Background(), &testSession{id: "theirs"})
+
+		require.False(t, call(t, qt, ctx, "begin_transaction", nil).IsError)
+
+		result := call(t, qt, other, "commit_transaction", nil)
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "no transaction is open")
+
+		result = call(t, qt, ctx, "begin_transaction", nil)
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "already open")
+
+		require.False(t, call(t, qt, ctx, "rollback_transaction", nil).IsError)
+	})
+
+	t.Run("transactions are per principal", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+		session := srv.WithContext(context.Background(), &testSession{id: "shared"})
+		ctx := auth.WithPrincipal(session, &auth.Principal{Name: "alice"})
+		other := auth.WithPrincipal(session, &auth.Principal{Name: "bob"})
+
+		require.False(t, call(t, qt, ctx, "begin_transaction", nil).IsError)
+		require.False(t, call(t, qt, ctx, "execute_statement", insert).IsError)
+
+		// The other principal neither sees nor finishes the transaction, and cannot write meanwhile
+		assert.Equal(t, int64(2), countUsers(t, qt, other))
+		result := call(t, qt, other, "commit_transaction", nil)
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "no transaction is open")
+		result = call(t, qt, other, "execute_statement", insert)
+		assert.True(t, result.IsError)
+		assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "busy")
+
+		require.False(t, call(t, qt, ctx, "rollback_transaction", nil).IsError)
+		assert.Equal(t, int64(2), countUsers(t, qt, other))
+	})
+
+	t.Run("transaction statements are rejected", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+		ctx := srv.WithContext(context.Background(), &testSession{id: "raw"})
+
+		for _, stmt := range []string{"BEGIN", "COMMIT", "SAVEPOINT a", "ROLLBACK"} {
+			result := call(t, qt, ctx, "execute_statement", map[string]interface{}{"statement": stmt})
+			assert.True(t, result.IsError, stmt)
+			assert.Contains(t, testutil.GetTextContent(t, result.Content[0]), "begin_transaction", stmt)
+		}
+	})
+
+	t.Run("ended session rolls back", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db))
+		session := &testSession{id: "ended"}
+		ctx := srv.WithContext(context.Background(), session)
+
+		require.False(t, call(t, qt, ctx, "begin_transaction", nil).IsError)
+		require.False(t, call(t, qt, ctx, "execute_statement", insert).IsError)
+
+		qt.EndSession(context.Background(), session)
+		assert.Nil(t, qt.txs.get(owner{session: session.id}))
+		assert.Equal(t, int64(2), countUsers(t, qt, context.Background()))
+	})
+
+	t.Run("idle transaction rolls back", func(t *testing.T) {
+		qt := New(testutil.Databases(t, db), WithTransactionIdleTimeout(50*time.Millisecond))
+		ctx := srv.WithContext(context.Background(), &testSession{id: "idle"})
+
+		require.False(t, call(t, qt, ctx, "begin_transaction", nil).IsError)
+		require.False(t, call(t, qt, ctx, "execute_statement", insert).IsError)
+
+		require.Eventually(t, func() bool {
+			return qt.txs.get(owner{session: "idle"}) == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(2), countUsers(t, qt, ctx))
+
+		result := call(t, qt, ctx, "commit_transaction", nil)
+		assert.True(t, result.IsError)
+	})
+}